@@ -29,12 +29,122 @@ type ClusterScalingStateSpec struct {
 	// The State field represents the desired state for the cluster
 	State        string `json:"state"`
 	ScalingClass string `json:"scalingClass,omitempty"`
+	// Paused stops all scaling of the items of the scaling class. Step scaling in progress stops at the next step
+	// without putting the items in failure state. The items are reconciled again once it's unpaused.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 }
 
 // ClusterScalingStateStatus defines the observed state of ClusterScalingState
 type ClusterScalingStateStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// FailedItems lists the ScalingItems of this scaling class the operator gave up scaling.
+	// They are retried once the "scaler/retry-now" annotation is set or changed on the workload.
+	FailedItems []ScalingItemFailure `json:"failedItems,omitempty"`
+	// Capacity is the result of the last capacity check of a transition. Only set if the operator runs with --capacity-check.
+	Capacity *ClusterCapacity `json:"capacity,omitempty"`
+	// Transition is the last transition of the scaling class and the status of its hooks
+	Transition *TransitionStatus `json:"transition,omitempty"`
+	// DriftedItems lists the ScalingItems of this scaling class whose replicas were changed by someone else
+	// and that the operator didn't revert, as their enforcement mode is "warn".
+	DriftedItems []ReplicaDrift `json:"driftedItems,omitempty"`
+}
+
+// Phases of a transition
+const (
+	// TransitionPreHooks means the pre hooks run. The scaling class keeps the previous state until they are done.
+	TransitionPreHooks = "PreHooks"
+	// TransitionScaling means the items of the scaling class are scaled to the new state
+	TransitionScaling = "Scaling"
+	// TransitionPostHooks means the post hooks run
+	TransitionPostHooks = "PostHooks"
+	// TransitionCompleted means the items were scaled and all hooks ran
+	TransitionCompleted = "Completed"
+	// TransitionAborted means a pre hook failed. The scaling class keeps the previous state.
+	TransitionAborted = "Aborted"
+	// TransitionFailed means a post hook failed
+	TransitionFailed = "Failed"
+)
+
+// Phases of a hook
+const (
+	HookPending   = "Pending"
+	HookRunning   = "Running"
+	HookSucceeded = "Succeeded"
+	HookFailed    = "Failed"
+	// HookSkipped means the hook was removed from the ClusterScalingStateDefinition during the transition
+	HookSkipped = "Skipped"
+)
+
+// Stages of a hook
+const (
+	HookStagePre  = "Pre"
+	HookStagePost = "Post"
+)
+
+// TransitionStatus tracks a transition of the scaling class from one state to another
+type TransitionStatus struct {
+	// FromState is the state before the transition. Empty for the first state of the scaling class.
+	FromState      string       `json:"fromState,omitempty"`
+	State          string       `json:"state"`
+	Phase          string       `json:"phase"`
+	StartTime      metav1.Time  `json:"startTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	Hooks          []HookStatus `json:"hooks,omitempty"`
+}
+
+// HookStatus is the status of a hook of a transition
+type HookStatus struct {
+	Name  string `json:"name"`
+	Stage string `json:"stage"`
+	Phase string `json:"phase"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// JobName is the name of the Job created for the hook
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ClusterCapacity tells if the nodes have enough allocatable resources left for the pods a transition adds
+type ClusterCapacity struct {
+	Fits bool `json:"fits"`
+	// NewPods is the number of pods the transition adds
+	NewPods int32 `json:"newPods"`
+	// UnschedulablePods is the number of new pods that don't fit on any node
+	UnschedulablePods int32 `json:"unschedulablePods,omitempty"`
+	// Shortfall is the sum of the requests of the unschedulable pods. It's what a cluster autoscaler would need to add.
+	Shortfall map[string]string `json:"shortfall,omitempty"`
+}
+
+// ScalingItemFailure describes a ScalingItem that failed to scale too many times
+type ScalingItemFailure struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	// Attempts is the number of times the operator tried to scale the item
+	Attempts int32 `json:"attempts"`
+	// Message is the last error the operator ran into
+	Message string `json:"message,omitempty"`
+	// GaveUpTime is the time the operator stopped retrying
+	GaveUpTime metav1.Time `json:"gaveUpTime,omitempty"`
+}
+
+// ReplicaDrift describes a replica change of a ScalingItem the operator reported instead of reverting
+type ReplicaDrift struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	// Replicas is the replica count the item was changed to
+	Replicas int32 `json:"replicas"`
+	// DesiredReplicas is the replica count of the state applied on the item
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// Actor is the field manager that changed the replicas, as far as the managed fields of the item tell
+	Actor string `json:"actor,omitempty"`
+	// DetectedTime is the time the operator noticed the change
+	DetectedTime metav1.Time `json:"detectedTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
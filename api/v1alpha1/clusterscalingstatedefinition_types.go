@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,6 +33,110 @@ type States struct {
 	// Use priority to mark one state more important than another.
 	// Priority 1 is "higher" priority than priority 10
 	Priority int32 `json:"priority"`
+	// Hooks run on the transitions of a scaling class to this state
+	// +optional
+	Hooks *TransitionHooks `json:"hooks,omitempty"`
+	// Analysis gates the steps of the step scaler while items are scaled to this state
+	// +optional
+	Analysis *AnalysisGate `json:"analysis,omitempty"`
+}
+
+// What the step scaler does when an analysis gate fails
+const (
+	// AnalysisPause waits and evaluates the gate again until it passes or the pause times out
+	AnalysisPause = "Pause"
+	// AnalysisContinue reports the failure and takes the next step anyway
+	AnalysisContinue = "Continue"
+	// AnalysisAbort stops scaling the item and puts it in failure state
+	AnalysisAbort = "Abort"
+)
+
+// AnalysisGate queries a Prometheus compatible API between the steps of the step scaler.
+// The next step is only taken if every sample of the result meets the threshold.
+type AnalysisGate struct {
+	// Address of the Prometheus compatible API, like http://prometheus.monitoring:9090.
+	// Defaults to the --analysis-prometheus-url flag of the operator.
+	// +optional
+	Address string `json:"address,omitempty"`
+	// Query is an instant PromQL query. ${namespace} and ${name} are replaced with the namespace and name of the workload.
+	Query string `json:"query"`
+	// Threshold is a comparison every sample must meet, like "< 0.05" or ">= 0.99"
+	// +kubebuilder:validation:Pattern=`^\s*(<|<=|>|>=|==|!=)\s*\S+\s*$`
+	Threshold string `json:"threshold"`
+	// OnFailure is Pause (default), Continue or Abort. A query that fails or returns no data counts as a failure.
+	// +optional
+	// +kubebuilder:validation:Enum=Pause;Continue;Abort
+	OnFailure string `json:"onFailure,omitempty"`
+	// IntervalSeconds between the evaluations while paused. Defaults to 30 seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+	// PauseTimeoutSeconds puts the item in failure state if the gate didn't pass again in time. Defaults to 600 seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PauseTimeoutSeconds int32 `json:"pauseTimeoutSeconds,omitempty"`
+}
+
+// Failure policies of transition hooks
+const (
+	// HookFailureAbort stops the transition. A failed pre hook keeps the previous state.
+	HookFailureAbort = "Abort"
+	// HookFailureContinue carries on with the next hook and the transition
+	HookFailureContinue = "Continue"
+)
+
+// TransitionHooks run before and after the transition of a scaling class to a state.
+// Pre hooks run before any item is scaled, post hooks once all items of the class finished scaling.
+type TransitionHooks struct {
+	// +optional
+	Pre []TransitionHook `json:"pre,omitempty"`
+	// +optional
+	Post []TransitionHook `json:"post,omitempty"`
+}
+
+// TransitionHook is a Job or an HTTP call. Exactly one of them must be set.
+type TransitionHook struct {
+	// Name of the hook. It must be unique among the pre or post hooks of a transition.
+	Name string `json:"name"`
+	// +optional
+	Job *JobHook `json:"job,omitempty"`
+	// +optional
+	HTTP *HTTPHook `json:"http,omitempty"`
+	// TimeoutSeconds fails the hook if it didn't succeed in time. Defaults to 30 seconds for HTTP calls
+	// and 600 seconds for Jobs.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// FailurePolicy is Abort (default) or Continue
+	// +optional
+	// +kubebuilder:validation:Enum=Abort;Continue
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
+// JobHook runs a Job. The class, the state before and the new state are passed as the environment variables
+// PRESCALE_SCALING_CLASS, PRESCALE_FROM_STATE and PRESCALE_STATE to all containers.
+type JobHook struct {
+	// Namespace of the Job. Defaults to the namespace of the operator.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Template of the Job
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template batchv1beta1.JobTemplateSpec `json:"template"`
+}
+
+// HTTPHook calls an URL. Any 2xx answer is a success.
+type HTTPHook struct {
+	URL string `json:"url"`
+	// Method of the request. Defaults to POST.
+	// +optional
+	Method string `json:"method,omitempty"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body of the request. Defaults to a JSON object with scalingClass, fromState, state and hook.
+	// +optional
+	Body string `json:"body,omitempty"`
 }
 
 // ClusterScalingStateDefinitionStatus defines the observed state of ClusterScalingStateDefinition
@@ -56,7 +161,9 @@ type ClusterScalingStateDefinition struct {
 
 	Spec   []States                                   `json:"spec,omitempty"`
 	Config ClusterScalingStateDefinitionConfiguration `json:"config,omitempty"`
-	Status ClusterScalingStateDefinitionStatus        `json:"status,omitempty"`
+	// Hooks run on every transition of a scaling class, before the hooks of the state
+	Hooks  *TransitionHooks                    `json:"hooks,omitempty"`
+	Status ClusterScalingStateDefinitionStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,119 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OperatorConfigName is the name of the PreScalingOperatorConfig the operator reads. Objects with other names are ignored.
+const OperatorConfigName = "default"
+
+// PreScalingOperatorConfigSpec defines the configuration of the operator. Fields left out keep their defaults.
+type PreScalingOperatorConfigSpec struct {
+	// MaxConcurrentNamespaceReconciles is the number of namespaces that are scaled at the same time
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentNamespaceReconciles *int32 `json:"maxConcurrentNamespaceReconciles,omitempty"`
+	// RetriggerControllerSeconds is the delay before the state controllers retry namespaces they could not scale yet
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RetriggerControllerSeconds *int32 `json:"retriggerControllerSeconds,omitempty"`
+	// OptInLabel is the key of the label that opts a workload in. Its value has to be "true".
+	// +optional
+	OptInLabel string `json:"optInLabel,omitempty"`
+	// OptInSelector selects the workloads the operator scales. If set, it is used instead of the OptInLabel.
+	// +optional
+	OptInSelector *metav1.LabelSelector `json:"optInSelector,omitempty"`
+	// IncludeNamespaces restricts the operator to these namespaces. Only these namespaces are watched, so the operator can
+	// run with Roles in them instead of cluster-wide permissions on the workloads. Changes only take effect after a restart.
+	// +optional
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+	// ExcludeNamespaces are never scaled
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// NamespaceSelector restricts the operator to the namespaces with matching labels
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// QuotaPolicy decides what happens if a scale-up exceeds the ResourceQuotas of a namespace
+	// +kubebuilder:validation:Enum=all-or-nothing;proportional;priority
+	// +optional
+	QuotaPolicy string `json:"quotaPolicy,omitempty"`
+	// CapacityCheck checks transitions against the allocatable resources of the nodes
+	// +optional
+	CapacityCheck *bool `json:"capacityCheck,omitempty"`
+	// MaxConcurrentReconciles is the number of objects a controller reconciles in parallel, by controller name.
+	// Changes only take effect after a restart of the operator.
+	// +optional
+	MaxConcurrentReconciles map[string]int32 `json:"maxConcurrentReconciles,omitempty"`
+}
+
+// OperatorConfigValues are the configuration values in effect
+type OperatorConfigValues struct {
+	MaxConcurrentNamespaceReconciles int32                 `json:"maxConcurrentNamespaceReconciles"`
+	RetriggerControllerSeconds       int32                 `json:"retriggerControllerSeconds"`
+	OptInLabel                       string                `json:"optInLabel"`
+	OptInSelector                    *metav1.LabelSelector `json:"optInSelector,omitempty"`
+	IncludeNamespaces                []string              `json:"includeNamespaces,omitempty"`
+	ExcludeNamespaces                []string              `json:"excludeNamespaces,omitempty"`
+	NamespaceSelector                *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	QuotaPolicy                      string                `json:"quotaPolicy"`
+	CapacityCheck                    bool                  `json:"capacityCheck"`
+	MaxConcurrentReconciles          map[string]int32      `json:"maxConcurrentReconciles,omitempty"`
+}
+
+// PreScalingOperatorConfigStatus defines the observed state of PreScalingOperatorConfig
+type PreScalingOperatorConfigStatus struct {
+	// Valid tells if the spec was accepted. An invalid spec leaves the last valid configuration in effect.
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec the status belongs to
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Effective holds the values in effect, including the defaults of the fields left out of the spec
+	Effective *OperatorConfigValues `json:"effective,omitempty"`
+	// RestartRequired lists the settings that differ from the ones the operator runs with and only change after a restart:
+	// controllers whose MaxConcurrentReconciles changed and includeNamespaces
+	RestartRequired []string `json:"restartRequired,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=prescalingoperatorconfigs,scope=Cluster
+// +kubebuilder:printcolumn:name="Valid",type=boolean,JSONPath=`.status.valid`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`
+
+// PreScalingOperatorConfig is the Schema for the prescalingoperatorconfigs API
+type PreScalingOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PreScalingOperatorConfigSpec   `json:"spec,omitempty"`
+	Status PreScalingOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PreScalingOperatorConfigList contains a list of PreScalingOperatorConfig
+type PreScalingOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreScalingOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PreScalingOperatorConfig{}, &PreScalingOperatorConfigList{})
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of notifiers
+const (
+	// NotifierWebhook posts the event as JSON
+	NotifierWebhook = "webhook"
+	// NotifierSlack posts a message to a Slack compatible incoming webhook
+	NotifierSlack = "slack"
+	// NotifierCloudEvents posts the event as a CloudEvent in binary content mode
+	NotifierCloudEvents = "cloudevents"
+)
+
+// Events a ScalingNotification can subscribe to
+const (
+	// EventTransitionStarted is sent when the items of a namespace start moving to a new state
+	EventTransitionStarted = "TransitionStarted"
+	// EventTransitionFinished is sent once all items of the transition finished scaling
+	EventTransitionFinished = "TransitionFinished"
+	// EventQuotaRejected is sent when the ResourceQuotas of a namespace deny a scaling decision
+	EventQuotaRejected = "QuotaRejected"
+	// EventItemFailed is sent when the scaler puts an item in failure state
+	EventItemFailed = "ItemFailed"
+)
+
+// ScalingNotificationSpec defines the desired state of ScalingNotification
+type ScalingNotificationSpec struct {
+	// Type of the notifier. One of webhook, slack or cloudevents.
+	// +kubebuilder:validation:Enum=webhook;slack;cloudevents
+	Type string `json:"type"`
+
+	// URL the notifications are posted to
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// URLFrom reads the URL from a key of a Secret in the namespace of the operator. Use it for URLs that
+	// contain a secret, like Slack webhooks. It takes precedence over URL.
+	// +optional
+	URLFrom *corev1.SecretKeySelector `json:"urlFrom,omitempty"`
+
+	// Events to send. All events are sent if empty.
+	// +optional
+	Events []string `json:"events,omitempty"`
+
+	// ScalingClasses limits the notifications to events of these scaling classes
+	// +optional
+	ScalingClasses []string `json:"scalingClasses,omitempty"`
+
+	// Namespaces limits the notifications to events of these namespaces
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// ScalingNotificationStatus defines the observed state of ScalingNotification
+type ScalingNotificationStatus struct {
+	LastSentTime *metav1.Time `json:"lastSentTime,omitempty"`
+	LastEvent    string       `json:"lastEvent,omitempty"`
+	// LastError is the error of the last notification. It's cleared once a notification is sent.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=scalingnotifications,scope=Cluster
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Last Sent",type=date,JSONPath=`.status.lastSentTime`
+// +kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.lastError`
+
+// ScalingNotification is the Schema for the scalingnotifications API
+type ScalingNotification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScalingNotificationSpec   `json:"spec,omitempty"`
+	Status ScalingNotificationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ScalingNotificationList contains a list of ScalingNotification
+type ScalingNotificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScalingNotification `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScalingNotification{}, &ScalingNotificationList{})
+}
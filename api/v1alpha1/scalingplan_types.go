@@ -0,0 +1,111 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Phases of a ScalingPlan
+const (
+	// ScalingPlanPending means the plan is computed and waits for approval
+	ScalingPlanPending = "Pending"
+	// ScalingPlanStale means the cluster changed since the plan was computed. It won't be applied.
+	ScalingPlanStale = "Stale"
+	// ScalingPlanApplied means the state of the plan was set on the ClusterScalingState
+	ScalingPlanApplied = "Applied"
+	// ScalingPlanFailed means the plan could not be computed or applied
+	ScalingPlanFailed = "Failed"
+)
+
+// ScalingPlanSpec defines the desired state of ScalingPlan
+type ScalingPlanSpec struct {
+	// State is the state the plan would set on the ClusterScalingState
+	State string `json:"state"`
+	// ScalingClass is the scope of the plan. Only items of this class are planned and only the
+	// ClusterScalingState of this class is changed. Defaults to the default class.
+	ScalingClass string `json:"scalingClass,omitempty"`
+	// Approved applies the plan, as long as it's not stale
+	Approved bool `json:"approved,omitempty"`
+}
+
+// ScalingPlanStatus defines the observed state of ScalingPlan
+type ScalingPlanStatus struct {
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	// State and ScalingClass the plan was computed for
+	State        string `json:"state,omitempty"`
+	ScalingClass string `json:"scalingClass,omitempty"`
+	// ObservedGeneration is the generation of the plan the changes were computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// PlanHash identifies the computed changes. If a recomputation leads to another hash, the plan is stale.
+	PlanHash     string       `json:"planHash,omitempty"`
+	ComputedTime metav1.Time  `json:"computedTime,omitempty"`
+	AppliedTime  *metav1.Time `json:"appliedTime,omitempty"`
+	// Namespaces holds the computed changes per namespace
+	Namespaces []ScalingPlanNamespace `json:"namespaces,omitempty"`
+}
+
+// ScalingPlanNamespace holds the planned changes and the quota verdict of a namespace
+type ScalingPlanNamespace struct {
+	Namespace    string `json:"namespace"`
+	QuotaAllowed bool   `json:"quotaAllowed"`
+	// QuotaHeadroom holds the resources left in the ResourceQuotas after scaling, negative if exceeded
+	QuotaHeadroom map[string]string `json:"quotaHeadroom,omitempty"`
+	Items         []ScalingPlanItem `json:"items,omitempty"`
+}
+
+// ScalingPlanItem is the planned change of one ScalingItem
+type ScalingPlanItem struct {
+	Name            string `json:"name"`
+	Kind            string `json:"kind"`
+	CurrentReplicas int32  `json:"currentReplicas"`
+	DesiredReplicas int32  `json:"desiredReplicas"`
+	Mode            string `json:"mode,omitempty"`
+	// Blockers are the reasons the item would not be scaled
+	Blockers []string `json:"blockers,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=scalingplans,scope=Cluster
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.spec.state`
+// +kubebuilder:printcolumn:name="Class",type=string,JSONPath=`.spec.scalingClass`
+// +kubebuilder:printcolumn:name="Approved",type=boolean,JSONPath=`.spec.approved`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+
+// ScalingPlan is the Schema for the scalingplans API
+type ScalingPlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScalingPlanSpec   `json:"spec,omitempty"`
+	Status ScalingPlanStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ScalingPlanList contains a list of ScalingPlan
+type ScalingPlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScalingPlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScalingPlan{}, &ScalingPlanList{})
+}
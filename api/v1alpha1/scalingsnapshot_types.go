@@ -0,0 +1,136 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Phases of a ScalingSnapshot
+const (
+	// ScalingSnapshotCaptured means the snapshot holds the captured state and wasn't restored
+	ScalingSnapshotCaptured = "Captured"
+	// ScalingSnapshotRestored means the captured state was restored
+	ScalingSnapshotRestored = "Restored"
+	// ScalingSnapshotFailed means the snapshot could not be captured or restored
+	ScalingSnapshotFailed = "Failed"
+)
+
+// ScalingSnapshotSpec defines the desired state of ScalingSnapshot
+type ScalingSnapshotSpec struct {
+	// Restore makes the operator restore the snapshot: the scaling custom resources first, then the items that
+	// differ from the snapshot are reconciled to the replicas of their restored states. It's restored once per
+	// change of the spec.
+	// +optional
+	Restore bool `json:"restore,omitempty"`
+
+	// Snapshot is the captured state. The operator captures it if the ScalingSnapshot is created without one.
+	// A ScalingSnapshot saved to a file keeps it, so it can be restored into a rebuilt cluster.
+	// +optional
+	Snapshot *SnapshotData `json:"snapshot,omitempty"`
+}
+
+// SnapshotData holds the scaling custom resources and the replicas of all opted-in items at a point in time
+type SnapshotData struct {
+	CapturedTime                   metav1.Time                   `json:"capturedTime"`
+	ClusterScalingStateDefinitions []SnapshotStateDefinition     `json:"clusterScalingStateDefinitions,omitempty"`
+	ClusterScalingStates           []SnapshotClusterScalingState `json:"clusterScalingStates,omitempty"`
+	ScalingStates                  []SnapshotScalingState        `json:"scalingStates,omitempty"`
+	Items                          []SnapshotItem                `json:"items,omitempty"`
+}
+
+// SnapshotStateDefinition is a captured ClusterScalingStateDefinition
+type SnapshotStateDefinition struct {
+	Name   string           `json:"name"`
+	States []States         `json:"states,omitempty"`
+	Hooks  *TransitionHooks `json:"hooks,omitempty"`
+	DryRun bool             `json:"dryRun,omitempty"`
+}
+
+// SnapshotClusterScalingState is a captured ClusterScalingState
+type SnapshotClusterScalingState struct {
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	ScalingClass string `json:"scalingClass,omitempty"`
+}
+
+// SnapshotScalingState is a captured ScalingState
+type SnapshotScalingState struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	DryRun    bool   `json:"dryRun,omitempty"`
+}
+
+// SnapshotItem holds the captured replicas of a scaling item
+type SnapshotItem struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Replicas  int32  `json:"replicas"`
+}
+
+// SnapshotItemDrift is an item whose replicas differ from the snapshot
+type SnapshotItemDrift struct {
+	Namespace        string `json:"namespace"`
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	SnapshotReplicas int32  `json:"snapshotReplicas"`
+	// CurrentReplicas is -1 if the item doesn't exist anymore
+	CurrentReplicas int32 `json:"currentReplicas"`
+}
+
+// ScalingSnapshotStatus defines the observed state of ScalingSnapshot
+type ScalingSnapshotStatus struct {
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Drift lists the items whose replicas differ from the snapshot, as of CheckedTime
+	Drift       []SnapshotItemDrift `json:"drift,omitempty"`
+	CheckedTime *metav1.Time        `json:"checkedTime,omitempty"`
+	// RestoredGeneration is the generation of the spec that was restored last
+	RestoredGeneration int64        `json:"restoredGeneration,omitempty"`
+	RestoredTime       *metav1.Time `json:"restoredTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=scalingsnapshots,scope=Cluster
+// +kubebuilder:printcolumn:name="Captured",type=date,JSONPath=`.spec.snapshot.capturedTime`
+// +kubebuilder:printcolumn:name="Restore",type=boolean,JSONPath=`.spec.restore`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+
+// ScalingSnapshot is the Schema for the scalingsnapshots API
+type ScalingSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScalingSnapshotSpec   `json:"spec,omitempty"`
+	Status ScalingSnapshotStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ScalingSnapshotList contains a list of ScalingSnapshot
+type ScalingSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScalingSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScalingSnapshot{}, &ScalingSnapshotList{})
+}
@@ -30,6 +30,10 @@ type ScalingStateSpec struct {
 
 	// // The State field represents the desired state for the namespace
 	State string `json:"state"`
+	// Paused stops all scaling in the namespace. Step scaling in progress stops at the next step
+	// without putting the items in failure state. The items are reconciled again once it's unpaused.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 }
 
 // ScalingStateStatus defines the observed state of ScalingState
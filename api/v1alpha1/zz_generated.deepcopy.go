@@ -21,9 +21,48 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisGate) DeepCopyInto(out *AnalysisGate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisGate.
+func (in *AnalysisGate) DeepCopy() *AnalysisGate {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCapacity) DeepCopyInto(out *ClusterCapacity) {
+	*out = *in
+	if in.Shortfall != nil {
+		in, out := &in.Shortfall, &out.Shortfall
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCapacity.
+func (in *ClusterCapacity) DeepCopy() *ClusterCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterScalingState) DeepCopyInto(out *ClusterScalingState) {
 	*out = *in
@@ -31,7 +70,7 @@ func (in *ClusterScalingState) DeepCopyInto(out *ClusterScalingState) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
 	out.Config = in.Config
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterScalingState.
@@ -75,9 +114,16 @@ func (in *ClusterScalingStateDefinition) DeepCopyInto(out *ClusterScalingStateDe
 	if in.Spec != nil {
 		in, out := &in.Spec, &out.Spec
 		*out = make([]States, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	out.Config = in.Config
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(TransitionHooks)
+		(*in).DeepCopyInto(*out)
+	}
 	out.Status = in.Status
 }
 
@@ -211,6 +257,30 @@ func (in *ClusterScalingStateSpec) DeepCopy() *ClusterScalingStateSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterScalingStateStatus) DeepCopyInto(out *ClusterScalingStateStatus) {
 	*out = *in
+	if in.FailedItems != nil {
+		in, out := &in.FailedItems, &out.FailedItems
+		*out = make([]ScalingItemFailure, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = new(ClusterCapacity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transition != nil {
+		in, out := &in.Transition, &out.Transition
+		*out = new(TransitionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DriftedItems != nil {
+		in, out := &in.DriftedItems, &out.DriftedItems
+		*out = make([]ReplicaDrift, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterScalingStateStatus.
@@ -224,27 +294,129 @@ func (in *ClusterScalingStateStatus) DeepCopy() *ClusterScalingStateStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ScalingState) DeepCopyInto(out *ScalingState) {
+func (in *HTTPHook) DeepCopyInto(out *HTTPHook) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHook.
+func (in *HTTPHook) DeepCopy() *HTTPHook {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookStatus) DeepCopyInto(out *HookStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookStatus.
+func (in *HookStatus) DeepCopy() *HookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobHook) DeepCopyInto(out *JobHook) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobHook.
+func (in *JobHook) DeepCopy() *JobHook {
+	if in == nil {
+		return nil
+	}
+	out := new(JobHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigValues) DeepCopyInto(out *OperatorConfigValues) {
+	*out = *in
+	if in.OptInSelector != nil {
+		in, out := &in.OptInSelector, &out.OptInSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IncludeNamespaces != nil {
+		in, out := &in.IncludeNamespaces, &out.IncludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxConcurrentReconciles != nil {
+		in, out := &in.MaxConcurrentReconciles, &out.MaxConcurrentReconciles
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigValues.
+func (in *OperatorConfigValues) DeepCopy() *OperatorConfigValues {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigValues)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreScalingOperatorConfig) DeepCopyInto(out *PreScalingOperatorConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Config = in.Config
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingState.
-func (in *ScalingState) DeepCopy() *ScalingState {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreScalingOperatorConfig.
+func (in *PreScalingOperatorConfig) DeepCopy() *PreScalingOperatorConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ScalingState)
+	out := new(PreScalingOperatorConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ScalingState) DeepCopyObject() runtime.Object {
+func (in *PreScalingOperatorConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -252,46 +424,204 @@ func (in *ScalingState) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ScalingStateConfiguration) DeepCopyInto(out *ScalingStateConfiguration) {
+func (in *PreScalingOperatorConfigList) DeepCopyInto(out *PreScalingOperatorConfigList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PreScalingOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateConfiguration.
-func (in *ScalingStateConfiguration) DeepCopy() *ScalingStateConfiguration {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreScalingOperatorConfigList.
+func (in *PreScalingOperatorConfigList) DeepCopy() *PreScalingOperatorConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(ScalingStateConfiguration)
+	out := new(PreScalingOperatorConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreScalingOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ScalingStateList) DeepCopyInto(out *ScalingStateList) {
+func (in *PreScalingOperatorConfigSpec) DeepCopyInto(out *PreScalingOperatorConfigSpec) {
+	*out = *in
+	if in.MaxConcurrentNamespaceReconciles != nil {
+		in, out := &in.MaxConcurrentNamespaceReconciles, &out.MaxConcurrentNamespaceReconciles
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetriggerControllerSeconds != nil {
+		in, out := &in.RetriggerControllerSeconds, &out.RetriggerControllerSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OptInSelector != nil {
+		in, out := &in.OptInSelector, &out.OptInSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IncludeNamespaces != nil {
+		in, out := &in.IncludeNamespaces, &out.IncludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CapacityCheck != nil {
+		in, out := &in.CapacityCheck, &out.CapacityCheck
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxConcurrentReconciles != nil {
+		in, out := &in.MaxConcurrentReconciles, &out.MaxConcurrentReconciles
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreScalingOperatorConfigSpec.
+func (in *PreScalingOperatorConfigSpec) DeepCopy() *PreScalingOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreScalingOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreScalingOperatorConfigStatus) DeepCopyInto(out *PreScalingOperatorConfigStatus) {
+	*out = *in
+	if in.Effective != nil {
+		in, out := &in.Effective, &out.Effective
+		*out = new(OperatorConfigValues)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartRequired != nil {
+		in, out := &in.RestartRequired, &out.RestartRequired
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreScalingOperatorConfigStatus.
+func (in *PreScalingOperatorConfigStatus) DeepCopy() *PreScalingOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreScalingOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaDrift) DeepCopyInto(out *ReplicaDrift) {
+	*out = *in
+	in.DetectedTime.DeepCopyInto(&out.DetectedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaDrift.
+func (in *ReplicaDrift) DeepCopy() *ReplicaDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingItemFailure) DeepCopyInto(out *ScalingItemFailure) {
+	*out = *in
+	in.GaveUpTime.DeepCopyInto(&out.GaveUpTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingItemFailure.
+func (in *ScalingItemFailure) DeepCopy() *ScalingItemFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingItemFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingNotification) DeepCopyInto(out *ScalingNotification) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingNotification.
+func (in *ScalingNotification) DeepCopy() *ScalingNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingNotification) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingNotificationList) DeepCopyInto(out *ScalingNotificationList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ScalingState, len(*in))
+		*out = make([]ScalingNotification, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateList.
-func (in *ScalingStateList) DeepCopy() *ScalingStateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingNotificationList.
+func (in *ScalingNotificationList) DeepCopy() *ScalingNotificationList {
 	if in == nil {
 		return nil
 	}
-	out := new(ScalingStateList)
+	out := new(ScalingNotificationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ScalingStateList) DeepCopyObject() runtime.Object {
+func (in *ScalingNotificationList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -299,46 +629,648 @@ func (in *ScalingStateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ScalingStateSpec) DeepCopyInto(out *ScalingStateSpec) {
+func (in *ScalingNotificationSpec) DeepCopyInto(out *ScalingNotificationSpec) {
 	*out = *in
+	if in.URLFrom != nil {
+		in, out := &in.URLFrom, &out.URLFrom
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScalingClasses != nil {
+		in, out := &in.ScalingClasses, &out.ScalingClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateSpec.
-func (in *ScalingStateSpec) DeepCopy() *ScalingStateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingNotificationSpec.
+func (in *ScalingNotificationSpec) DeepCopy() *ScalingNotificationSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ScalingStateSpec)
+	out := new(ScalingNotificationSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ScalingStateStatus) DeepCopyInto(out *ScalingStateStatus) {
+func (in *ScalingNotificationStatus) DeepCopyInto(out *ScalingNotificationStatus) {
 	*out = *in
+	if in.LastSentTime != nil {
+		in, out := &in.LastSentTime, &out.LastSentTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateStatus.
-func (in *ScalingStateStatus) DeepCopy() *ScalingStateStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingNotificationStatus.
+func (in *ScalingNotificationStatus) DeepCopy() *ScalingNotificationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ScalingStateStatus)
+	out := new(ScalingNotificationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *States) DeepCopyInto(out *States) {
+func (in *ScalingPlan) DeepCopyInto(out *ScalingPlan) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new States.
-func (in *States) DeepCopy() *States {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPlan.
+func (in *ScalingPlan) DeepCopy() *ScalingPlan {
 	if in == nil {
 		return nil
 	}
-	out := new(States)
+	out := new(ScalingPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingPlan) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPlanItem) DeepCopyInto(out *ScalingPlanItem) {
+	*out = *in
+	if in.Blockers != nil {
+		in, out := &in.Blockers, &out.Blockers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPlanItem.
+func (in *ScalingPlanItem) DeepCopy() *ScalingPlanItem {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPlanItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPlanList) DeepCopyInto(out *ScalingPlanList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScalingPlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPlanList.
+func (in *ScalingPlanList) DeepCopy() *ScalingPlanList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPlanList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingPlanList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPlanNamespace) DeepCopyInto(out *ScalingPlanNamespace) {
+	*out = *in
+	if in.QuotaHeadroom != nil {
+		in, out := &in.QuotaHeadroom, &out.QuotaHeadroom
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScalingPlanItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPlanNamespace.
+func (in *ScalingPlanNamespace) DeepCopy() *ScalingPlanNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPlanNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPlanSpec) DeepCopyInto(out *ScalingPlanSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPlanSpec.
+func (in *ScalingPlanSpec) DeepCopy() *ScalingPlanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPlanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPlanStatus) DeepCopyInto(out *ScalingPlanStatus) {
+	*out = *in
+	in.ComputedTime.DeepCopyInto(&out.ComputedTime)
+	if in.AppliedTime != nil {
+		in, out := &in.AppliedTime, &out.AppliedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]ScalingPlanNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPlanStatus.
+func (in *ScalingPlanStatus) DeepCopy() *ScalingPlanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPlanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingSnapshot) DeepCopyInto(out *ScalingSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingSnapshot.
+func (in *ScalingSnapshot) DeepCopy() *ScalingSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingSnapshotList) DeepCopyInto(out *ScalingSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScalingSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingSnapshotList.
+func (in *ScalingSnapshotList) DeepCopy() *ScalingSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingSnapshotSpec) DeepCopyInto(out *ScalingSnapshotSpec) {
+	*out = *in
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(SnapshotData)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingSnapshotSpec.
+func (in *ScalingSnapshotSpec) DeepCopy() *ScalingSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingSnapshotStatus) DeepCopyInto(out *ScalingSnapshotStatus) {
+	*out = *in
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]SnapshotItemDrift, len(*in))
+		copy(*out, *in)
+	}
+	if in.CheckedTime != nil {
+		in, out := &in.CheckedTime, &out.CheckedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RestoredTime != nil {
+		in, out := &in.RestoredTime, &out.RestoredTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingSnapshotStatus.
+func (in *ScalingSnapshotStatus) DeepCopy() *ScalingSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingState) DeepCopyInto(out *ScalingState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Config = in.Config
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingState.
+func (in *ScalingState) DeepCopy() *ScalingState {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingStateConfiguration) DeepCopyInto(out *ScalingStateConfiguration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateConfiguration.
+func (in *ScalingStateConfiguration) DeepCopy() *ScalingStateConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingStateConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingStateList) DeepCopyInto(out *ScalingStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScalingState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateList.
+func (in *ScalingStateList) DeepCopy() *ScalingStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScalingStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingStateSpec) DeepCopyInto(out *ScalingStateSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateSpec.
+func (in *ScalingStateSpec) DeepCopy() *ScalingStateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingStateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingStateStatus) DeepCopyInto(out *ScalingStateStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingStateStatus.
+func (in *ScalingStateStatus) DeepCopy() *ScalingStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotClusterScalingState) DeepCopyInto(out *SnapshotClusterScalingState) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotClusterScalingState.
+func (in *SnapshotClusterScalingState) DeepCopy() *SnapshotClusterScalingState {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotClusterScalingState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotData) DeepCopyInto(out *SnapshotData) {
+	*out = *in
+	in.CapturedTime.DeepCopyInto(&out.CapturedTime)
+	if in.ClusterScalingStateDefinitions != nil {
+		in, out := &in.ClusterScalingStateDefinitions, &out.ClusterScalingStateDefinitions
+		*out = make([]SnapshotStateDefinition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterScalingStates != nil {
+		in, out := &in.ClusterScalingStates, &out.ClusterScalingStates
+		*out = make([]SnapshotClusterScalingState, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScalingStates != nil {
+		in, out := &in.ScalingStates, &out.ScalingStates
+		*out = make([]SnapshotScalingState, len(*in))
+		copy(*out, *in)
+	}
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SnapshotItem, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotData.
+func (in *SnapshotData) DeepCopy() *SnapshotData {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotItem) DeepCopyInto(out *SnapshotItem) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotItem.
+func (in *SnapshotItem) DeepCopy() *SnapshotItem {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotItemDrift) DeepCopyInto(out *SnapshotItemDrift) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotItemDrift.
+func (in *SnapshotItemDrift) DeepCopy() *SnapshotItemDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotItemDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotScalingState) DeepCopyInto(out *SnapshotScalingState) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScalingState.
+func (in *SnapshotScalingState) DeepCopy() *SnapshotScalingState {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScalingState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotStateDefinition) DeepCopyInto(out *SnapshotStateDefinition) {
+	*out = *in
+	if in.States != nil {
+		in, out := &in.States, &out.States
+		*out = make([]States, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(TransitionHooks)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotStateDefinition.
+func (in *SnapshotStateDefinition) DeepCopy() *SnapshotStateDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotStateDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *States) DeepCopyInto(out *States) {
+	*out = *in
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(TransitionHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(AnalysisGate)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new States.
+func (in *States) DeepCopy() *States {
+	if in == nil {
+		return nil
+	}
+	out := new(States)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitionHook) DeepCopyInto(out *TransitionHook) {
+	*out = *in
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(JobHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPHook)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitionHook.
+func (in *TransitionHook) DeepCopy() *TransitionHook {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitionHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitionHooks) DeepCopyInto(out *TransitionHooks) {
+	*out = *in
+	if in.Pre != nil {
+		in, out := &in.Pre, &out.Pre
+		*out = make([]TransitionHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Post != nil {
+		in, out := &in.Post, &out.Post
+		*out = make([]TransitionHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitionHooks.
+func (in *TransitionHooks) DeepCopy() *TransitionHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitionHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitionStatus) DeepCopyInto(out *TransitionStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]HookStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitionStatus.
+func (in *TransitionStatus) DeepCopy() *TransitionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitionStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/containersol/prescale-operator/internal/capture"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// captureState records the current replicas of the opted-in items as a new state
+func captureState(ctx context.Context, _client client.Client, _ string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("capture", flag.ContinueOnError)
+	opts := capture.Options{}
+	var priority int
+	flags.IntVar(&priority, "priority", 0, "The priority of the state in the ClusterScalingStateDefinition. 1 is the highest.")
+	flags.StringVar(&opts.Description, "description", "", "The description of the state in the ClusterScalingStateDefinition.")
+	namespaceFlags(flags, &opts.Namespace, "Only capture the items of this namespace. Defaults to all namespaces.")
+	flags.StringVar(&opts.ScalingClass, "class", "", "Only capture the items of this scaling class. Defaults to all classes.")
+	flags.BoolVar(&opts.Overwrite, "overwrite", false, "Capture a state that is already defined again.")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "Only print the replicas that would be captured.")
+	args, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("capture needs exactly one state")
+	}
+	if priority <= 0 {
+		return errors.New("capture needs a --priority of 1 or more")
+	}
+	opts.State = args[0]
+	opts.Priority = int32(priority)
+
+	items, err := capture.State(ctx, _client, opts)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tREPLICAS")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", item.Namespace, item.ItemTypeName, item.Name, item.SpecReplica)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Fprintf(out, "\nDry run: state %s not captured\n", opts.State)
+	} else {
+		fmt.Fprintf(out, "\nCaptured %d items as state %s with priority %d\n", len(items), opts.State, opts.Priority)
+	}
+	return nil
+}
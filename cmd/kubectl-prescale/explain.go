@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	nd "github.com/containersol/prescale-operator/internal/namespace_defaults"
+	"github.com/containersol/prescale-operator/internal/resources"
+	sr "github.com/containersol/prescale-operator/internal/state_replicas"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// detail is one line of an explanation
+type detail struct {
+	name  string
+	value string
+}
+
+// explain prints where the target replicas of a workload come from
+func explain(ctx context.Context, _client client.Client, namespace string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	namespaceFlags(flags, &namespace, "The namespace of the workload.")
+	args, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("explain needs exactly one workload")
+	}
+
+	_, item, err := workload(ctx, _client, namespace, args[0])
+	if err != nil {
+		return err
+	}
+	namespaceAnnotations, err := nd.Annotations(ctx, _client, item.Namespace)
+	if err != nil {
+		return err
+	}
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, _client)
+	if err != nil {
+		return err
+	}
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err = _client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return err
+	}
+
+	details, err := explainItem(ctx, _client, item, namespaceAnnotations, stateDefinitions, clusterScalingStates)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, d := range details {
+		fmt.Fprintf(w, "%s:\t%s\n", d.name, d.value)
+	}
+	return w.Flush()
+}
+
+// explainItem resolves the target of the item like the operator does and tells which setting each step comes from
+func explainItem(ctx context.Context, _client client.Client, item g.ScalingInfo, namespaceAnnotations map[string]string, stateDefinitions states.States, clusterScalingStates v1alpha1.ClusterScalingStateList) ([]detail, error) {
+	workloadAnnotations := item.Annotations
+	item = nd.Apply(item, namespaceAnnotations)
+
+	details := []detail{{"Workload", fmt.Sprintf("%s %s/%s", item.ItemTypeName, item.Namespace, item.Name)}}
+	details = append(details, detail{"Opt-in", optInSource(ctx, _client, item)})
+
+	class := states.GetAppliedScalingClassFromScalingItem(item).Name
+	if _, found := item.Labels["scaler/scaling-class"]; found {
+		details = append(details, detail{"Scaling class", class + " (scaler/scaling-class label)"})
+	} else {
+		details = append(details, detail{"Scaling class", class + " (no scaler/scaling-class label)"})
+	}
+
+	items, namespaceStates, err := resources.WithTargets(ctx, _client, []g.ScalingInfo{item}, stateDefinitions, clusterScalingStates)
+	if err != nil {
+		return nil, err
+	}
+	item = items[0]
+	namespaceState := namespaceStates[item.Namespace]
+	if item.ClusterClassState.Name != "" {
+		details = append(details, detail{"Cluster state", fmt.Sprintf("%s (priority %d)", item.ClusterClassState.Name, item.ClusterClassState.Priority)})
+	} else {
+		details = append(details, detail{"Cluster state", fmt.Sprintf("- (no ClusterScalingState of class %s)", class)})
+	}
+	if namespaceState.Name != "" {
+		details = append(details, detail{"Namespace state", fmt.Sprintf("%s (priority %d)", namespaceState.Name, namespaceState.Priority)})
+	} else {
+		details = append(details, detail{"Namespace state", "- (no ScalingState in the namespace)"})
+	}
+	details = append(details, detail{"Effective state", stateSource(item.State, namespaceState, states.State(item.ClusterClassState))})
+
+	if item.State != "" {
+		details = append(details, detail{"Replicas", replicasSource(item.State, workloadAnnotations, namespaceAnnotations, item.Annotations)})
+	}
+	if item.DesiredReplicas == -1 {
+		details = append(details, detail{"Target", fmt.Sprintf("- (currently %d, %d ready)", item.SpecReplica, item.ReadyReplicas)})
+	} else {
+		details = append(details, detail{"Target", fmt.Sprintf("%d (currently %d, %d ready)", item.DesiredReplicas, item.SpecReplica, item.ReadyReplicas)})
+	}
+
+	if item.ItemTypeName == "RedisCluster" {
+		details = append(details, detail{"Scaling mode", "rapid (RedisClusters don't support step scaling)"})
+	} else if states.GetRapidScalingSetting(item) {
+		details = append(details, detail{"Scaling mode", "rapid (" + annotationSource(constants.RapidScalingAnnotation, workloadAnnotations, namespaceAnnotations) + ")"})
+	} else {
+		details = append(details, detail{"Scaling mode", "step (" + annotationSource(constants.RapidScalingAnnotation, workloadAnnotations, namespaceAnnotations) + ")"})
+	}
+
+	item = resources.SetPriorities(ctx, _client, []g.ScalingInfo{item})[0]
+	details = append(details, detail{"Priority", fmt.Sprintf("%d (%s)", item.Priority, resources.PrioritySource(item))})
+
+	if item.Failure {
+		details = append(details, detail{"Failure", item.FailureMessage})
+	}
+	return details, nil
+}
+
+func optInSource(ctx context.Context, _client client.Client, item g.ScalingInfo) string {
+	switch {
+	case !resources.NamespaceWatched(ctx, _client, item.Namespace):
+		return "no, the operator doesn't watch the namespace"
+	case config.OptedIn(item.Labels):
+		return "yes, by the labels of the workload"
+	case item.NamespaceOptIn:
+		return "yes, by the " + constants.NamespaceOptInAnnotation + " annotation of the namespace"
+	case nd.OptedOut(item.Labels):
+		return "no, the workload opts out with its opt-in label"
+	}
+	return "no, neither the workload nor its namespace opt in"
+}
+
+// stateSource tells why the state of the item won. The state with the lower priority value wins.
+func stateSource(state string, namespaceState states.State, clusterState states.State) string {
+	switch {
+	case state == "":
+		return "- (neither a cluster nor a namespace state applies)"
+	case namespaceState.Name == "":
+		return state + " (the cluster state, there is no namespace state)"
+	case clusterState.Name == "":
+		return state + " (the namespace state, there is no cluster state)"
+	case namespaceState.Name == clusterState.Name:
+		return state + " (cluster and namespace state agree)"
+	case state == namespaceState.Name:
+		return state + " (the namespace state has the higher priority)"
+	}
+	return state + " (the cluster state has the higher priority)"
+}
+
+// replicasSource tells which annotation set the replicas of the state, following the rules of namespace_defaults.Apply
+func replicasSource(state string, workloadAnnotations map[string]string, namespaceAnnotations map[string]string, applied map[string]string) string {
+	key := sr.StateReplicaAnnotationPrefix + state + sr.StateReplicaAnnotationSuffix
+	if _, found := workloadAnnotations[key]; found {
+		return fmt.Sprintf("%s (%s annotation of the workload)", workloadAnnotations[key], key)
+	}
+
+	multiplierKey := sr.StateReplicaAnnotationPrefix + state + sr.StateMultiplierAnnotationSuffix
+	defaultKey := sr.StateReplicaAnnotationPrefix + constants.DefaultReplicaAnnotation + sr.StateReplicaAnnotationSuffix
+	if _, found := namespaceAnnotations[multiplierKey]; found && state != constants.DefaultReplicaAnnotation {
+		if _, err := strconv.Atoi(applied[defaultKey]); err == nil {
+			return fmt.Sprintf("%s (%s annotation of the namespace times the %s replicas)", applied[key], multiplierKey, constants.DefaultReplicaAnnotation)
+		}
+	}
+	if _, found := namespaceAnnotations[key]; found {
+		return fmt.Sprintf("%s (%s annotation of the namespace)", namespaceAnnotations[key], key)
+	}
+	return fmt.Sprintf("- (no %s annotation on the workload or the namespace)", key)
+}
+
+func annotationSource(key string, workloadAnnotations map[string]string, namespaceAnnotations map[string]string) string {
+	if _, found := workloadAnnotations[key]; found {
+		return key + " annotation of the workload"
+	}
+	if _, found := namespaceAnnotations[key]; found {
+		return key + " annotation of the namespace"
+	}
+	return "no " + key + " annotation"
+}
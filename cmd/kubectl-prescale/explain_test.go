@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExplainItem(t *testing.T) {
+	_client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+			&v1alpha1.ScalingState{ObjectMeta: metav1.ObjectMeta{Name: "scalingstate-shop", Namespace: "shop"}, Spec: v1alpha1.ScalingStateSpec{State: "bau"}},
+		).
+		Build()
+	stateDefinitions := states.States{{Name: "peak", Priority: 1}, {Name: "bau", Priority: 5}}
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{Items: []v1alpha1.ClusterScalingState{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "peak"}},
+	}}
+	item := g.ScalingInfo{
+		Namespace:       "shop",
+		Name:            "web",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+		Annotations:     map[string]string{"scaler/state-default-replicas": "2", "scaler/priority": "7"},
+		SpecReplica:     2,
+		ReadyReplicas:   2,
+		DesiredReplicas: -1,
+	}
+	namespaceAnnotations := map[string]string{
+		"scaler/opt-in":                "true",
+		"scaler/state-peak-multiplier": "2.5",
+		"scaler/rapid-scaling":         "true",
+	}
+
+	got, err := explainItem(context.TODO(), _client, item, namespaceAnnotations, stateDefinitions, clusterScalingStates)
+	if err != nil {
+		t.Fatalf("explainItem() error = %v", err)
+	}
+	want := []detail{
+		{"Workload", "Deployment shop/web"},
+		{"Opt-in", "yes, by the scaler/opt-in annotation of the namespace"},
+		{"Scaling class", "default (no scaler/scaling-class label)"},
+		{"Cluster state", "peak (priority 1)"},
+		{"Namespace state", "bau (priority 5)"},
+		{"Effective state", "peak (the cluster state has the higher priority)"},
+		{"Replicas", "5 (scaler/state-peak-multiplier annotation of the namespace times the default replicas)"},
+		{"Target", "5 (currently 2, 2 ready)"},
+		{"Scaling mode", "rapid (scaler/rapid-scaling annotation of the namespace)"},
+		{"Priority", "7 (scaler/priority annotation)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("explainItem() = %v, want %v", got, want)
+	}
+}
+
+func TestReplicasSource(t *testing.T) {
+	tests := []struct {
+		name                 string
+		workloadAnnotations  map[string]string
+		namespaceAnnotations map[string]string
+		applied              map[string]string
+		want                 string
+	}{
+		{
+			name:                 "TestWorkloadAnnotation",
+			workloadAnnotations:  map[string]string{"scaler/state-peak-replicas": "4"},
+			namespaceAnnotations: map[string]string{"scaler/state-peak-replicas": "6"},
+			want:                 "4 (scaler/state-peak-replicas annotation of the workload)",
+		},
+		{
+			name:                 "TestNamespaceAnnotation",
+			namespaceAnnotations: map[string]string{"scaler/state-peak-replicas": "6"},
+			want:                 "6 (scaler/state-peak-replicas annotation of the namespace)",
+		},
+		{
+			name:                 "TestMultiplierWithoutDefaultReplicas",
+			namespaceAnnotations: map[string]string{"scaler/state-peak-replicas": "6", "scaler/state-peak-multiplier": "2"},
+			want:                 "6 (scaler/state-peak-replicas annotation of the namespace)",
+		},
+		{
+			name: "TestNoAnnotation",
+			want: "- (no scaler/state-peak-replicas annotation on the workload or the namespace)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replicasSource("peak", tt.workloadAnnotations, tt.namespaceAnnotations, tt.applied); got != tt.want {
+				t.Errorf("replicasSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadItemType(t *testing.T) {
+	tests := []struct {
+		reference string
+		itemType  string
+		name      string
+		wantErr   bool
+	}{
+		{reference: "web", itemType: "Deployment", name: "web"},
+		{reference: "deploy/web", itemType: "Deployment", name: "web"},
+		{reference: "dc/web", itemType: "DeploymentConfig", name: "web"},
+		{reference: "RedisCluster/cache", itemType: "RedisCluster", name: "cache"},
+		{reference: "statefulset/db", wantErr: true},
+		{reference: "deployment/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			itemType, name, err := workloadItemType(tt.reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("workloadItemType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if itemType != tt.itemType || name != tt.name {
+				t.Errorf("workloadItemType() = %v, %v, want %v, %v", itemType, name, tt.itemType, tt.name)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	flags := flag.NewFlagSet("switch", flag.ContinueOnError)
+	var class string
+	flags.StringVar(&class, "class", "", "")
+
+	got, err := parse(flags, []string{"peak", "--class", "batch"})
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"peak"}) || class != "batch" {
+		t.Errorf("parse() = %v with class %q, want [peak] with class batch", got, class)
+	}
+}
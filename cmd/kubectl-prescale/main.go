@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+   http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-prescale is a kubectl plugin to inspect and switch the scaling states of the pre-scaling operator.
+// It uses the code of the operator to resolve states and desired replicas, so it shows what the operator does.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/controllers"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/validations"
+	redisalpha "github.com/containersolutions/redis-operator/api/v1alpha1"
+	dc "github.com/openshift/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const usage = `kubectl prescale inspects and switches the scaling states of the pre-scaling operator.
+
+Usage:
+  kubectl prescale status [-n namespace | -A]
+  kubectl prescale switch <state> [--class class] [--namespace namespace] [--for duration]
+  kubectl prescale plan <state> [--class class]
+  kubectl prescale retry <workload> [-n namespace]
+  kubectl prescale explain <workload> [-n namespace]
+  kubectl prescale capture <state> --priority priority [-n namespace] [--class class] [--overwrite] [--dry-run]
+  kubectl prescale snapshot save [name] [-f file]
+  kubectl prescale snapshot diff <name> | -f file
+  kubectl prescale snapshot restore <name> | -f file
+
+Workloads are given as <kind>/<name>, like deployment/web or dc/web. A name without kind is a Deployment.
+
+Global flags:
+`
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(dc.AddToScheme(scheme))
+	utilruntime.Must(scalingv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(redisalpha.AddToScheme(scheme))
+}
+
+// command runs a subcommand. The namespace is the one of the current kubeconfig context.
+type command func(ctx context.Context, _client client.Client, namespace string, args []string, out io.Writer) error
+
+var commands = map[string]command{
+	"status":   status,
+	"switch":   switchState,
+	"plan":     plan,
+	"retry":    retryWorkload,
+	"explain":  explain,
+	"capture":  captureState,
+	"snapshot": snapshotCommand,
+}
+
+func main() {
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "Print the log of the operator code the plugin runs.")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if debug {
+		ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	} else {
+		ctrl.SetLogger(ctrllog.NullLogger{})
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	run, found := commands[flag.Arg(0)]
+	if !found {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// The quota and cluster checks of the operator load the kubeconfig on their own
+	if kubeconfig := flag.Lookup("kubeconfig"); kubeconfig != nil && kubeconfig.Value.String() != "" {
+		os.Setenv(clientcmd.RecommendedConfigPathEnvVar, kubeconfig.Value.String())
+	}
+	namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{}).Namespace()
+	if err != nil {
+		fail(err)
+	}
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		fail(err)
+	}
+	_client, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		fail(err)
+	}
+
+	// The plugin has to decide like the operator, so it uses the same configuration and kinds
+	ctx := context.Background()
+	if err := controllers.LoadOperatorConfig(ctx, _client); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: cannot load the PreScalingOperatorConfig, using the defaults: %v\n", err)
+	}
+	constants.OpenshiftCluster, _ = validations.OpenshiftClusterCheck()
+	constants.RedisCluster, _ = validations.RedisClusterInstalled()
+
+	if err := run(ctx, _client, namespace, flag.Args()[1:], os.Stdout); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
+
+// parse parses the flags of a subcommand and returns its arguments. Unlike with the flag package alone, flags may
+// follow the arguments, like kubectl allows it.
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	arguments := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return arguments, nil
+		}
+		arguments = append(arguments, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// namespaceFlags registers --namespace and its short form -n
+func namespaceFlags(flags *flag.FlagSet, namespace *string, usage string) {
+	flags.StringVar(namespace, "namespace", *namespace, usage)
+	flags.StringVar(namespace, "n", *namespace, usage)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// plan prints the replica changes and quota verdicts switching a class to a state would lead to. It computes them
+// like a ScalingPlan does and changes nothing.
+func plan(ctx context.Context, _client client.Client, _ string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("plan", flag.ContinueOnError)
+	var class string
+	flags.StringVar(&class, "class", "", "The scaling class to plan for. Defaults to the default class.")
+	args, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("plan needs exactly one state")
+	}
+
+	scalingPlan := v1alpha1.ScalingPlan{Spec: v1alpha1.ScalingPlanSpec{State: args[0], ScalingClass: class}}
+	namespaces, err := reconciler.ComputeScalingPlan(ctx, _client, scalingPlan)
+	if err != nil {
+		return err
+	}
+	if len(namespaces) == 0 {
+		fmt.Fprintf(out, "No changes switching class %s to %s\n", reconciler.PlanScalingClass(scalingPlan), args[0])
+		return nil
+	}
+	return writePlan(out, namespaces)
+}
+
+func writePlan(out io.Writer, namespaces []v1alpha1.ScalingPlanNamespace) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tREPLICAS\tMODE\tQUOTA\tBLOCKERS")
+	for _, namespace := range namespaces {
+		quota := "allowed"
+		if !namespace.QuotaAllowed {
+			quota = "exceeded"
+		}
+		for _, item := range namespace.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d -> %d\t%s\t%s\t%s\n",
+				namespace.Namespace, item.Kind, item.Name, item.CurrentReplicas, item.DesiredReplicas,
+				orNone(item.Mode), quota, orNone(strings.Join(item.Blockers, "; ")))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	constants "github.com/containersol/prescale-operator/internal"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// retryWorkload makes the operator retry a workload it gave up scaling by changing its retry annotation
+func retryWorkload(ctx context.Context, _client client.Client, namespace string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("retry", flag.ContinueOnError)
+	namespaceFlags(flags, &namespace, "The namespace of the workload.")
+	args, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("retry needs exactly one workload")
+	}
+
+	obj, item, err := workload(ctx, _client, namespace, args[0])
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[constants.RetryAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+	err = _client.Patch(ctx, obj, patch)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s %s/%s will be retried\n", item.ItemTypeName, item.Namespace, item.Name)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/snapshot"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// snapshotCommand saves, diffs and restores snapshots of the scaling state. Snapshots are kept as ScalingSnapshots in
+// the cluster or as ScalingSnapshot manifests in files, which survive the cluster.
+func snapshotCommand(ctx context.Context, _client client.Client, _ string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("snapshot needs one of save, diff or restore")
+	}
+	flags := flag.NewFlagSet("snapshot "+args[0], flag.ContinueOnError)
+	var file string
+	flags.StringVar(&file, "f", "", "The file of the snapshot instead of a ScalingSnapshot in the cluster. - is stdin or stdout.")
+	action := args[0]
+	args, err := parse(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 || (len(args) == 0 && file == "") {
+		return fmt.Errorf("snapshot %s needs either the name of a ScalingSnapshot or -f", action)
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	switch action {
+	case "save":
+		return saveSnapshot(ctx, _client, name, file, out)
+	case "diff":
+		data, err := loadSnapshot(ctx, _client, name, file)
+		if err != nil {
+			return err
+		}
+		drift, err := snapshot.Diff(ctx, _client, data)
+		if err != nil {
+			return err
+		}
+		return writeDrift(out, drift)
+	case "restore":
+		data, err := loadSnapshot(ctx, _client, name, file)
+		if err != nil {
+			return err
+		}
+		items, err := snapshot.Restore(ctx, _client, data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Restored the state captured at %s\n", data.CapturedTime.UTC().Format(time.RFC3339))
+		// The operator scales the items when it reconciles the restored states
+		for _, item := range items {
+			fmt.Fprintf(out, "%s %s/%s differs from the snapshot. The operator scales it to the replicas of its state\n", item.ItemTypeName, item.Namespace, item.Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown snapshot command %q", action)
+}
+
+// saveSnapshot captures the state into a ScalingSnapshot. With a file it's written there instead of to the cluster.
+func saveSnapshot(ctx context.Context, _client client.Client, name string, file string, out io.Writer) error {
+	data, err := snapshot.Capture(ctx, _client)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = "snapshot-" + data.CapturedTime.UTC().Format("20060102-150405")
+	}
+	scalingSnapshot := &v1alpha1.ScalingSnapshot{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "ScalingSnapshot"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1alpha1.ScalingSnapshotSpec{Snapshot: &data},
+	}
+
+	if file == "" {
+		err = _client.Create(ctx, scalingSnapshot)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "ScalingSnapshot %s saved with %d items\n", name, len(data.Items))
+		return nil
+	}
+	manifest, err := yaml.Marshal(scalingSnapshot)
+	if err != nil {
+		return err
+	}
+	if file == "-" {
+		_, err = out.Write(manifest)
+		return err
+	}
+	err = ioutil.WriteFile(file, manifest, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Snapshot saved to %s with %d items\n", file, len(data.Items))
+	return nil
+}
+
+// loadSnapshot reads the captured state of a ScalingSnapshot from the cluster or from a file
+func loadSnapshot(ctx context.Context, _client client.Client, name string, file string) (v1alpha1.SnapshotData, error) {
+	scalingSnapshot := &v1alpha1.ScalingSnapshot{}
+	if file == "" {
+		err := _client.Get(ctx, client.ObjectKey{Name: name}, scalingSnapshot)
+		if err != nil {
+			return v1alpha1.SnapshotData{}, err
+		}
+	} else {
+		var manifest []byte
+		var err error
+		if file == "-" {
+			manifest, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			manifest, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return v1alpha1.SnapshotData{}, err
+		}
+		err = yaml.UnmarshalStrict(manifest, scalingSnapshot)
+		if err != nil {
+			return v1alpha1.SnapshotData{}, err
+		}
+	}
+	if scalingSnapshot.Spec.Snapshot == nil {
+		return v1alpha1.SnapshotData{}, fmt.Errorf("ScalingSnapshot %s wasn't captured yet", scalingSnapshot.Name)
+	}
+	return *scalingSnapshot.Spec.Snapshot, nil
+}
+
+func writeDrift(out io.Writer, drift []v1alpha1.SnapshotItemDrift) error {
+	if len(drift) == 0 {
+		fmt.Fprintln(out, "All items have the replicas of the snapshot")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tSNAPSHOT\tCURRENT")
+	for _, item := range drift {
+		current := fmt.Sprint(item.CurrentReplicas)
+		if item.CurrentReplicas == -1 {
+			current = "<gone>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", item.Namespace, item.Kind, item.Name, item.SnapshotReplicas, current)
+	}
+	return w.Flush()
+}
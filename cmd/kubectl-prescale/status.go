@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// status prints the state of every scaling class, the ScalingStates of the namespaces and the progress of the items
+func status(ctx context.Context, _client client.Client, namespace string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	namespaceFlags(flags, &namespace, "The namespace to show the items of.")
+	var allNamespaces bool
+	flags.BoolVar(&allNamespaces, "all-namespaces", false, "Show the items of all namespaces.")
+	flags.BoolVar(&allNamespaces, "A", false, "Show the items of all namespaces.")
+	args, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("status takes no arguments")
+	}
+	if allNamespaces {
+		namespace = ""
+	}
+
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, _client)
+	if err != nil {
+		return err
+	}
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err = _client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return err
+	}
+	items, err := resources.ScalingItemNamespaceLister(ctx, _client, namespace, config.OptInSelector())
+	if err != nil {
+		return err
+	}
+	items, namespaceStates, err := resources.WithTargets(ctx, _client, items, stateDefinitions, clusterScalingStates)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLASS\tCLUSTER STATE")
+	for _, css := range clusterScalingStates.Items {
+		fmt.Fprintf(w, "%s\t%s\n", states.GetAppliedScalingClassFromClusterScalingState(css).Name, css.Spec.State)
+	}
+
+	namespaces := []string{}
+	for name := range namespaceStates {
+		namespaces = append(namespaces, name)
+	}
+	sort.Strings(namespaces)
+	fmt.Fprintln(w, "\nNAMESPACE\tNAMESPACE STATE")
+	for _, name := range namespaces {
+		fmt.Fprintf(w, "%s\t%s\n", name, orNone(namespaceStates[name].Name))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		return items[i].Name < items[j].Name
+	})
+	fmt.Fprintln(w, "\nNAMESPACE\tKIND\tNAME\tCLASS\tSTATE\tREPLICAS\tREADY\tTARGET\tPROGRESS")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			item.Namespace, item.ItemTypeName, item.Name, states.GetAppliedScalingClassFromScalingItem(item).Name,
+			orNone(item.State), item.SpecReplica, item.ReadyReplicas, target(item), progress(item))
+	}
+	return w.Flush()
+}
+
+// progress is the progress of the item with the reason of a failure
+func progress(item g.ScalingInfo) string {
+	if item.Failure {
+		return resources.ProgressFailed + ": " + item.FailureMessage
+	}
+	return resources.Progress(item)
+}
+
+func target(item g.ScalingInfo) string {
+	if item.DesiredReplicas == -1 {
+		return "-"
+	}
+	return fmt.Sprint(item.DesiredReplicas)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
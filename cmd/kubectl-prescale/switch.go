@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/states"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// switchState sets the state of the ClusterScalingState of a class, or of the ScalingState of a namespace
+func switchState(ctx context.Context, _client client.Client, _ string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("switch", flag.ContinueOnError)
+	var class, namespace string
+	flags.StringVar(&class, "class", "", "The scaling class to switch. Defaults to the default class.")
+	namespaceFlags(flags, &namespace, "Switch the ScalingState of this namespace instead of a ClusterScalingState.")
+	var duration time.Duration
+	flags.DurationVar(&duration, "for", 0, "Go back to the current state after this long, like 2h. Defaults to keeping the state.")
+	args, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("switch needs exactly one state")
+	}
+	state := args[0]
+	var expires *time.Time
+	if duration > 0 {
+		expiry := time.Now().Add(duration)
+		expires = &expiry
+	}
+
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, _client)
+	if err != nil {
+		return err
+	}
+	err = stateDefinitions.FindState(state, &states.State{})
+	if err != nil {
+		return err
+	}
+
+	if namespace != "" {
+		if class != "" {
+			return errors.New("--class can't be used with --namespace. The ScalingState of a namespace applies to all classes")
+		}
+		name, err := reconciler.SwitchNamespaceState(ctx, _client, namespace, state, expires)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "ScalingState %s/%s switched to %s%s\n", namespace, name, state, until(expires))
+		return nil
+	}
+
+	err = reconciler.SwitchClusterState(ctx, _client, class, state, expires)
+	if err != nil {
+		return err
+	}
+	if class == "" {
+		class = constants.DefaultScalingClass.Name
+	}
+	fmt.Fprintf(out, "ClusterScalingState of class %s switched to %s%s\n", class, state, until(expires))
+	return nil
+}
+
+func until(expires *time.Time) string {
+	if expires == nil {
+		return ""
+	}
+	return " until " + expires.Format(time.RFC3339)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containersol/prescale-operator/internal/resources"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// workloadItemType returns the item type of a workload reference like deployment/web. A name without kind is a Deployment.
+func workloadItemType(reference string) (string, string, error) {
+	kind, name := "deployment", reference
+	if i := strings.Index(reference, "/"); i >= 0 {
+		kind, name = reference[:i], reference[i+1:]
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("no name in workload %q", reference)
+	}
+	switch strings.ToLower(kind) {
+	case "deployment", "deployments", "deploy", "deployment.apps":
+		return "Deployment", name, nil
+	case "deploymentconfig", "deploymentconfigs", "dc", "deploymentconfig.apps.openshift.io":
+		return "DeploymentConfig", name, nil
+	case "rediscluster", "redisclusters", "rediscluster.redis.containersolutions.com":
+		return "RedisCluster", name, nil
+	}
+	return "", "", fmt.Errorf("unknown kind %q. Workloads are Deployments, DeploymentConfigs or RedisClusters", kind)
+}
+
+// workload gets the referenced workload and its scaling item like the watch controllers build it
+func workload(ctx context.Context, _client client.Client, namespace string, reference string) (client.Object, g.ScalingInfo, error) {
+	itemType, name, err := workloadItemType(reference)
+	if err != nil {
+		return nil, g.ScalingInfo{}, err
+	}
+
+	item := g.ScalingInfo{Namespace: namespace, Name: name, ScalingItemType: g.ScalingItemType{ItemTypeName: itemType}}
+	obj, err := resources.ScalingItemObject(ctx, _client, item)
+	if err != nil {
+		return nil, g.ScalingInfo{}, err
+	}
+	item, err = resources.ScalingItemFromObject(obj)
+	return obj, item, err
+}
@@ -23,7 +23,10 @@ import (
 
 	"github.com/containersol/prescale-operator/api/v1alpha1"
 	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
-	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/hooks"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	"github.com/containersol/prescale-operator/internal/tracing"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -42,6 +45,10 @@ type ClusterScalingStateReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// APIReader reads the dry run report ConfigMaps without the cache
+	APIReader client.Reader
+	// Hooks runs the transition hooks. It's added to the manager, which cancels the running hooks on shutdown.
+	Hooks *hooks.Runner
 }
 
 // +kubebuilder:rbac:groups=scaling.prescale.com,resources=clusterscalingstates,verbs=get;list;watch;create;update;patch;delete
@@ -63,14 +70,38 @@ func (r *ClusterScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.
 	var appliedStates []string
 	var appliedStateNamespaceList []string
 	var dryRunCluster string
+	var transitionRequeue time.Duration
 	log := r.Log.
 		WithValues("reconciler kind", "ClusterScalingState").
 		WithValues("reconciler object", req.Name)
 
+	// Root span of the transition. All namespaces and items scaled because of it are its children.
+	ctx, span := tracing.Start(ctx, "ClusterScalingStateTransition", tracing.NameKey.String(req.Name))
+	defer span.End()
+
 	css := &v1alpha1.ClusterScalingState{}
 	err := r.Get(ctx, req.NamespacedName, css)
 	if err != nil {
 		log.Error(err, "ClusterScalingState could not be found! It might've been deleted. Reconciling.")
+	} else {
+		class := states.GetAppliedScalingClassFromClusterScalingState(*css).Name
+		metrics.SetClassState(class, css.Spec.State)
+		span.SetAttributes(tracing.ClassKey.String(class), tracing.StateKey.String(css.Spec.State), tracing.DryRunKey.Bool(css.Config.DryRun))
+
+		if css.Spec.Paused {
+			// The items of the class are skipped by the scalers. Hooks and transitions carry on once it's unpaused.
+			log.Info("Scaling of the class is paused")
+		} else {
+			// The pre hooks of a transition run before the items of the class are scaled
+			proceed, requeue, err := r.Hooks.Advance(ctx, css)
+			if err != nil {
+				log.Error(err, "Failed to advance the transition hooks")
+			}
+			if !proceed {
+				return ctrl.Result{RequeueAfter: requeue}, nil
+			}
+			transitionRequeue = requeue
+		}
 	}
 
 	clusterStateDefinitions, err := states.GetClusterScalingStates(ctx, r.Client)
@@ -78,18 +109,30 @@ func (r *ClusterScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.
 		// If we encounter an error trying to retrieve the state definitions,
 		// we will not be able to compute anything else.
 		log.Error(err, "Failed to get ClusterStateDefinitions")
+		span.RecordError(err)
 		return ctrl.Result{}, err
 	}
 
 	log.Info("Clusterscalingstate Controller: Reconciling namespaces")
 
-	nsInfos, retrigger, err := reconciler.PrepareForNamespaceReconcile(ctx, r.Client, "", clusterStateDefinitions, states.State{}, r.Recorder, css.Config.DryRun)
+	nsInfos, capacity, retrigger, err := reconciler.PrepareForNamespaceReconcile(ctx, r.Client, "", clusterStateDefinitions, states.State{}, r.Recorder, css.Config.DryRun)
 	if err != nil {
+		span.RecordError(err)
 		return ctrl.Result{}, err
 	}
 
+	if capacity != nil {
+		if !capacity.Fits {
+			r.Recorder.Event(css, "Warning", "InsufficientCapacity", reconciler.InsufficientCapacityMessage(*capacity))
+		}
+		err = reconciler.SetCapacityOnStatus(ctx, r.Client, req.Name, capacity)
+		if err != nil {
+			log.Error(err, "Failed to put the capacity check on the status")
+		}
+	}
+
 	if nsInfos == nil && !retrigger && err == nil {
-		return ctrl.Result{}, nil
+		return ctrl.Result{RequeueAfter: transitionRequeue}, nil
 	}
 
 	// Loop over all the namespace events of the namespaces which have been reconciled
@@ -103,6 +146,9 @@ func (r *ClusterScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.
 			if nsInfo.NSEvents.QuotaExceeded != "" {
 				eventsList = append(eventsList, nsInfo.NSEvents.QuotaExceeded)
 			}
+			if nsInfo.NSEvents.QuotaShortfall != "" {
+				r.Recorder.Event(css, "Warning", "QuotaShortfall", fmt.Sprintf("Partially scaled because of the ResourceQuotas. %s", nsInfo.NSEvents.QuotaShortfall))
+			}
 
 			appliedStateNamespaceList = append(appliedStateNamespaceList, namespaceKey)
 			appliedStates = append(appliedStates, nsInfo.AppliedState)
@@ -123,20 +169,29 @@ func (r *ClusterScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.
 		log.Info("Clusterscalingstate Reconciliation loop completed successfully")
 
 	} else {
+		reportNamespace, reportName := reconciler.OperatorNamespace(), reconciler.DryRunReportName("ClusterScalingState", req.Name)
+		err = reconciler.WriteDryRunReport(ctx, r.Client, r.APIReader, reportNamespace, reportName, reconciler.NewDryRunReport("ClusterScalingState/"+req.Name, nsInfos, capacity))
+		if err != nil {
+			log.Error(err, "Failed to write the dry run report")
+		}
+
 		if dryRunCluster == "" {
 			r.Recorder.Event(css, "Normal", "DryRun", "DryRun: No changes in any namespace would be made!")
 
 		} else {
-			r.Recorder.Event(css, "Normal", "DryRun", fmt.Sprintf("DryRun: %s", dryRunCluster))
+			r.Recorder.Event(css, "Normal", "DryRun", fmt.Sprintf("DryRun: %sFull report in ConfigMap %s/%s", dryRunCluster, reportNamespace, reportName))
 		}
 
 	}
 	if retrigger {
-		log.Info(fmt.Sprintf("Not all namespaces reconciled. Retriggering ClusterScalingStateController in %d seconds", constants.RetriggerControllerSeconds))
-		return ctrl.Result{RequeueAfter: time.Second * constants.RetriggerControllerSeconds}, nil
+		retriggerSeconds := config.Get().RetriggerControllerSeconds
+		log.Info(fmt.Sprintf("Not all namespaces reconciled. Retriggering ClusterScalingStateController in %d seconds", retriggerSeconds))
+		if retriggerAfter := time.Second * time.Duration(retriggerSeconds); transitionRequeue == 0 || retriggerAfter < transitionRequeue {
+			transitionRequeue = retriggerAfter
+		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: transitionRequeue}, nil
 
 }
 
@@ -144,7 +199,7 @@ func (r *ClusterScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.
 func (r *ClusterScalingStateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&scalingv1alpha1.ClusterScalingState{}).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.ClusterScalingStateController)}).
 		WithEventFilter(validations.StartupFilter()).
 		Owns(&scalingv1alpha1.ScalingState{}).
 		Complete(r)
@@ -31,8 +31,8 @@ import (
 
 	"github.com/containersol/prescale-operator/api/v1alpha1"
 	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
-	c "github.com/containersol/prescale-operator/internal"
 	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
 	"github.com/containersol/prescale-operator/internal/reconciler"
 	"github.com/containersol/prescale-operator/internal/states"
 	"github.com/containersol/prescale-operator/internal/validations"
@@ -44,6 +44,8 @@ type ClusterScalingStateDefinitionReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// APIReader reads the dry run report ConfigMaps without the cache
+	APIReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=scaling.prescale.com,resources=clusterscalingstatedefinitions,verbs=get;list;watch;create;update;patch;delete
@@ -93,11 +95,15 @@ func (r *ClusterScalingStateDefinitionReconciler) Reconcile(ctx context.Context,
 	}
 	log.Info("Clusterscalingstatedefinition Controller: Reconciling namespaces")
 
-	nsInfos, retrigger, err := reconciler.PrepareForNamespaceReconcile(ctx, r.Client, "", clusterStateDefinitions, states.State{}, r.Recorder, cssd.Config.DryRun)
+	nsInfos, capacity, retrigger, err := reconciler.PrepareForNamespaceReconcile(ctx, r.Client, "", clusterStateDefinitions, states.State{}, r.Recorder, cssd.Config.DryRun)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if capacity != nil && !capacity.Fits {
+		r.Recorder.Event(cssd, "Warning", "InsufficientCapacity", reconciler.InsufficientCapacityMessage(*capacity))
+	}
+
 	if nsInfos == nil && !retrigger && err == nil {
 		return ctrl.Result{}, nil
 	}
@@ -109,6 +115,9 @@ func (r *ClusterScalingStateDefinitionReconciler) Reconcile(ctx context.Context,
 			if nsInfo.NSEvents.QuotaExceeded != "" {
 				eventsList = append(eventsList, nsInfo.NSEvents.QuotaExceeded)
 			}
+			if nsInfo.NSEvents.QuotaShortfall != "" {
+				r.Recorder.Event(cssd, "Warning", "QuotaShortfall", fmt.Sprintf("Partially scaled because of the ResourceQuotas. %s", nsInfo.NSEvents.QuotaShortfall))
+			}
 
 			appliedStateNamespaceList = append(appliedStateNamespaceList, namespaceKey)
 			appliedStates = append(appliedStates, nsInfo.AppliedState)
@@ -127,23 +136,30 @@ func (r *ClusterScalingStateDefinitionReconciler) Reconcile(ctx context.Context,
 		log.Info("Clusterscalingstatedefinition Reconciliation loop completed")
 
 	} else {
+		reportNamespace, reportName := reconciler.OperatorNamespace(), reconciler.DryRunReportName("ClusterScalingStateDefinition", req.Name)
+		err = reconciler.WriteDryRunReport(ctx, r.Client, r.APIReader, reportNamespace, reportName, reconciler.NewDryRunReport("ClusterScalingStateDefinition/"+req.Name, nsInfos, capacity))
+		if err != nil {
+			log.Error(err, "Failed to write the dry run report")
+		}
+
 		if dryRunCluster == "" {
 			r.Recorder.Event(cssd, "Normal", "DryRun", "DryRun: No changes in any namespace would be made!")
 
 		} else {
-			r.Recorder.Event(cssd, "Normal", "DryRun", fmt.Sprintf("DryRun: %s", dryRunCluster))
+			r.Recorder.Event(cssd, "Normal", "DryRun", fmt.Sprintf("DryRun: %sFull report in ConfigMap %s/%s", dryRunCluster, reportNamespace, reportName))
 		}
 
 	}
 	if retrigger {
-		log.Info(fmt.Sprintf("Not all namespaces reconciled. Retriggering ClusterScalingStateDefinitionController in %d seconds", c.RetriggerControllerSeconds))
-		return ctrl.Result{RequeueAfter: time.Second * c.RetriggerControllerSeconds}, nil
+		retriggerSeconds := config.Get().RetriggerControllerSeconds
+		log.Info(fmt.Sprintf("Not all namespaces reconciled. Retriggering ClusterScalingStateDefinitionController in %d seconds", retriggerSeconds))
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(retriggerSeconds)}, nil
 	}
 
 	// Trigger again in order to catch resources which have been created or updated within the first 10 seconds of startup
 	if time.Since(constants.StartTime).Seconds() < 12 {
 		log.Info("Startup complete. Retriggering ClusterScalingStateDefinitionController one more time")
-		return ctrl.Result{RequeueAfter: time.Second * c.RetriggerControllerSeconds}, nil
+		return ctrl.Result{RequeueAfter: time.Second * time.Duration(config.Get().RetriggerControllerSeconds)}, nil
 	}
 
 	return ctrl.Result{}, nil
@@ -153,7 +169,7 @@ func (r *ClusterScalingStateDefinitionReconciler) Reconcile(ctx context.Context,
 func (r *ClusterScalingStateDefinitionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&scalingv1alpha1.ClusterScalingStateDefinition{}).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.ClusterScalingStateDefinitionController)}).
 		WithEventFilter(validations.DeleteFilter()).
 		Complete(r)
 }
@@ -15,7 +15,9 @@ package controllers
 import (
 	"context"
 
+	"github.com/containersol/prescale-operator/internal/config"
 	"github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/resources"
 	"github.com/containersol/prescale-operator/internal/validations"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
 	"github.com/go-logr/logr"
@@ -50,6 +52,11 @@ func (r *DeploymentConfigWatcher) Reconcile(ctx context.Context, req ctrl.Reques
 		WithValues("reconciler namespace", req.Namespace).
 		WithValues("reconciler object", req.Name)
 
+	// Namespaces the PreScalingOperatorConfig leaves out are not scaled
+	if !resources.NamespaceWatched(ctx, r.Client, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
 	deploymentconfig := ocv1.DeploymentConfig{}
 
 	err := r.Client.Get(ctx, req.NamespacedName, &deploymentconfig)
@@ -57,12 +64,12 @@ func (r *DeploymentConfigWatcher) Reconcile(ctx context.Context, req ctrl.Reques
 		log.Error(err, "Failed to get the deploymentconfig data")
 		return ctrl.Result{}, err
 	}
-	deploymentItem := g.ConvertDeploymentConfigToItem(deploymentconfig)
+	deploymentItem := resources.ApplyNamespaceDefaults(ctx, r.Client, g.ConvertDeploymentConfigToItem(deploymentconfig))
 
 	// After we have the deploymentconfig and state data, we are ready to reconcile the deploymentconfig
-	// Only reconcile if the item is not in a failure state. Failure states are only handled by RectifyScaleItemsInFailureState() in reconciler_cron.go
+	// Only reconcile if the item is not in a failure state. Failure states are retried with backoff by the ScalingExecutor in executor.go
 	if !g.GetDenyList().IsDeploymentInFailureState(deploymentItem) {
-		go reconciler.ReconcileScalingItem(ctx, r.Client, deploymentItem, false, r.Recorder, "DEPLOYMENTCONFIGCONTROLLLER")
+		reconciler.GetScalingExecutor().EnqueueReconcile(ctx, deploymentItem, "DEPLOYMENTCONFIGCONTROLLLER")
 	}
 
 	log.Info("Deploymentconfig Reconciliation loop completed")
@@ -74,8 +81,8 @@ func (r *DeploymentConfigWatcher) Reconcile(ctx context.Context, req ctrl.Reques
 func (r *DeploymentConfigWatcher) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ocv1.DeploymentConfig{}).
-		WithEventFilter(validations.PreFilter(r.Recorder)).
+		WithEventFilter(validations.PreFilter(r.Recorder, mgr.GetClient())).
 		WithEventFilter(validations.StartupFilter()).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.DeploymentConfigWatcherController)}).
 		Complete(r)
 }
@@ -15,6 +15,7 @@ package controllers
 import (
 	"context"
 
+	"github.com/containersol/prescale-operator/internal/config"
 	"github.com/containersol/prescale-operator/internal/reconciler"
 	"github.com/containersol/prescale-operator/internal/resources"
 	"github.com/containersol/prescale-operator/internal/validations"
@@ -50,18 +51,23 @@ func (r *DeploymentWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		WithValues("reconciler kind", "DeploymentWatcher").
 		WithValues("reconciler namespace", req.Namespace).
 		WithValues("reconciler object", req.Name)
+
+	// Namespaces the PreScalingOperatorConfig leaves out are not scaled
+	if !resources.NamespaceWatched(ctx, r.Client, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
 	// Fetch the deployment data
 	deployment, err := resources.DeploymentGetter(ctx, r.Client, req)
 	if err != nil {
 		log.Error(err, "Failed to get the deployment data")
 		return ctrl.Result{}, err
 	}
-	deploymentItem := g.ConvertDeploymentToItem(deployment)
+	deploymentItem := resources.ApplyNamespaceDefaults(ctx, r.Client, g.ConvertDeploymentToItem(deployment))
 
 	// After we have the deployment and state data, we are ready to reconcile the deployment
-	// Only reconcile if the item is not in a failure state. Failure states are only handled by RectifyScaleItemsInFailureState() in reconciler_cron.go
+	// Only reconcile if the item is not in a failure state. Failure states are retried with backoff by the ScalingExecutor in executor.go
 	if !g.GetDenyList().IsDeploymentInFailureState(deploymentItem) {
-		go reconciler.ReconcileScalingItem(ctx, r.Client, deploymentItem, false, r.Recorder, "DEPLOYMENTWATCHCONTROLLER")
+		reconciler.GetScalingExecutor().EnqueueReconcile(ctx, deploymentItem, "DEPLOYMENTWATCHCONTROLLER")
 	}
 
 	log.Info("Deployment Reconciliation loop completed")
@@ -73,8 +79,8 @@ func (r *DeploymentWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ct
 func (r *DeploymentWatcher) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.Deployment{}).
-		WithEventFilter(validations.PreFilter(r.Recorder)).
+		WithEventFilter(validations.PreFilter(r.Recorder, mgr.GetClient())).
 		WithEventFilter(validations.StartupFilter()).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.DeploymentWatcherController)}).
 		Complete(r)
 }
@@ -0,0 +1,76 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+   http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/validations"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// NamespaceWatcher reconciles the workloads of a namespace when its opt-in or defaults change
+type NamespaceWatcher struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=list;watch;
+
+// Reconcile reconciles the replicas of the opted-in workloads of the namespace
+func (r *NamespaceWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	log := r.Log.
+		WithValues("reconciler kind", "NamespaceWatcher").
+		WithValues("reconciler object", req.Name)
+
+	scalingItems, err := resources.ScalingItemNamespaceLister(ctx, r.Client, req.Name, config.OptInSelector())
+	if err != nil {
+		log.Error(err, "Failed to list the scaling items of the namespace")
+		return ctrl.Result{}, err
+	}
+
+	// Failure states are retried with backoff by the ScalingExecutor in executor.go
+	for _, scalingItem := range scalingItems {
+		if !g.GetDenyList().IsDeploymentInFailureState(scalingItem) {
+			reconciler.GetScalingExecutor().EnqueueReconcile(ctx, scalingItem, "NAMESPACEWATCHCONTROLLER")
+		}
+	}
+
+	log.Info("Namespace Reconciliation loop completed")
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceWatcher) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		WithEventFilter(validations.PreFilter(r.Recorder, mgr.GetClient())).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.NamespaceWatcherController)}).
+		Complete(r)
+}
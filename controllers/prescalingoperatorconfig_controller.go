@@ -0,0 +1,108 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+)
+
+// PreScalingOperatorConfigReconciler puts the PreScalingOperatorConfig in effect whenever it changes
+type PreScalingOperatorConfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=prescalingoperatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=prescalingoperatorconfigs/status,verbs=get;update;patch
+
+// Reconcile validates the PreScalingOperatorConfig and puts it in effect. An invalid config leaves the last valid one in effect.
+// The values in effect are written to the status.
+func (r *PreScalingOperatorConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.
+		WithValues("reconciler kind", "PreScalingOperatorConfig").
+		WithValues("reconciler object", req.Name)
+
+	operatorConfig := &v1alpha1.PreScalingOperatorConfig{}
+	err := r.Get(ctx, req.NamespacedName, operatorConfig)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil && req.Name == v1alpha1.OperatorConfigName {
+			log.Info("PreScalingOperatorConfig deleted. Using the defaults")
+			config.Reset()
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if operatorConfig.Name != v1alpha1.OperatorConfigName {
+		operatorConfig.Status = v1alpha1.PreScalingOperatorConfigStatus{
+			Valid:              false,
+			Message:            fmt.Sprintf("Ignored. Only the PreScalingOperatorConfig named %s is used", v1alpha1.OperatorConfigName),
+			ObservedGeneration: operatorConfig.Generation,
+		}
+		return ctrl.Result{}, r.Status().Update(ctx, operatorConfig)
+	}
+
+	err = config.Apply(operatorConfig.Spec)
+	if err != nil {
+		log.Error(err, "Invalid PreScalingOperatorConfig. Keeping the configuration in effect")
+		r.Recorder.Event(operatorConfig, "Warning", "InvalidConfig", err.Error())
+		operatorConfig.Status.Valid = false
+		operatorConfig.Status.Message = fmt.Sprintf("Invalid: %s", err.Error())
+	} else {
+		log.Info("Applied PreScalingOperatorConfig")
+		operatorConfig.Status.Valid = true
+		operatorConfig.Status.Message = ""
+	}
+
+	effective := config.Get()
+	operatorConfig.Status.Effective = &effective
+	operatorConfig.Status.RestartRequired = config.RestartRequired()
+	operatorConfig.Status.ObservedGeneration = operatorConfig.Generation
+	return ctrl.Result{}, r.Status().Update(ctx, operatorConfig)
+}
+
+// LoadOperatorConfig puts the PreScalingOperatorConfig in effect before the controllers are set up, so they start with
+// the configured MaxConcurrentReconciles. The manager's cache isn't running yet, so the reader has to go to the API server.
+func LoadOperatorConfig(ctx context.Context, reader client.Reader) error {
+	operatorConfig := &v1alpha1.PreScalingOperatorConfig{}
+	err := reader.Get(ctx, types.NamespacedName{Name: v1alpha1.OperatorConfigName}, operatorConfig)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return config.Apply(operatorConfig.Spec)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PreScalingOperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.PreScalingOperatorConfig{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.PreScalingOperatorConfigController)}).
+		Complete(r)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+)
+
+// How often a plan waiting for approval is recomputed to find out if it went stale
+const planResyncPeriod = time.Minute
+
+// ScalingPlanReconciler reconciles a ScalingPlan object
+type ScalingPlanReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingplans,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingplans/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingplans/finalizers,verbs=update
+
+// Reconcile computes the changes of a ScalingPlan and applies them once the plan is approved.
+// An approved plan is only applied if the changes are still the same as the computed ones. Otherwise it goes stale.
+// Only an approval given after the computed plan was published applies it.
+func (r *ScalingPlanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.
+		WithValues("reconciler kind", "ScalingPlan").
+		WithValues("reconciler object", req.Name)
+
+	plan := &v1alpha1.ScalingPlan{}
+	err := r.Get(ctx, req.NamespacedName, plan)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Applied plans are done. A new plan needs to be created for the next change.
+	if plan.Status.Phase == v1alpha1.ScalingPlanApplied {
+		return ctrl.Result{}, nil
+	}
+
+	namespaces, err := reconciler.ComputeScalingPlan(ctx, r.Client, *plan)
+	if err != nil {
+		log.Error(err, "Failed to compute the ScalingPlan")
+		plan.Status.Phase = v1alpha1.ScalingPlanFailed
+		plan.Status.Message = fmt.Sprintf("Failed to compute the plan: %s", err.Error())
+		return ctrl.Result{RequeueAfter: planResyncPeriod}, r.Status().Update(ctx, plan)
+	}
+	planHash := reconciler.PlanHash(namespaces)
+
+	targetChanged := plan.Status.State != plan.Spec.State || plan.Status.ScalingClass != plan.Spec.ScalingClass
+	specChanged := plan.Generation != plan.Status.ObservedGeneration
+	// The approval only counts if it was given after the Pending plan was published, which raises the generation.
+	// A plan created or retargeted with approved set is computed for review first.
+	approved := plan.Spec.Approved && plan.Generation > plan.Status.ObservedGeneration
+
+	switch {
+	case plan.Status.Phase == "" || plan.Status.Phase == v1alpha1.ScalingPlanFailed || targetChanged || (specChanged && !plan.Spec.Approved):
+		// New plans, changed plans and plans whose approval was revoked are (re)computed for review
+		plan.Status.Phase = v1alpha1.ScalingPlanPending
+		plan.Status.Message = "Waiting for approval"
+		plan.Status.State = plan.Spec.State
+		plan.Status.ScalingClass = plan.Spec.ScalingClass
+		plan.Status.ObservedGeneration = plan.Generation
+		plan.Status.PlanHash = planHash
+		plan.Status.ComputedTime = metav1.Now()
+		plan.Status.Namespaces = namespaces
+		log.Info("Computed ScalingPlan")
+
+	case planHash != plan.Status.PlanHash:
+		if plan.Status.Phase != v1alpha1.ScalingPlanStale {
+			r.Recorder.Event(plan, "Warning", "Stale", "The cluster changed since the plan was computed. It won't be applied.")
+		}
+		plan.Status.Phase = v1alpha1.ScalingPlanStale
+		plan.Status.Message = "The cluster changed since the plan was computed. Set approved to false to recompute it."
+
+	case approved:
+		err = reconciler.ApplyScalingPlan(ctx, r.Client, *plan)
+		if err != nil {
+			log.Error(err, "Failed to apply the ScalingPlan")
+			r.Recorder.Event(plan, "Warning", "ApplyFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		now := metav1.Now()
+		plan.Status.Phase = v1alpha1.ScalingPlanApplied
+		plan.Status.Message = fmt.Sprintf("State %s set on the ClusterScalingState of class %s", plan.Spec.State, reconciler.PlanScalingClass(*plan))
+		plan.Status.AppliedTime = &now
+		r.Recorder.Event(plan, "Normal", "Applied", plan.Status.Message)
+		log.Info("Applied ScalingPlan")
+
+	case plan.Spec.Approved:
+		plan.Status.Phase = v1alpha1.ScalingPlanPending
+		plan.Status.Message = "Approved before the plan was computed. Set approved to false and back to true after reviewing it."
+
+	default:
+		// The cluster changed back to what the plan was computed for
+		plan.Status.Phase = v1alpha1.ScalingPlanPending
+		plan.Status.Message = "Waiting for approval"
+	}
+
+	err = r.Status().Update(ctx, plan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if plan.Status.Phase == v1alpha1.ScalingPlanApplied {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: planResyncPeriod}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScalingPlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ScalingPlan{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.ScalingPlanController)}).
+		Complete(r)
+}
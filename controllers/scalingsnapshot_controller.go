@@ -0,0 +1,136 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/snapshot"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+)
+
+// How often the drift of a snapshot is checked
+const snapshotResyncPeriod = time.Minute
+
+// ScalingSnapshotReconciler reconciles a ScalingSnapshot object
+type ScalingSnapshotReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingsnapshots,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingsnapshots/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingsnapshots/finalizers,verbs=update
+
+// Reconcile captures the state into a new ScalingSnapshot, keeps its drift up to date and restores it when asked to.
+// A snapshot is restored once per generation, so changing the spec again restores it again.
+func (r *ScalingSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.
+		WithValues("reconciler kind", "ScalingSnapshot").
+		WithValues("reconciler object", req.Name)
+
+	scalingSnapshot := &v1alpha1.ScalingSnapshot{}
+	err := r.Get(ctx, req.NamespacedName, scalingSnapshot)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// The captured state goes into the spec, so a ScalingSnapshot saved to a file can be restored anywhere
+	if scalingSnapshot.Spec.Snapshot == nil {
+		data, err := snapshot.Capture(ctx, r.Client)
+		if err != nil {
+			log.Error(err, "Failed to capture the ScalingSnapshot")
+			scalingSnapshot.Status.Phase = v1alpha1.ScalingSnapshotFailed
+			scalingSnapshot.Status.Message = fmt.Sprintf("Failed to capture the snapshot: %s", err.Error())
+			return ctrl.Result{RequeueAfter: snapshotResyncPeriod}, r.Status().Update(ctx, scalingSnapshot)
+		}
+		scalingSnapshot.Spec.Snapshot = &data
+		r.Recorder.Event(scalingSnapshot, "Normal", "Captured", fmt.Sprintf("Captured %d items", len(data.Items)))
+		log.Info("Captured ScalingSnapshot")
+		// The update triggers the next reconcile, which checks the drift
+		return ctrl.Result{}, r.Update(ctx, scalingSnapshot)
+	}
+
+	if scalingSnapshot.Spec.Restore && scalingSnapshot.Status.RestoredGeneration != scalingSnapshot.Generation {
+		items, err := snapshot.Restore(ctx, r.Client, *scalingSnapshot.Spec.Snapshot)
+		scalingSnapshot.Status.RestoredGeneration = scalingSnapshot.Generation
+		// The items are scaled by the executor like any other change, so the restore doesn't race the operator
+		for _, item := range items {
+			item = resources.ApplyNamespaceDefaults(ctx, r.Client, item)
+			if !g.GetDenyList().IsDeploymentInFailureState(item) {
+				reconciler.GetScalingExecutor().EnqueueReconcile(ctx, item, "SCALINGSNAPSHOTCONTROLLER")
+			}
+		}
+		if err != nil {
+			log.Error(err, "Failed to restore the ScalingSnapshot")
+			r.Recorder.Event(scalingSnapshot, "Warning", "RestoreFailed", err.Error())
+			scalingSnapshot.Status.Phase = v1alpha1.ScalingSnapshotFailed
+			scalingSnapshot.Status.Message = fmt.Sprintf("Failed to restore the snapshot: %s", err.Error())
+		} else {
+			now := metav1.Now()
+			scalingSnapshot.Status.Phase = v1alpha1.ScalingSnapshotRestored
+			scalingSnapshot.Status.Message = fmt.Sprintf("Restored the state captured at %s", scalingSnapshot.Spec.Snapshot.CapturedTime.UTC().Format(time.RFC3339))
+			scalingSnapshot.Status.RestoredTime = &now
+			r.Recorder.Event(scalingSnapshot, "Normal", "Restored", scalingSnapshot.Status.Message)
+			log.Info("Restored ScalingSnapshot")
+		}
+	} else if scalingSnapshot.Status.Phase == "" {
+		scalingSnapshot.Status.Phase = v1alpha1.ScalingSnapshotCaptured
+	}
+
+	drift, err := snapshot.Diff(ctx, r.Client, *scalingSnapshot.Spec.Snapshot)
+	if err != nil {
+		log.Error(err, "Failed to compute the drift of the ScalingSnapshot")
+	} else {
+		now := metav1.Now()
+		scalingSnapshot.Status.Drift = drift
+		scalingSnapshot.Status.CheckedTime = &now
+	}
+
+	err = r.Status().Update(ctx, scalingSnapshot)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: snapshotResyncPeriod}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScalingSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ScalingSnapshot{}).
+		// Status updates don't trigger a reconcile. The drift is checked every snapshotResyncPeriod.
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.ScalingSnapshotController)}).
+		Complete(r)
+}
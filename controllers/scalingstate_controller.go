@@ -29,8 +29,10 @@ import (
 
 	"github.com/containersol/prescale-operator/api/v1alpha1"
 	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
 	"github.com/containersol/prescale-operator/internal/reconciler"
 	"github.com/containersol/prescale-operator/internal/states"
+	"github.com/containersol/prescale-operator/internal/tracing"
 	"github.com/containersol/prescale-operator/internal/validations"
 )
 
@@ -40,6 +42,8 @@ type ScalingStateReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// APIReader reads the dry run report ConfigMaps without the cache
+	APIReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingstates,verbs=get;list;watch;create;update;patch;delete
@@ -62,10 +66,16 @@ func (r *ScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		WithValues("reconciler namespace", req.Namespace).
 		WithValues("reconciler object", req.Name)
 
+	// Root span of the transition of the namespace
+	ctx, span := tracing.Start(ctx, "ScalingStateTransition", tracing.NamespaceKey.String(req.Namespace), tracing.NameKey.String(req.Name))
+	defer span.End()
+
 	ss := &v1alpha1.ScalingState{}
 	err := r.Get(ctx, req.NamespacedName, ss)
 	if err != nil {
 		log.Error(err, "Scalingstate could not be found! It might've been deleted. Reconciling.")
+	} else {
+		span.SetAttributes(tracing.StateKey.String(ss.Spec.State), tracing.DryRunKey.Bool(ss.Config.DryRun))
 	}
 
 	clusterStateDefinitions, err := states.GetClusterScalingStates(ctx, r.Client)
@@ -73,17 +83,31 @@ func (r *ScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		// If we encounter an error trying to retrieve the state definitions,
 		// we will not be able to compute anything else.
 		log.Error(err, "Failed to get ClusterStateDefinitions")
+		span.RecordError(err)
 		return ctrl.Result{}, err
 	}
 
 	log.WithValues("Namespace", req.Namespace).
 		Info("Scalingstate Controller: Reconciling namespace")
 
-	nsInfos, _, err := reconciler.PrepareForNamespaceReconcile(ctx, r.Client, req.Namespace, clusterStateDefinitions, states.State{}, r.Recorder, ss.Config.DryRun)
+	nsInfos, capacity, _, err := reconciler.PrepareForNamespaceReconcile(ctx, r.Client, req.Namespace, clusterStateDefinitions, states.State{}, r.Recorder, ss.Config.DryRun)
 	if err != nil {
+		span.RecordError(err)
 		return ctrl.Result{}, err
 	}
 
+	if capacity != nil && !capacity.Fits {
+		r.Recorder.Event(ss, "Warning", "InsufficientCapacity", reconciler.InsufficientCapacityMessage(*capacity))
+	}
+
+	reportName := reconciler.DryRunReportName("ScalingState", req.Name)
+	if ss.Config.DryRun {
+		err = reconciler.WriteDryRunReport(ctx, r.Client, r.APIReader, req.Namespace, reportName, reconciler.NewDryRunReport("ScalingState/"+req.Namespace+"/"+req.Name, nsInfos, capacity))
+		if err != nil {
+			log.Error(err, "Failed to write the dry run report")
+		}
+	}
+
 	if len(nsInfos) == 0 && ss.Config.DryRun {
 		r.Recorder.Event(ss, "Normal", "DryRun", "DryRun: No changes in any namespace would be made!")
 	}
@@ -94,12 +118,15 @@ func (r *ScalingStateReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			if nsInfo.NSEvents.QuotaExceeded != "" {
 				r.Recorder.Event(ss, "Warning", "QuotaExceeded", fmt.Sprintf("Not enough available resources for namespace %s", nsInfo.NSEvents.QuotaExceeded))
 			}
+			if nsInfo.NSEvents.QuotaShortfall != "" {
+				r.Recorder.Event(ss, "Warning", "QuotaShortfall", fmt.Sprintf("Partially scaled because of the ResourceQuotas. %s", nsInfo.NSEvents.QuotaShortfall))
+			}
 
 			log.Info("Scalingstate Reconciliation loop completed successfully")
 
 		} else {
 
-			r.Recorder.Event(ss, "Normal", "DryRun", fmt.Sprintf("DryRun: %s", nsInfo.NSEvents.DryRunInfo))
+			r.Recorder.Event(ss, "Normal", "DryRun", fmt.Sprintf("DryRun: %sFull report in ConfigMap %s/%s", nsInfo.NSEvents.DryRunInfo, req.Namespace, reportName))
 
 		}
 	}
@@ -112,7 +139,7 @@ func (r *ScalingStateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&scalingv1alpha1.ScalingState{}).
 		WithEventFilter(validations.StartupFilter()).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 5}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles(config.ScalingStateController)}).
 		Owns(&scalingv1alpha1.ClusterScalingState{}).
 		Complete(r)
 }
@@ -0,0 +1,251 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+)
+
+const (
+	defaultInterval     = 30 * time.Second
+	defaultPauseTimeout = 600 * time.Second
+	// queryTimeout limits a single query
+	queryTimeout = 10 * time.Second
+)
+
+var (
+	mu sync.RWMutex
+	// defaultAddress is the Prometheus compatible API of gates without an address
+	defaultAddress string
+)
+
+// SetDefaultAddress sets the API the gates without an address query, from the --analysis-prometheus-url flag
+func SetDefaultAddress(address string) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultAddress = address
+}
+
+// For returns the analysis gate of an item. The annotations of the workload replace the analysis of its state.
+func For(item g.ScalingInfo, definition v1alpha1.ClusterScalingStateDefinition) (v1alpha1.AnalysisGate, bool) {
+	if query := item.Annotations[constants.AnalysisQueryAnnotation]; query != "" {
+		return v1alpha1.AnalysisGate{
+			Address:   item.Annotations[constants.AnalysisAddressAnnotation],
+			Query:     query,
+			Threshold: item.Annotations[constants.AnalysisThresholdAnnotation],
+			OnFailure: item.Annotations[constants.AnalysisOnFailureAnnotation],
+		}, true
+	}
+	for _, state := range definition.Spec {
+		if state.Name == item.State && state.Analysis != nil {
+			return *state.Analysis, true
+		}
+	}
+	return v1alpha1.AnalysisGate{}, false
+}
+
+// OnFailure of the gate, Pause if it isn't set
+func OnFailure(gate v1alpha1.AnalysisGate) string {
+	switch gate.OnFailure {
+	case v1alpha1.AnalysisContinue, v1alpha1.AnalysisAbort:
+		return gate.OnFailure
+	}
+	return v1alpha1.AnalysisPause
+}
+
+// Interval between the evaluations of a paused gate
+func Interval(gate v1alpha1.AnalysisGate) time.Duration {
+	if gate.IntervalSeconds > 0 {
+		return time.Duration(gate.IntervalSeconds) * time.Second
+	}
+	return defaultInterval
+}
+
+// PauseTimeout is the longest a gate may pause the step scaler
+func PauseTimeout(gate v1alpha1.AnalysisGate) time.Duration {
+	if gate.PauseTimeoutSeconds > 0 {
+		return time.Duration(gate.PauseTimeoutSeconds) * time.Second
+	}
+	return defaultPauseTimeout
+}
+
+// Evaluate runs the query of the gate for the item. It returns nil if every sample meets the threshold.
+// A failed query or a result without samples is an error, as nothing tells that the workload is healthy.
+func Evaluate(ctx context.Context, httpClient *http.Client, gate v1alpha1.AnalysisGate, item g.ScalingInfo) error {
+	compare, err := ParseThreshold(gate.Threshold)
+	if err != nil {
+		return err
+	}
+	query := strings.NewReplacer("${namespace}", item.Namespace, "${name}", item.Name).Replace(gate.Query)
+	samples, err := Query(ctx, httpClient, address(gate), query)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("the query %s returned no data", query)
+	}
+	for _, sample := range samples {
+		if !compare(sample.Value) {
+			return fmt.Errorf("%s is %g, which is not %s", sample.Name(), sample.Value, strings.TrimSpace(gate.Threshold))
+		}
+	}
+	return nil
+}
+
+func address(gate v1alpha1.AnalysisGate) string {
+	if gate.Address != "" {
+		return gate.Address
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultAddress
+}
+
+// ParseThreshold turns a threshold like "< 0.05" into a comparison with the samples
+func ParseThreshold(threshold string) (func(float64) bool, error) {
+	threshold = strings.TrimSpace(threshold)
+	// The two character operators first, so <= isn't read as <
+	for _, op := range []string{"<=", ">=", "==", "!=", "<", ">"} {
+		if !strings.HasPrefix(threshold, op) {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(threshold, op)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %w", threshold, err)
+		}
+		switch op {
+		case "<=":
+			return func(sample float64) bool { return sample <= value }, nil
+		case ">=":
+			return func(sample float64) bool { return sample >= value }, nil
+		case "==":
+			return func(sample float64) bool { return sample == value }, nil
+		case "!=":
+			return func(sample float64) bool { return sample != value }, nil
+		case "<":
+			return func(sample float64) bool { return sample < value }, nil
+		default:
+			return func(sample float64) bool { return sample > value }, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid threshold %q: it must start with one of <, <=, >, >=, == or !=", threshold)
+}
+
+// Sample is a value of the result of a query
+type Sample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+// Name of the series of the sample, like {code="500"}, for the messages
+func (s Sample) Name() string {
+	if len(s.Metric) == 0 {
+		return "the result"
+	}
+	labels := []string{}
+	for key, value := range s.Metric {
+		if key == "__name__" {
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%s=%q", key, value))
+	}
+	sort.Strings(labels)
+	return s.Metric["__name__"] + "{" + strings.Join(labels, ",") + "}"
+}
+
+// response of the instant query API of Prometheus
+type response struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query runs an instant query against the Prometheus compatible API at the address
+func Query(ctx context.Context, httpClient *http.Client, address string, query string) ([]Sample, error) {
+	if address == "" {
+		return nil, fmt.Errorf("no Prometheus address: set the address of the gate or --analysis-prometheus-url")
+	}
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(address, "/")+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	result := response{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%s answered with %s", req.URL.Host, resp.Status)
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("query %s failed: %s %s", query, result.ErrorType, result.Error)
+	}
+
+	switch result.Data.ResultType {
+	case "vector":
+		vector := []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}{}
+		if err := json.Unmarshal(result.Data.Result, &vector); err != nil {
+			return nil, err
+		}
+		samples := []Sample{}
+		for _, v := range vector {
+			value, err := sampleValue(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, Sample{Metric: v.Metric, Value: value})
+		}
+		return samples, nil
+	case "scalar":
+		scalar := []interface{}{}
+		if err := json.Unmarshal(result.Data.Result, &scalar); err != nil {
+			return nil, err
+		}
+		value, err := sampleValue(scalar)
+		if err != nil {
+			return nil, err
+		}
+		return []Sample{{Value: value}}, nil
+	}
+	return nil, fmt.Errorf("query %s returned a %s, but only instant vectors and scalars can be compared", query, result.Data.ResultType)
+}
+
+// sampleValue reads a [<time>, "<value>"] pair
+func sampleValue(pair []interface{}) (float64, error) {
+	if len(pair) != 2 {
+		return 0, fmt.Errorf("unexpected sample %v", pair)
+	}
+	value, ok := pair[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value %v", pair[1])
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+)
+
+// prometheus answers instant queries with the canned results by query
+func prometheus(t *testing.T, results map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		result, found := results[r.URL.Query().Get("query")]
+		if !found {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":` + result + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		threshold string
+		sample    float64
+		want      bool
+	}{
+		{"< 0.05", 0.01, true},
+		{"< 0.05", 0.05, false},
+		{"<=0.05", 0.05, true},
+		{" > 100 ", 150, true},
+		{">= 0.99", 0.98, false},
+		{"== 1", 1, true},
+		{"!= 0", 0, false},
+	}
+	for _, test := range tests {
+		compare, err := ParseThreshold(test.threshold)
+		if err != nil {
+			t.Fatalf("ParseThreshold(%q) error = %v", test.threshold, err)
+		}
+		if got := compare(test.sample); got != test.want {
+			t.Errorf("ParseThreshold(%q)(%g) = %v, want %v", test.threshold, test.sample, got, test.want)
+		}
+	}
+	for _, threshold := range []string{"0.05", "~ 1", "< high"} {
+		if _, err := ParseThreshold(threshold); err == nil {
+			t.Errorf("ParseThreshold(%q) didn't fail", threshold)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	definition := v1alpha1.ClusterScalingStateDefinition{Spec: []v1alpha1.States{
+		{Name: "peak", Analysis: &v1alpha1.AnalysisGate{Query: "errors", Threshold: "< 0.05"}},
+		{Name: "bau"},
+	}}
+	if gate, found := For(g.ScalingInfo{State: "peak"}, definition); !found || gate.Query != "errors" {
+		t.Errorf("For() = %+v, %v, want the gate of the state", gate, found)
+	}
+	if _, found := For(g.ScalingInfo{State: "bau"}, definition); found {
+		t.Errorf("For() found a gate for a state without analysis")
+	}
+	item := g.ScalingInfo{State: "peak", Annotations: map[string]string{"scaler/analysis-query": "latency", "scaler/analysis-threshold": "< 0.3", "scaler/analysis-on-failure": "Abort"}}
+	if gate, found := For(item, definition); !found || gate.Query != "latency" || gate.Threshold != "< 0.3" || OnFailure(gate) != v1alpha1.AnalysisAbort {
+		t.Errorf("For() = %+v, %v, want the gate of the annotations", gate, found)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	server := prometheus(t, map[string]string{
+		`errors{namespace="shop",deployment="web"}`: `{"resultType":"vector","result":[{"metric":{"code":"500"},"value":[1700000000,"0.01"]},{"metric":{"code":"503"},"value":[1700000000,"0.02"]}]}`,
+		`latency`:    `{"resultType":"vector","result":[{"metric":{"__name__":"latency","route":"/cart"},"value":[1700000000,"0.7"]}]}`,
+		`scalar(up)`: `{"resultType":"scalar","result":[1700000000,"1"]}`,
+		`missing`:    `{"resultType":"vector","result":[]}`,
+		`range`:      `{"resultType":"matrix","result":[]}`,
+	})
+	item := g.ScalingInfo{Namespace: "shop", Name: "web"}
+	tests := []struct {
+		name    string
+		gate    v1alpha1.AnalysisGate
+		wantErr string
+	}{
+		{"healthy", v1alpha1.AnalysisGate{Address: server.URL, Query: `errors{namespace="${namespace}",deployment="${name}"}`, Threshold: "< 0.05"}, ""},
+		{"regressed", v1alpha1.AnalysisGate{Address: server.URL, Query: "latency", Threshold: "< 0.3"}, `latency{route="/cart"} is 0.7, which is not < 0.3`},
+		{"scalar", v1alpha1.AnalysisGate{Address: server.URL, Query: "scalar(up)", Threshold: "== 1"}, ""},
+		{"no data", v1alpha1.AnalysisGate{Address: server.URL, Query: "missing", Threshold: "< 1"}, "no data"},
+		{"matrix", v1alpha1.AnalysisGate{Address: server.URL, Query: "range", Threshold: "< 1"}, "only instant vectors and scalars"},
+		{"query error", v1alpha1.AnalysisGate{Address: server.URL, Query: "unknown(", Threshold: "< 1"}, "parse error"},
+		{"no address", v1alpha1.AnalysisGate{Query: "latency", Threshold: "< 1"}, "no Prometheus address"},
+	}
+	for _, test := range tests {
+		err := Evaluate(context.TODO(), server.Client(), test.gate, item)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("Evaluate() %s error = %v", test.name, err)
+		}
+		if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+			t.Errorf("Evaluate() %s error = %v, want %q", test.name, err, test.wantErr)
+		}
+	}
+
+	SetDefaultAddress(server.URL)
+	defer SetDefaultAddress("")
+	if err := Evaluate(context.TODO(), server.Client(), v1alpha1.AnalysisGate{Query: "scalar(up)", Threshold: "> 0"}, item); err != nil {
+		t.Errorf("Evaluate() with the default address error = %v", err)
+	}
+}
@@ -0,0 +1,408 @@
+// Package api serves the HTTP/JSON management API of the operator. It lets systems without kube credentials list the
+// states, follow the progress and switch states. All of it is backed by the custom resources, so GitOps tools and the
+// API see the same state.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/states"
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// How long the server waits for running requests on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// The largest request body the server reads. A switch is a few hundred bytes.
+const maxRequestBytes = 64 * 1024
+
+// Timeouts of the connections, so slow or idle clients don't hold on to them. Computing a plan of a large cluster
+// can take a while, so writing the response gets the most time.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 2 * time.Minute
+	idleTimeout       = 2 * time.Minute
+)
+
+// Server serves the management API
+type Server struct {
+	client   client.Client
+	addr     string
+	certFile string
+	keyFile  string
+	// insecure allows serving plain HTTP without a certificate
+	insecure bool
+	log      logr.Logger
+	// authorize checks if the caller of a request may do what the attributes describe. It returns the HTTP status to
+	// answer with if not.
+	authorize func(ctx context.Context, r *http.Request, attributes authorizationv1.ResourceAttributes) (int, error)
+}
+
+// NewServer creates a Server listening on addr. Without a certificate and key it only serves plain HTTP if insecure
+// is set, as the bearer tokens of the callers would go over the wire in cleartext.
+func NewServer(_client client.Client, addr string, certFile string, keyFile string, insecure bool, log logr.Logger) *Server {
+	s := &Server{client: _client, addr: addr, certFile: certFile, keyFile: keyFile, insecure: insecure, log: log}
+	s.authorize = s.reviewAccess
+	return s
+}
+
+// Start serves the API until the context is cancelled. It implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	if s.certFile == "" && !s.insecure {
+		return errors.New("the management API needs a TLS certificate and key. Allow plain HTTP explicitly to serve it without")
+	}
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		s.log.Info("Serving the management API", "address", s.addr, "tls", s.certFile != "")
+		if s.certFile != "" {
+			errs <- server.ListenAndServeTLS(s.certFile, s.keyFile)
+		} else {
+			errs <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection lets every replica serve the API. All changes go through the custom resources.
+// It implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/states", s.get(s.states))
+	mux.HandleFunc("/api/v1/status", s.get(s.status))
+	mux.HandleFunc("/api/v1/plan", s.get(s.plan))
+	mux.HandleFunc("/api/v1/classes/", s.put(s.switchClass))
+	mux.HandleFunc("/api/v1/namespaces/", s.put(s.switchNamespace))
+	return mux
+}
+
+// apiError is an error with the HTTP status to answer with
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e apiError) Error() string {
+	return e.err.Error()
+}
+
+type handler func(r *http.Request) (interface{}, error)
+
+func (s *Server) get(h handler) http.HandlerFunc {
+	return s.serve(http.MethodGet, h)
+}
+
+func (s *Server) put(h handler) http.HandlerFunc {
+	return s.serve(http.MethodPut, h)
+}
+
+func (s *Server) serve(method string, h handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: fmt.Sprintf("only %s is allowed", method)})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		response, err := h(r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var apiErr apiError
+			if errors.As(err, &apiErr) {
+				status = apiErr.status
+			}
+			if status == http.StatusInternalServerError {
+				s.log.Error(err, "Management API request failed", "method", r.Method, "path", r.URL.Path)
+			}
+			writeJSON(w, status, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// check answers with the status of the authorizer if the caller may not do what the attributes describe
+func (s *Server) check(r *http.Request, attributes authorizationv1.ResourceAttributes) error {
+	attributes.Group = v1alpha1.GroupVersion.Group
+	status, err := s.authorize(r.Context(), r, attributes)
+	if err != nil {
+		return apiError{status: status, err: err}
+	}
+	return nil
+}
+
+// reviewAccess authenticates the bearer token of the request with a TokenReview and authorizes its user with a
+// SubjectAccessReview. Callers need the RBAC permissions on the custom resources an endpoint reads or changes.
+func (s *Server) reviewAccess(ctx context.Context, r *http.Request, attributes authorizationv1.ResourceAttributes) (int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return http.StatusUnauthorized, errors.New("a bearer token is needed")
+	}
+
+	tokenReview := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	err := s.client.Create(ctx, tokenReview)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to review the token: %w", err)
+	}
+	if !tokenReview.Status.Authenticated {
+		return http.StatusUnauthorized, errors.New("the token is not valid")
+	}
+
+	user := tokenReview.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	accessReview := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User:               user.Username,
+		UID:                user.UID,
+		Groups:             user.Groups,
+		Extra:              extra,
+		ResourceAttributes: &attributes,
+	}}
+	err = s.client.Create(ctx, accessReview)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to review the access: %w", err)
+	}
+	if !accessReview.Status.Allowed {
+		return http.StatusForbidden, fmt.Errorf("%s may not %s %s", user.Username, attributes.Verb, attributes.Resource)
+	}
+	return http.StatusOK, nil
+}
+
+// states lists the states of the ClusterScalingStateDefinition
+func (s *Server) states(r *http.Request) (interface{}, error) {
+	err := s.check(r, authorizationv1.ResourceAttributes{Verb: "list", Resource: "clusterscalingstatedefinitions"})
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := states.GetClusterScalingStateDefinitionsList(r.Context(), s.client)
+	if err != nil {
+		return nil, err
+	}
+	response := []StateDefinition{}
+	for _, state := range definitions.Items[0].Spec {
+		response = append(response, StateDefinition{Name: state.Name, Description: state.Description, Priority: state.Priority})
+	}
+	return response, nil
+}
+
+// status returns the states of the classes and namespaces and the progress of the items
+func (s *Server) status(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	namespace := r.URL.Query().Get("namespace")
+	attributes := authorizationv1.ResourceAttributes{Verb: "list", Resource: "clusterscalingstates"}
+	if namespace != "" {
+		attributes = authorizationv1.ResourceAttributes{Verb: "list", Resource: "scalingstates", Namespace: namespace}
+	}
+	err := s.check(r, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, s.client)
+	if err != nil {
+		return nil, err
+	}
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err = s.client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return nil, err
+	}
+	scalingStates := v1alpha1.ScalingStateList{}
+	err = s.client.List(ctx, &scalingStates, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+	items, err := resources.ScalingItemNamespaceLister(ctx, s.client, namespace, config.OptInSelector())
+	if err != nil {
+		return nil, err
+	}
+	items, _, err = resources.WithTargets(ctx, s.client, items, stateDefinitions, clusterScalingStates)
+	if err != nil {
+		return nil, err
+	}
+	return newStatus(clusterScalingStates, scalingStates, items), nil
+}
+
+// plan returns the latest ScalingPlan of a class. With a state, it computes a new dry run plan instead.
+func (s *Server) plan(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	err := s.check(r, authorizationv1.ResourceAttributes{Verb: "list", Resource: "scalingplans"})
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	scalingPlan := v1alpha1.ScalingPlan{Spec: v1alpha1.ScalingPlanSpec{State: query.Get("state"), ScalingClass: query.Get("class")}}
+	class := reconciler.PlanScalingClass(scalingPlan)
+
+	if scalingPlan.Spec.State != "" {
+		namespaces, err := reconciler.ComputeScalingPlan(ctx, s.client, scalingPlan)
+		if err != nil {
+			return nil, apiError{status: http.StatusBadRequest, err: err}
+		}
+		return Plan{State: scalingPlan.Spec.State, ScalingClass: class, Namespaces: namespaces}, nil
+	}
+
+	plans := v1alpha1.ScalingPlanList{}
+	err = s.client.List(ctx, &plans)
+	if err != nil {
+		return nil, err
+	}
+	var latest *v1alpha1.ScalingPlan
+	for i, p := range plans.Items {
+		if p.Status.Phase == "" || reconciler.PlanScalingClass(p) != class {
+			continue
+		}
+		if latest == nil || latest.Status.ComputedTime.Before(&p.Status.ComputedTime) {
+			latest = &plans.Items[i]
+		}
+	}
+	if latest == nil {
+		return nil, apiError{status: http.StatusNotFound, err: fmt.Errorf("no ScalingPlan of class %s", class)}
+	}
+	computed := latest.Status.ComputedTime.Time
+	return Plan{
+		Name:         latest.Name,
+		State:        latest.Status.State,
+		ScalingClass: class,
+		Phase:        latest.Status.Phase,
+		Message:      latest.Status.Message,
+		ComputedTime: &computed,
+		Namespaces:   latest.Status.Namespaces,
+	}, nil
+}
+
+// switchClass sets the state of the ClusterScalingState of a class: PUT /api/v1/classes/<class>/state
+func (s *Server) switchClass(r *http.Request) (interface{}, error) {
+	class, err := pathName(r, "/api/v1/classes/")
+	if err != nil {
+		return nil, err
+	}
+	err = s.check(r, authorizationv1.ResourceAttributes{Verb: "update", Resource: "clusterscalingstates"})
+	if err != nil {
+		return nil, err
+	}
+	state, expires, err := s.switchRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	err = reconciler.SwitchClusterState(r.Context(), s.client, class, state, expires)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("Switched the state of a class", "class", class, "state", state, "expires", expires)
+	return SwitchResponse{State: state, ExpiresAt: expires}, nil
+}
+
+// switchNamespace sets the state of the ScalingState of a namespace: PUT /api/v1/namespaces/<namespace>/state
+func (s *Server) switchNamespace(r *http.Request) (interface{}, error) {
+	namespace, err := pathName(r, "/api/v1/namespaces/")
+	if err != nil {
+		return nil, err
+	}
+	err = s.check(r, authorizationv1.ResourceAttributes{Verb: "update", Resource: "scalingstates", Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	state, expires, err := s.switchRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	_, err = reconciler.SwitchNamespaceState(r.Context(), s.client, namespace, state, expires)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("Switched the state of a namespace", "namespace", namespace, "state", state, "expires", expires)
+	return SwitchResponse{State: state, ExpiresAt: expires}, nil
+}
+
+// pathName returns the name in a path like <prefix><name>/state
+func pathName(r *http.Request, prefix string) (string, error) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), "/state")
+	if name == "" || strings.Contains(name, "/") || !strings.HasSuffix(r.URL.Path, "/state") {
+		return "", apiError{status: http.StatusNotFound, err: fmt.Errorf("no such path %s", r.URL.Path)}
+	}
+	return name, nil
+}
+
+// switchRequest reads and validates the body of a switch
+func (s *Server) switchRequest(r *http.Request) (string, *time.Time, error) {
+	request := SwitchRequest{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		return "", nil, apiError{status: http.StatusBadRequest, err: fmt.Errorf("invalid request: %w", err)}
+	}
+
+	stateDefinitions, err := states.GetClusterScalingStates(r.Context(), s.client)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := stateDefinitions.FindState(request.State, &states.State{}); err != nil {
+		return "", nil, apiError{status: http.StatusBadRequest, err: err}
+	}
+
+	var expires *time.Time
+	switch {
+	case request.ExpiresIn != "" && request.ExpiresAt != nil:
+		return "", nil, apiError{status: http.StatusBadRequest, err: errors.New("only one of expiresIn and expiresAt can be set")}
+	case request.ExpiresIn != "":
+		duration, err := time.ParseDuration(request.ExpiresIn)
+		if err != nil || duration <= 0 {
+			return "", nil, apiError{status: http.StatusBadRequest, err: fmt.Errorf("invalid expiresIn %q", request.ExpiresIn)}
+		}
+		expiry := time.Now().Add(duration).Truncate(time.Second)
+		expires = &expiry
+	case request.ExpiresAt != nil:
+		if !request.ExpiresAt.After(time.Now()) {
+			return "", nil, apiError{status: http.StatusBadRequest, err: errors.New("expiresAt is in the past")}
+		}
+		expires = request.ExpiresAt
+	}
+	return request.State, expires, nil
+}
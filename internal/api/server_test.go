@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	v1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newTestServer(t *testing.T, allowed func(authorizationv1.ResourceAttributes) bool) (*Server, client.Client) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	replicas := int32(2)
+	_client := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+			&v1alpha1.ClusterScalingStateDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "states"},
+				Spec:       []v1alpha1.States{{Name: "peak", Priority: 1}, {Name: "bau", Priority: 5}},
+			},
+			&v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "bau"}},
+			&v1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "web",
+					Namespace:   "shop",
+					Labels:      map[string]string{"scaler/opt-in": "true"},
+					Annotations: map[string]string{"scaler/state-bau-replicas": "2", "scaler/state-peak-replicas": "6"},
+				},
+				Spec:   v1.DeploymentSpec{Replicas: &replicas, ProgressDeadlineSeconds: new(int32)},
+				Status: v1.DeploymentStatus{ReadyReplicas: 2, AvailableReplicas: 2},
+			},
+		).
+		Build()
+
+	s := NewServer(_client, ":0", "", "", true, ctrllog.NullLogger{})
+	s.authorize = func(ctx context.Context, r *http.Request, attributes authorizationv1.ResourceAttributes) (int, error) {
+		if !allowed(attributes) {
+			return http.StatusForbidden, errors.New("forbidden")
+		}
+		return http.StatusOK, nil
+	}
+	return s, _client
+}
+
+func request(t *testing.T, s *Server, method string, path string, body string, response interface{}) int {
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if response != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Fatalf("%s %s returned no JSON: %v", method, path, err)
+		}
+	}
+	return recorder.Code
+}
+
+func allowAll(authorizationv1.ResourceAttributes) bool {
+	return true
+}
+
+func TestStates(t *testing.T) {
+	s, _ := newTestServer(t, allowAll)
+	response := []StateDefinition{}
+	if code := request(t, s, http.MethodGet, "/api/v1/states", "", &response); code != http.StatusOK {
+		t.Fatalf("GET /api/v1/states = %d, want 200", code)
+	}
+	if len(response) != 2 || response[0].Name != "peak" || response[1].Priority != 5 {
+		t.Errorf("GET /api/v1/states = %v, want peak and bau", response)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s, _ := newTestServer(t, allowAll)
+	response := Status{}
+	if code := request(t, s, http.MethodGet, "/api/v1/status", "", &response); code != http.StatusOK {
+		t.Fatalf("GET /api/v1/status = %d, want 200", code)
+	}
+	if len(response.Classes) != 1 || response.Classes[0].State != "bau" || response.Classes[0].Progress["Done"] != 1 {
+		t.Errorf("GET /api/v1/status classes = %+v, want default in bau with one item done", response.Classes)
+	}
+	want := ItemStatus{Namespace: "shop", Kind: "Deployment", Name: "web", Class: "default", State: "bau", Replicas: 2, ReadyReplicas: 2, TargetReplicas: 2, Progress: "Done"}
+	if len(response.Items) != 1 || response.Items[0] != want {
+		t.Errorf("GET /api/v1/status items = %+v, want %+v", response.Items, want)
+	}
+}
+
+func TestSwitchClass(t *testing.T) {
+	s, _client := newTestServer(t, allowAll)
+	response := SwitchResponse{}
+	code := request(t, s, http.MethodPut, "/api/v1/classes/default/state", `{"state": "peak", "expiresIn": "2h"}`, &response)
+	if code != http.StatusOK {
+		t.Fatalf("PUT /api/v1/classes/default/state = %d, want 200", code)
+	}
+	if response.State != "peak" || response.ExpiresAt == nil {
+		t.Errorf("PUT /api/v1/classes/default/state = %+v, want peak with an expiry", response)
+	}
+
+	css := &v1alpha1.ClusterScalingState{}
+	_ = _client.Get(context.TODO(), client.ObjectKey{Name: "default"}, css)
+	if css.Spec.State != "peak" || css.Annotations[constants.StateRevertAnnotation] != "bau" || css.Annotations[constants.StateExpiresAnnotation] == "" {
+		t.Errorf("ClusterScalingState = %v with annotations %v, want peak reverting to bau", css.Spec.State, css.Annotations)
+	}
+
+	if code := request(t, s, http.MethodPut, "/api/v1/classes/default/state", `{"state": "unknown"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("PUT of an unknown state = %d, want 400", code)
+	}
+	if code := request(t, s, http.MethodGet, "/api/v1/classes/default/state", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET of a switch = %d, want 405", code)
+	}
+	tooLarge := `{"state": "peak", "expiresIn": "` + strings.Repeat("1", maxRequestBytes) + `s"}`
+	if code := request(t, s, http.MethodPut, "/api/v1/classes/default/state", tooLarge, nil); code != http.StatusBadRequest {
+		t.Errorf("PUT of a body over the limit = %d, want 400", code)
+	}
+}
+
+func TestSwitchNamespace(t *testing.T) {
+	s, _client := newTestServer(t, func(attributes authorizationv1.ResourceAttributes) bool {
+		return attributes.Resource == "scalingstates" && attributes.Namespace == "shop" && attributes.Verb == "update"
+	})
+	if code := request(t, s, http.MethodPut, "/api/v1/namespaces/shop/state", `{"state": "peak"}`, &SwitchResponse{}); code != http.StatusOK {
+		t.Fatalf("PUT /api/v1/namespaces/shop/state = %d, want 200", code)
+	}
+	ss := &v1alpha1.ScalingState{}
+	_ = _client.Get(context.TODO(), client.ObjectKey{Namespace: "shop", Name: "scalingstate-shop"}, ss)
+	if ss.Spec.State != "peak" {
+		t.Errorf("ScalingState = %v, want peak", ss.Spec.State)
+	}
+
+	// The caller may only switch the namespace
+	if code := request(t, s, http.MethodPut, "/api/v1/classes/default/state", `{"state": "peak"}`, nil); code != http.StatusForbidden {
+		t.Errorf("PUT /api/v1/classes/default/state = %d, want 403", code)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	s, _client := newTestServer(t, allowAll)
+	response := Plan{}
+	if code := request(t, s, http.MethodGet, "/api/v1/plan?state=peak", "", &response); code != http.StatusOK {
+		t.Fatalf("GET /api/v1/plan?state=peak = %d, want 200", code)
+	}
+	if len(response.Namespaces) != 1 || response.Namespaces[0].Items[0].DesiredReplicas != 6 {
+		t.Errorf("GET /api/v1/plan?state=peak = %+v, want web scaled to 6", response)
+	}
+
+	if code := request(t, s, http.MethodGet, "/api/v1/plan", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET /api/v1/plan without plans = %d, want 404", code)
+	}
+	_ = _client.Create(context.TODO(), &v1alpha1.ScalingPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "to-peak"},
+		Spec:       v1alpha1.ScalingPlanSpec{State: "peak"},
+		Status:     v1alpha1.ScalingPlanStatus{Phase: v1alpha1.ScalingPlanPending, State: "peak", ComputedTime: metav1.Now()},
+	})
+	if code := request(t, s, http.MethodGet, "/api/v1/plan", "", &response); code != http.StatusOK || response.Name != "to-peak" {
+		t.Errorf("GET /api/v1/plan = %d %+v, want the to-peak plan", code, response)
+	}
+}
+
+func TestReviewAccess(t *testing.T) {
+	s := NewServer(fake.NewClientBuilder().Build(), ":0", "", "", true, ctrllog.NullLogger{})
+	code := request(t, s, http.MethodGet, "/api/v1/states", "", nil)
+	if code != http.StatusUnauthorized {
+		t.Errorf("GET /api/v1/states without token = %d, want 401", code)
+	}
+}
+
+func TestStartRequiresTLS(t *testing.T) {
+	s := NewServer(fake.NewClientBuilder().Build(), ":0", "", "", false, ctrllog.NullLogger{})
+	if err := s.Start(context.TODO()); err == nil {
+		t.Errorf("Start() without a certificate = nil, want an error unless insecure is set")
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StateDefinition is a state of the ClusterScalingStateDefinition
+type StateDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Priority    int32  `json:"priority"`
+}
+
+// Status holds the states of the classes and namespaces and the progress of the items
+type Status struct {
+	Classes    []ClassStatus     `json:"classes"`
+	Namespaces []NamespaceStatus `json:"namespaces"`
+	Items      []ItemStatus      `json:"items"`
+}
+
+// ClassStatus is the state of the ClusterScalingState of a class and how many of its items are in which progress
+type ClassStatus struct {
+	Class string `json:"class"`
+	State string `json:"state"`
+	Expiry
+	Progress map[string]int `json:"progress"`
+}
+
+// NamespaceStatus is the state of the ScalingState of a namespace
+type NamespaceStatus struct {
+	Namespace string `json:"namespace"`
+	State     string `json:"state"`
+	Expiry
+}
+
+// Expiry tells when a state goes back to the previous one
+type Expiry struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// RevertTo is empty if the custom resource is deleted once the state expires
+	RevertTo string `json:"revertTo,omitempty"`
+}
+
+// ItemStatus is the effective state of an item and its progress. TargetReplicas is -1 if the state has no replicas for the item.
+type ItemStatus struct {
+	Namespace      string `json:"namespace"`
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	Class          string `json:"class"`
+	State          string `json:"state,omitempty"`
+	Replicas       int32  `json:"replicas"`
+	ReadyReplicas  int32  `json:"readyReplicas"`
+	TargetReplicas int32  `json:"targetReplicas"`
+	Progress       string `json:"progress"`
+	Message        string `json:"message,omitempty"`
+}
+
+// Plan holds the changes of switching a class to a state
+type Plan struct {
+	// Name of the ScalingPlan. Empty for plans computed for the request.
+	Name         string                          `json:"name,omitempty"`
+	State        string                          `json:"state"`
+	ScalingClass string                          `json:"scalingClass"`
+	Phase        string                          `json:"phase,omitempty"`
+	Message      string                          `json:"message,omitempty"`
+	ComputedTime *time.Time                      `json:"computedTime,omitempty"`
+	Namespaces   []v1alpha1.ScalingPlanNamespace `json:"namespaces"`
+}
+
+// SwitchRequest switches a class or namespace to a state. With expiresIn, like 2h, or expiresAt the previous state comes back.
+type SwitchRequest struct {
+	State     string     `json:"state"`
+	ExpiresIn string     `json:"expiresIn,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// SwitchResponse confirms a switch
+type SwitchResponse struct {
+	State     string     `json:"state"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func expiry(obj client.Object) Expiry {
+	expires, revertTo, found := reconciler.StateExpiry(obj)
+	if !found {
+		return Expiry{}
+	}
+	return Expiry{ExpiresAt: &expires, RevertTo: revertTo}
+}
+
+// newStatus builds the status from the custom resources and the items with resolved targets
+func newStatus(clusterScalingStates v1alpha1.ClusterScalingStateList, scalingStates v1alpha1.ScalingStateList, items []g.ScalingInfo) Status {
+	status := Status{Classes: []ClassStatus{}, Namespaces: []NamespaceStatus{}, Items: []ItemStatus{}}
+
+	classes := map[string]int{}
+	for i, css := range clusterScalingStates.Items {
+		class := states.GetAppliedScalingClassFromClusterScalingState(css).Name
+		classes[class] = len(status.Classes)
+		status.Classes = append(status.Classes, ClassStatus{
+			Class:    class,
+			State:    css.Spec.State,
+			Expiry:   expiry(&clusterScalingStates.Items[i]),
+			Progress: map[string]int{},
+		})
+	}
+	for i, ss := range scalingStates.Items {
+		status.Namespaces = append(status.Namespaces, NamespaceStatus{
+			Namespace: ss.Namespace,
+			State:     ss.Spec.State,
+			Expiry:    expiry(&scalingStates.Items[i]),
+		})
+	}
+
+	for _, item := range items {
+		class := states.GetAppliedScalingClassFromScalingItem(item).Name
+		itemStatus := ItemStatus{
+			Namespace:      item.Namespace,
+			Kind:           item.ItemTypeName,
+			Name:           item.Name,
+			Class:          class,
+			State:          item.State,
+			Replicas:       item.SpecReplica,
+			ReadyReplicas:  item.ReadyReplicas,
+			TargetReplicas: item.DesiredReplicas,
+			Progress:       resources.Progress(item),
+		}
+		if item.Failure {
+			itemStatus.Message = item.FailureMessage
+		}
+		status.Items = append(status.Items, itemStatus)
+		if i, found := classes[class]; found {
+			status.Classes[i].Progress[itemStatus.Progress]++
+		}
+	}
+
+	sort.Slice(status.Classes, func(i, j int) bool {
+		return status.Classes[i].Class < status.Classes[j].Class
+	})
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Namespace < status.Namespaces[j].Namespace
+	})
+	sort.Slice(status.Items, func(i, j int) bool {
+		if status.Items[i].Namespace != status.Items[j].Namespace {
+			return status.Items[i].Namespace < status.Items[j].Namespace
+		}
+		return status.Items[i].Name < status.Items[j].Name
+	})
+	return status
+}
@@ -0,0 +1,129 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/resources"
+	sr "github.com/containersol/prescale-operator/internal/state_replicas"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Options select the state to capture and the items to capture it from
+type Options struct {
+	// State is the name of the new state
+	State string
+	// Priority of the state in the ClusterScalingStateDefinition
+	Priority int32
+	// Description of the state in the ClusterScalingStateDefinition
+	Description string
+	// Namespace limits the capture to one namespace. Empty captures all watched namespaces.
+	Namespace string
+	// ScalingClass limits the capture to the items of one scaling class. Empty captures all classes.
+	ScalingClass string
+	// Overwrite allows capturing a state that is already defined and replaces the replicas of its annotations
+	Overwrite bool
+	// DryRun only returns the items that would be annotated
+	DryRun bool
+}
+
+// State records the current replicas of the opted-in items as the replicas of a new state and registers the state
+// in the ClusterScalingStateDefinition. It returns the captured items with their replicas.
+func State(ctx context.Context, _client client.Client, opts Options) ([]g.ScalingInfo, error) {
+	annotation := sr.StateReplicaAnnotationPrefix + opts.State + sr.StateReplicaAnnotationSuffix
+	if opts.State == "" || opts.State == constants.DefaultReplicaAnnotation {
+		return nil, fmt.Errorf("%q can't be captured as a state", opts.State)
+	}
+	if errs := validation.IsQualifiedName(annotation); len(errs) > 0 {
+		return nil, fmt.Errorf("%q is no valid state name: %s", opts.State, strings.Join(errs, ", "))
+	}
+
+	definitions, err := states.GetClusterScalingStateDefinitionsList(ctx, _client)
+	if err != nil {
+		return nil, err
+	}
+	definition := definitions.Items[0]
+	defined := false
+	for _, state := range definition.Spec {
+		if state.Name == opts.State {
+			defined = true
+		}
+	}
+	if defined && !opts.Overwrite {
+		return nil, fmt.Errorf("state %s is already defined in ClusterScalingStateDefinition %s", opts.State, definition.Name)
+	}
+
+	items, err := resources.ScalingItemNamespaceLister(ctx, _client, opts.Namespace, config.OptInSelector())
+	if err != nil {
+		return nil, err
+	}
+	captured := []g.ScalingInfo{}
+	for _, item := range items {
+		if opts.ScalingClass == "" || states.GetAppliedScalingClassFromScalingItem(item).Name == opts.ScalingClass {
+			captured = append(captured, item)
+		}
+	}
+	sort.Slice(captured, func(i, j int) bool {
+		if captured[i].Namespace != captured[j].Namespace {
+			return captured[i].Namespace < captured[j].Namespace
+		}
+		return captured[i].Name < captured[j].Name
+	})
+	if opts.DryRun {
+		return captured, nil
+	}
+
+	// The items are annotated first, so the state has replicas for all of them once it can be switched to
+	for _, item := range captured {
+		err = annotate(ctx, _client, item, annotation)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s/%s: %w", item.ItemTypeName, item.Namespace, item.Name, err)
+		}
+	}
+
+	if defined {
+		for i := range definition.Spec {
+			if definition.Spec[i].Name == opts.State {
+				definition.Spec[i].Priority = opts.Priority
+				if opts.Description != "" {
+					definition.Spec[i].Description = opts.Description
+				}
+			}
+		}
+	} else {
+		definition.Spec = append(definition.Spec, v1alpha1.States{
+			Name:        opts.State,
+			Description: opts.Description,
+			Priority:    opts.Priority,
+		})
+	}
+	err = _client.Update(ctx, &definition)
+	if err != nil {
+		return nil, err
+	}
+	return captured, nil
+}
+
+// annotate sets the state replicas annotation of the item to its current replicas
+func annotate(ctx context.Context, _client client.Client, item g.ScalingInfo, annotation string) error {
+	obj, err := resources.ScalingItemObject(ctx, _client, item)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotation] = fmt.Sprint(item.SpecReplica)
+	obj.SetAnnotations(annotations)
+	return _client.Patch(ctx, obj, patch)
+}
@@ -0,0 +1,93 @@
+package capture
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func deployment(name string, replicas int32, labels map[string]string) *v1.Deployment {
+	return &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: labels},
+		Spec:       v1.DeploymentSpec{Replicas: &replicas, ProgressDeadlineSeconds: new(int32)},
+	}
+}
+
+func TestState(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	newClient := func() client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+				&v1alpha1.ClusterScalingStateDefinition{
+					ObjectMeta: metav1.ObjectMeta{Name: "states"},
+					Spec:       []v1alpha1.States{{Name: "peak", Priority: 1}, {Name: "bau", Priority: 5}},
+				},
+				deployment("web", 7, map[string]string{"scaler/opt-in": "true"}),
+				deployment("batch", 3, map[string]string{"scaler/opt-in": "true", "scaler/scaling-class": "batch"}),
+				deployment("unrelated", 1, nil),
+			).
+			Build()
+	}
+	ctx := context.TODO()
+
+	t.Run("TestCapture", func(t *testing.T) {
+		_client := newClient()
+		captured, err := State(ctx, _client, Options{State: "black-friday", Priority: 2, Description: "Tuned for the 2026 sale"})
+		if err != nil {
+			t.Fatalf("State() error = %v", err)
+		}
+		if len(captured) != 2 {
+			t.Errorf("State() captured %d items, want 2", len(captured))
+		}
+		for name, want := range map[string]string{"web": "7", "batch": "3", "unrelated": ""} {
+			d := &v1.Deployment{}
+			_ = _client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: name}, d)
+			if got := d.Annotations["scaler/state-black-friday-replicas"]; got != want {
+				t.Errorf("State() annotation of %s = %q, want %q", name, got, want)
+			}
+		}
+		definition := &v1alpha1.ClusterScalingStateDefinition{}
+		_ = _client.Get(ctx, client.ObjectKey{Name: "states"}, definition)
+		want := v1alpha1.States{Name: "black-friday", Description: "Tuned for the 2026 sale", Priority: 2}
+		if len(definition.Spec) != 3 || definition.Spec[2] != want {
+			t.Errorf("State() definition = %v, want %v added", definition.Spec, want)
+		}
+	})
+
+	t.Run("TestScalingClass", func(t *testing.T) {
+		captured, err := State(ctx, newClient(), Options{State: "night", Priority: 9, ScalingClass: "batch", DryRun: true})
+		if err != nil {
+			t.Fatalf("State() error = %v", err)
+		}
+		if len(captured) != 1 || captured[0].Name != "batch" {
+			t.Errorf("State() = %v, want only the batch deployment", captured)
+		}
+	})
+
+	t.Run("TestDefinedState", func(t *testing.T) {
+		_client := newClient()
+		if _, err := State(ctx, _client, Options{State: "peak", Priority: 1}); err == nil {
+			t.Errorf("State() error = nil, want an error for the defined state")
+		}
+		if _, err := State(ctx, _client, Options{State: "peak", Priority: 1, Overwrite: true}); err != nil {
+			t.Errorf("State() error = %v with overwrite", err)
+		}
+	})
+
+	t.Run("TestInvalidState", func(t *testing.T) {
+		for _, state := range []string{"", "default", "black friday"} {
+			if _, err := State(ctx, newClient(), Options{State: state}); err == nil {
+				t.Errorf("State(%q) error = nil, want an error", state)
+			}
+		}
+	})
+}
@@ -0,0 +1,299 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/quotas"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Names of the controllers whose MaxConcurrentReconciles can be configured
+const (
+	ClusterScalingStateDefinitionController = "ClusterScalingStateDefinition"
+	ClusterScalingStateController           = "ClusterScalingState"
+	ScalingStateController                  = "ScalingState"
+	ScalingPlanController                   = "ScalingPlan"
+	ScalingSnapshotController               = "ScalingSnapshot"
+	PreScalingOperatorConfigController      = "PreScalingOperatorConfig"
+	DeploymentWatcherController             = "DeploymentWatcher"
+	DeploymentConfigWatcherController       = "DeploymentConfigWatcher"
+	RedisClusterWatcherController           = "RedisClusterWatcher"
+	NamespaceWatcherController              = "NamespaceWatcher"
+)
+
+// IncludeNamespacesSetting is reported by RestartRequired if the includeNamespaces changed after the start
+const IncludeNamespacesSetting = "includeNamespaces"
+
+// The configuration is read on every use, so a change of the PreScalingOperatorConfig applies without a restart.
+// The only exceptions are the MaxConcurrentReconciles of the controllers, which are read once when they are set up,
+// and the IncludeNamespaces the cache is built for.
+var (
+	mu       sync.RWMutex
+	defaults = Defaults()
+	current  = Defaults()
+	// started holds the MaxConcurrentReconciles the controllers were set up with
+	started = map[string]int32{}
+	// watched holds the namespaces the cache was built for, nil if it wasn't restricted
+	watched []string
+)
+
+// Defaults returns the built-in configuration
+func Defaults() v1alpha1.OperatorConfigValues {
+	return v1alpha1.OperatorConfigValues{
+		MaxConcurrentNamespaceReconciles: 1,
+		RetriggerControllerSeconds:       15,
+		OptInLabel:                       "scaler/opt-in",
+		QuotaPolicy:                      quotas.QuotaPolicyAllOrNothing,
+		CapacityCheck:                    false,
+		MaxConcurrentReconciles: map[string]int32{
+			ClusterScalingStateDefinitionController: 1,
+			ClusterScalingStateController:           1,
+			ScalingStateController:                  5,
+			ScalingPlanController:                   1,
+			ScalingSnapshotController:               1,
+			PreScalingOperatorConfigController:      1,
+			DeploymentWatcherController:             1,
+			DeploymentConfigWatcherController:       1,
+			RedisClusterWatcherController:           1,
+			NamespaceWatcherController:              1,
+		},
+	}
+}
+
+// SetDefaults sets the values used for the fields the PreScalingOperatorConfig leaves out, e.g. from command line flags.
+// It resets the configuration in effect to these values.
+func SetDefaults(values v1alpha1.OperatorConfigValues) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaults = copyValues(values)
+	current = copyValues(values)
+}
+
+// Get returns the configuration in effect
+func Get() v1alpha1.OperatorConfigValues {
+	mu.RLock()
+	defer mu.RUnlock()
+	return copyValues(current)
+}
+
+// Apply validates the spec and puts it in effect. Fields left out of the spec get their defaults.
+// If the spec is invalid, the configuration in effect doesn't change.
+func Apply(spec v1alpha1.PreScalingOperatorConfigSpec) error {
+	if err := Validate(spec); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	values := copyValues(defaults)
+	if spec.MaxConcurrentNamespaceReconciles != nil {
+		values.MaxConcurrentNamespaceReconciles = *spec.MaxConcurrentNamespaceReconciles
+	}
+	if spec.RetriggerControllerSeconds != nil {
+		values.RetriggerControllerSeconds = *spec.RetriggerControllerSeconds
+	}
+	if spec.OptInLabel != "" {
+		values.OptInLabel = spec.OptInLabel
+	}
+	if spec.OptInSelector != nil {
+		values.OptInSelector = spec.OptInSelector.DeepCopy()
+	}
+	if spec.IncludeNamespaces != nil {
+		values.IncludeNamespaces = append([]string{}, spec.IncludeNamespaces...)
+	}
+	if spec.ExcludeNamespaces != nil {
+		values.ExcludeNamespaces = append([]string{}, spec.ExcludeNamespaces...)
+	}
+	if spec.NamespaceSelector != nil {
+		values.NamespaceSelector = spec.NamespaceSelector.DeepCopy()
+	}
+	if spec.QuotaPolicy != "" {
+		values.QuotaPolicy = spec.QuotaPolicy
+	}
+	if spec.CapacityCheck != nil {
+		values.CapacityCheck = *spec.CapacityCheck
+	}
+	for controller, maxConcurrentReconciles := range spec.MaxConcurrentReconciles {
+		values.MaxConcurrentReconciles[controller] = maxConcurrentReconciles
+	}
+	current = values
+	return nil
+}
+
+// Reset puts the defaults back in effect, e.g. when the PreScalingOperatorConfig is deleted
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	current = copyValues(defaults)
+}
+
+// Validate checks the spec. The CRD validates most of it already, this also covers what the schema can't express.
+func Validate(spec v1alpha1.PreScalingOperatorConfigSpec) error {
+	problems := []string{}
+	if spec.MaxConcurrentNamespaceReconciles != nil && *spec.MaxConcurrentNamespaceReconciles < 1 {
+		problems = append(problems, "maxConcurrentNamespaceReconciles must be at least 1")
+	}
+	if spec.RetriggerControllerSeconds != nil && *spec.RetriggerControllerSeconds < 1 {
+		problems = append(problems, "retriggerControllerSeconds must be at least 1")
+	}
+	if spec.OptInLabel != "" {
+		for _, problem := range validation.IsQualifiedName(spec.OptInLabel) {
+			problems = append(problems, fmt.Sprintf("optInLabel: %s", problem))
+		}
+	}
+	for field, selector := range map[string]*metav1.LabelSelector{"optInSelector": spec.OptInSelector, "namespaceSelector": spec.NamespaceSelector} {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", field, err.Error()))
+		}
+	}
+	for field, namespaces := range map[string][]string{"includeNamespaces": spec.IncludeNamespaces, "excludeNamespaces": spec.ExcludeNamespaces} {
+		for _, namespace := range namespaces {
+			for _, problem := range validation.IsDNS1123Label(namespace) {
+				problems = append(problems, fmt.Sprintf("%s: %q %s", field, namespace, problem))
+			}
+		}
+	}
+	if spec.QuotaPolicy != "" && !quotas.IsQuotaPolicy(spec.QuotaPolicy) {
+		problems = append(problems, fmt.Sprintf("unknown quotaPolicy %q", spec.QuotaPolicy))
+	}
+	known := Defaults().MaxConcurrentReconciles
+	for controller, maxConcurrentReconciles := range spec.MaxConcurrentReconciles {
+		if _, found := known[controller]; !found {
+			problems = append(problems, fmt.Sprintf("unknown controller %q in maxConcurrentReconciles", controller))
+		} else if maxConcurrentReconciles < 1 {
+			problems = append(problems, fmt.Sprintf("maxConcurrentReconciles of %s must be at least 1", controller))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// OptInSelector returns the selector of the workloads the operator scales: the OptInSelector if set, the OptInLabel otherwise
+func OptInSelector() labels.Selector {
+	values := Get()
+	if values.OptInSelector != nil {
+		// The selector was validated when it was applied
+		if selector, err := metav1.LabelSelectorAsSelector(values.OptInSelector); err == nil {
+			return selector
+		}
+	}
+	return labels.SelectorFromSet(labels.Set{values.OptInLabel: "true"})
+}
+
+// OptedIn tells if a workload with these labels is scaled by the operator
+func OptedIn(workloadLabels map[string]string) bool {
+	return OptInSelector().Matches(labels.Set(workloadLabels))
+}
+
+// NamespaceLabelsNeeded tells if WatchesNamespace needs the labels of the namespace
+func NamespaceLabelsNeeded() bool {
+	return Get().NamespaceSelector != nil
+}
+
+// WatchesNamespace tells if the operator scales the workloads of the namespace. The labels are only needed if
+// NamespaceLabelsNeeded.
+func WatchesNamespace(namespace string, namespaceLabels map[string]string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(current.IncludeNamespaces) > 0 && !contains(current.IncludeNamespaces, namespace) {
+		return false
+	}
+	// The cache only holds the namespaces it was built for, until a restart puts a changed list in effect
+	if watched != nil && !contains(watched, namespace) {
+		return false
+	}
+	if contains(current.ExcludeNamespaces, namespace) {
+		return false
+	}
+	if current.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(current.NamespaceSelector)
+		if err != nil || !selector.Matches(labels.Set(namespaceLabels)) {
+			return false
+		}
+	}
+	return true
+}
+
+// WatchedNamespaces returns the namespaces the cache has to be built for and records them, or nil to watch all of them.
+// Called once before the manager is created.
+func WatchedNamespaces() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(current.IncludeNamespaces) == 0 {
+		watched = nil
+		return nil
+	}
+	watched = append([]string{}, current.IncludeNamespaces...)
+	return append([]string{}, watched...)
+}
+
+// MaxConcurrentReconciles returns the number of objects the controller reconciles in parallel and records it.
+// Called once when the controller is set up.
+func MaxConcurrentReconciles(controller string) int {
+	mu.Lock()
+	defer mu.Unlock()
+	maxConcurrentReconciles, found := current.MaxConcurrentReconciles[controller]
+	if !found || maxConcurrentReconciles < 1 {
+		maxConcurrentReconciles = 1
+	}
+	started[controller] = maxConcurrentReconciles
+	return int(maxConcurrentReconciles)
+}
+
+// RestartRequired returns the controllers that run with other MaxConcurrentReconciles than the ones in effect,
+// and IncludeNamespacesSetting if the cache was built for other namespaces
+func RestartRequired() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := []string{}
+	for controller, maxConcurrentReconciles := range started {
+		if current.MaxConcurrentReconciles[controller] != maxConcurrentReconciles {
+			result = append(result, controller)
+		}
+	}
+	if !sameNamespaces(watched, current.IncludeNamespaces) {
+		result = append(result, IncludeNamespacesSetting)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sameNamespaces(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, namespace := range a {
+		if !contains(b, namespace) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func copyValues(values v1alpha1.OperatorConfigValues) v1alpha1.OperatorConfigValues {
+	result := values.DeepCopy()
+	if result.MaxConcurrentReconciles == nil {
+		result.MaxConcurrentReconciles = map[string]int32{}
+	}
+	return *result
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/quotas"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApply(t *testing.T) {
+	defer SetDefaults(Defaults())
+	SetDefaults(Defaults())
+
+	namespaces := int32(3)
+	err := Apply(v1alpha1.PreScalingOperatorConfigSpec{
+		MaxConcurrentNamespaceReconciles: &namespaces,
+		QuotaPolicy:                      quotas.QuotaPolicyProportional,
+		MaxConcurrentReconciles:          map[string]int32{ScalingPlanController: 2},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := Defaults()
+	want.MaxConcurrentNamespaceReconciles = 3
+	want.QuotaPolicy = quotas.QuotaPolicyProportional
+	want.MaxConcurrentReconciles[ScalingPlanController] = 2
+	if got := Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+
+	Reset()
+	if got := Get(); !reflect.DeepEqual(got, Defaults()) {
+		t.Errorf("Get() after Reset() = %v, want %v", got, Defaults())
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	defer SetDefaults(Defaults())
+	SetDefaults(Defaults())
+
+	zero := int32(0)
+	tests := []struct {
+		name string
+		spec v1alpha1.PreScalingOperatorConfigSpec
+	}{
+		{name: "TestNamespacesBelowOne", spec: v1alpha1.PreScalingOperatorConfigSpec{MaxConcurrentNamespaceReconciles: &zero}},
+		{name: "TestRetriggerBelowOne", spec: v1alpha1.PreScalingOperatorConfigSpec{RetriggerControllerSeconds: &zero}},
+		{name: "TestInvalidOptInLabel", spec: v1alpha1.PreScalingOperatorConfigSpec{OptInLabel: "scaler/opt in"}},
+		{name: "TestUnknownQuotaPolicy", spec: v1alpha1.PreScalingOperatorConfigSpec{QuotaPolicy: "best-guess"}},
+		{name: "TestUnknownController", spec: v1alpha1.PreScalingOperatorConfigSpec{MaxConcurrentReconciles: map[string]int32{"Unknown": 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Apply(tt.spec); err == nil {
+				t.Errorf("Apply() error = nil, want an error")
+			}
+			if got := Get(); !reflect.DeepEqual(got, Defaults()) {
+				t.Errorf("Get() = %v, want the configuration to stay unchanged", got)
+			}
+		})
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	defer SetDefaults(Defaults())
+	SetDefaults(Defaults())
+	started = map[string]int32{}
+
+	if got := MaxConcurrentReconciles(ScalingStateController); got != 5 {
+		t.Errorf("MaxConcurrentReconciles() = %v, want %v", got, 5)
+	}
+	if got := RestartRequired(); len(got) != 0 {
+		t.Errorf("RestartRequired() = %v, want none", got)
+	}
+
+	err := Apply(v1alpha1.PreScalingOperatorConfigSpec{MaxConcurrentReconciles: map[string]int32{ScalingStateController: 10}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got, want := RestartRequired(), []string{ScalingStateController}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RestartRequired() = %v, want %v", got, want)
+	}
+}
+
+func TestOptedIn(t *testing.T) {
+	defer SetDefaults(Defaults())
+	SetDefaults(Defaults())
+
+	if !OptedIn(map[string]string{"scaler/opt-in": "true"}) {
+		t.Errorf("OptedIn() with the opt-in label = false, want true")
+	}
+
+	err := Apply(v1alpha1.PreScalingOperatorConfigSpec{OptInSelector: &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"payments", "checkout"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if OptedIn(map[string]string{"scaler/opt-in": "true"}) {
+		t.Errorf("OptedIn() with the opt-in label and a selector = true, want false")
+	}
+	if !OptedIn(map[string]string{"team": "checkout"}) {
+		t.Errorf("OptedIn() with matching labels = false, want true")
+	}
+}
+
+func TestWatchesNamespace(t *testing.T) {
+	defer SetDefaults(Defaults())
+	SetDefaults(Defaults())
+
+	if !WatchesNamespace("anything", nil) {
+		t.Errorf("WatchesNamespace() without restrictions = false, want true")
+	}
+
+	err := Apply(v1alpha1.PreScalingOperatorConfigSpec{
+		IncludeNamespaces: []string{"team-a", "team-b", "kube-system"},
+		ExcludeNamespaces: []string{"kube-system"},
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"scaling": "enabled"}},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	enabled := map[string]string{"scaling": "enabled"}
+	tests := []struct {
+		name      string
+		namespace string
+		labels    map[string]string
+		want      bool
+	}{
+		{name: "TestIncludedAndSelected", namespace: "team-a", labels: enabled, want: true},
+		{name: "TestNotIncluded", namespace: "team-c", labels: enabled, want: false},
+		{name: "TestExcluded", namespace: "kube-system", labels: enabled, want: false},
+		{name: "TestNotSelected", namespace: "team-b", labels: map[string]string{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WatchesNamespace(tt.namespace, tt.labels); got != tt.want {
+				t.Errorf("WatchesNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got, want := RestartRequired(), []string{IncludeNamespacesSetting}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RestartRequired() = %v, want %v", got, want)
+	}
+}
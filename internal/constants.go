@@ -25,9 +25,62 @@ const (
 	//Key for the default replica annotation
 	DefaultReplicaAnnotation = "default"
 
+	//EnvMaxConcurrentNamespaceReconciles is deprecated. It's only read on startup as default of the PreScalingOperatorConfig.
 	EnvMaxConcurrentNamespaceReconciles = "MaxConcurrentNamespaceReconciles"
 
-	RetriggerControllerSeconds = 15
+	//EnvOperatorNamespace holds the namespace the operator runs in. Reports of cluster-wide custom resources are written there.
+	EnvOperatorNamespace = "OPERATOR_NAMESPACE"
+
+	//DefaultOperatorNamespace is used if EnvOperatorNamespace is not set
+	DefaultOperatorNamespace = "default"
+
+	//DryRunReportKey is the key of the report in the dry run ConfigMap
+	DryRunReportKey = "report.json"
+
+	//RetryAnnotation triggers a manual retry of an item the operator gave up scaling when it's set or changed
+	RetryAnnotation = "scaler/retry-now"
+
+	//PriorityAnnotation orders the items of a namespace when resources are tight. Higher values are scaled up first and down last.
+	PriorityAnnotation = "scaler/priority"
+
+	//NamespaceOptInAnnotation on a namespace opts in all of its workloads, except those with the opt-in label set to false
+	NamespaceOptInAnnotation = "scaler/opt-in"
+
+	//RapidScalingAnnotation makes the operator scale to the desired replicas at once instead of step by step
+	RapidScalingAnnotation = "scaler/rapid-scaling"
+
+	//StateExpiresAnnotation on a ClusterScalingState or ScalingState holds the RFC3339 time its state expires
+	StateExpiresAnnotation = "scaler/state-expires-at"
+
+	//StateRevertAnnotation holds the state to go back to once the state expires. Without it, the custom resource is deleted.
+	StateRevertAnnotation = "scaler/state-revert-to"
+
+	//HookLabel holds the name of the transition hook on the Jobs the operator creates for hooks
+	HookLabel = "scaler/hook"
+
+	//HookScalingClassLabel holds the scaling class of the transition on the Jobs of hooks
+	HookScalingClassLabel = "scaler/hook-scaling-class"
+
+	//PausedAnnotation set to "true" on a workload stops the operator from scaling it
+	PausedAnnotation = "scaler/paused"
+
+	//AnalysisQueryAnnotation on a workload sets the query of its analysis gate. It replaces the analysis of the state.
+	AnalysisQueryAnnotation = "scaler/analysis-query"
+
+	//AnalysisThresholdAnnotation holds the threshold of the analysis gate of a workload, like "< 0.05"
+	AnalysisThresholdAnnotation = "scaler/analysis-threshold"
+
+	//AnalysisOnFailureAnnotation holds what to do if the analysis gate of a workload fails: Pause, Continue or Abort
+	AnalysisOnFailureAnnotation = "scaler/analysis-on-failure"
+
+	//AnalysisAddressAnnotation overrides the Prometheus compatible API the analysis gate of a workload queries
+	AnalysisAddressAnnotation = "scaler/analysis-address"
+
+	//EnforcementAnnotation tells what the operator does about replica changes of others: enforce, warn or ignore
+	EnforcementAnnotation = "scaler/enforcement"
+
+	//WakeUpTimeoutAnnotation holds the seconds the first pods of a workload scaled up from zero replicas get to become ready
+	WakeUpTimeoutAnnotation = "scaler/wake-up-timeout-seconds"
 )
 
 type ScalingClass struct {
@@ -35,9 +88,6 @@ type ScalingClass struct {
 }
 
 var (
-	//OptInLabel represents the label key and value for the opted-in applications
-	OptInLabel = map[string]string{"scaler/opt-in": "true"}
-
 	//OpenshiftCluster is used to identify if the operator is running in an Openshift cluster
 	OpenshiftCluster bool
 
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("e2e Test for the authentication and authorization of the management API", func() {
+
+	const timeout = time.Second * 30
+	const interval = time.Millisecond * 200
+	const namespaceName = "e2e-tests-api"
+
+	var namespace corev1.Namespace
+	var serviceAccount corev1.ServiceAccount
+	var cssd v1alpha1.ClusterScalingStateDefinition
+	var server *api.Server
+
+	// getStates calls GET /api/v1/states with the token through the real TokenReview and SubjectAccessReview path
+	getStates := func(token string) int {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/states", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	serviceAccountToken := func() string {
+		clientset, err := kubernetes.NewForConfig(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		tokenRequest, err := clientset.CoreV1().ServiceAccounts(namespaceName).CreateToken(context.Background(), serviceAccount.Name, &authenticationv1.TokenRequest{}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return tokenRequest.Status.Token
+	}
+
+	BeforeEach(func() {
+		namespace = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+		Expect(k8sClient.Create(context.Background(), &namespace)).Should(Succeed())
+		serviceAccount = corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "api-caller", Namespace: namespaceName}}
+		Expect(k8sClient.Create(context.Background(), &serviceAccount)).Should(Succeed())
+		cssd = CreateClusterScalingStateDefinition()
+		Expect(k8sClient.Create(context.Background(), &cssd)).Should(Succeed())
+
+		server = api.NewServer(k8sClient, ":0", "", "", true, ctrl.Log.WithName("api"))
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(context.Background(), &cssd)).Should(Succeed())
+		Expect(k8sClient.Delete(context.Background(), &namespace)).Should(Succeed())
+	})
+
+	It("rejects tokens the cluster doesn't know", func() {
+		Expect(getStates("not-a-token")).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("forbids callers without the RBAC permissions and allows them once they are granted", func() {
+		token := serviceAccountToken()
+		Expect(getStates(token)).Should(Equal(http.StatusForbidden))
+
+		role := rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "e2e-tests-api-caller"},
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{v1alpha1.GroupVersion.Group},
+				Resources: []string{"clusterscalingstatedefinitions"},
+				Verbs:     []string{"list"},
+			}},
+		}
+		Expect(k8sClient.Create(context.Background(), &role)).Should(Succeed())
+		binding := rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "e2e-tests-api-caller"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role.Name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccount.Name, Namespace: namespaceName}},
+		}
+		Expect(k8sClient.Create(context.Background(), &binding)).Should(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(context.Background(), &binding)).Should(Succeed())
+			Expect(k8sClient.Delete(context.Background(), &role)).Should(Succeed())
+		}()
+
+		// The authorizer of the API server picks up the binding with a short delay
+		Eventually(func() int {
+			return getStates(token)
+		}, timeout, interval).Should(Equal(http.StatusOK))
+	})
+})
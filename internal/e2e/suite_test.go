@@ -23,7 +23,6 @@ import (
 	"time"
 
 	constants "github.com/containersol/prescale-operator/internal"
-	"github.com/joho/godotenv"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	dc "github.com/openshift/api/apps/v1"
@@ -41,6 +40,9 @@ import (
 	"github.com/containersol/prescale-operator/api/v1alpha1"
 	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
 	"github.com/containersol/prescale-operator/controllers"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/hooks"
+	"github.com/containersol/prescale-operator/internal/reconciler"
 	"github.com/containersol/prescale-operator/internal/validations"
 	// +kubebuilder:scaffold:imports
 )
@@ -72,7 +74,8 @@ var _ = BeforeSuite(func() {
 		UseExistingCluster: &useCluster,
 	}
 
-	cfg, err := testEnv.Start()
+	var err error
+	cfg, err = testEnv.Start()
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
@@ -104,27 +107,39 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	// All scaling work runs on the executor, like in the operator
+	err = k8sManager.Add(reconciler.NewScalingExecutor(k8sManager.GetClient(), k8sManager.GetEventRecorderFor("scaling-executor"), 0, 0))
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&controllers.ClusterScalingStateDefinitionReconciler{
-		Client:   k8sManager.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ClusterScalingStateDefinition"),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: k8sManager.GetEventRecorderFor("clusterscalingstatedefinition-controller"),
+		Client:    k8sManager.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ClusterScalingStateDefinition"),
+		Scheme:    k8sManager.GetScheme(),
+		Recorder:  k8sManager.GetEventRecorderFor("clusterscalingstatedefinition-controller"),
+		APIReader: k8sManager.GetAPIReader(),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
+	hookRunner := hooks.NewRunner(k8sManager.GetClient(), k8sManager.GetEventRecorderFor("clusterscalingstate-controller"))
+	err = k8sManager.Add(hookRunner)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&controllers.ClusterScalingStateReconciler{
-		Client:   k8sManager.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ClusterScalingState"),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: k8sManager.GetEventRecorderFor("clusterscalingstate-controller"),
+		Client:    k8sManager.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ClusterScalingState"),
+		Scheme:    k8sManager.GetScheme(),
+		Recorder:  k8sManager.GetEventRecorderFor("clusterscalingstate-controller"),
+		APIReader: k8sManager.GetAPIReader(),
+		Hooks:     hookRunner,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
 	err = (&controllers.ScalingStateReconciler{
-		Client:   k8sManager.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ScalingState"),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: k8sManager.GetEventRecorderFor("scalingstate-controller"),
+		Client:    k8sManager.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ScalingState"),
+		Scheme:    k8sManager.GetScheme(),
+		Recorder:  k8sManager.GetEventRecorderFor("scalingstate-controller"),
+		APIReader: k8sManager.GetAPIReader(),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -149,7 +164,9 @@ var _ = BeforeSuite(func() {
 	}
 	constants.StartTime = time.Now()
 
-	godotenv.Load("../../.env")
+	defaults := config.Defaults()
+	defaults.MaxConcurrentNamespaceReconciles = 2
+	config.SetDefaults(defaults)
 	go func() {
 		err = k8sManager.Start(ctrl.SetupSignalHandler())
 		Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,192 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/reconciler"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	defaultJobTimeout  = 600 * time.Second
+)
+
+// Environment variables passed to the containers of Job hooks
+const (
+	EnvScalingClass = "PRESCALE_SCALING_CLASS"
+	EnvFromState    = "PRESCALE_FROM_STATE"
+	EnvState        = "PRESCALE_STATE"
+)
+
+// Transition identifies the transition a hook runs for
+type Transition struct {
+	ScalingClass string `json:"scalingClass"`
+	FromState    string `json:"fromState"`
+	State        string `json:"state"`
+	Hook         string `json:"hook"`
+}
+
+// For returns the pre and post hooks of a transition to the state: the hooks of the definition first, then those of the state
+func For(definition v1alpha1.ClusterScalingStateDefinition, state string) ([]v1alpha1.TransitionHook, []v1alpha1.TransitionHook) {
+	pre, post := []v1alpha1.TransitionHook{}, []v1alpha1.TransitionHook{}
+	add := func(hooks *v1alpha1.TransitionHooks) {
+		if hooks != nil {
+			pre = append(pre, hooks.Pre...)
+			post = append(post, hooks.Post...)
+		}
+	}
+	add(definition.Hooks)
+	for _, s := range definition.Spec {
+		if s.Name == state {
+			add(s.Hooks)
+		}
+	}
+	return pre, post
+}
+
+// Timeout of the hook, with the defaults of its type
+func Timeout(hook v1alpha1.TransitionHook) time.Duration {
+	if hook.TimeoutSeconds > 0 {
+		return time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	if hook.Job != nil {
+		return defaultJobTimeout
+	}
+	return defaultHTTPTimeout
+}
+
+// Call runs an HTTP hook. Any 2xx answer is a success.
+func Call(ctx context.Context, httpClient *http.Client, hook v1alpha1.TransitionHook, transition Transition) error {
+	if hook.HTTP == nil {
+		return fmt.Errorf("hook %s is no HTTP hook", hook.Name)
+	}
+	body := []byte(hook.HTTP.Body)
+	if hook.HTTP.Body == "" {
+		var err error
+		body, err = json.Marshal(transition)
+		if err != nil {
+			return err
+		}
+	}
+	method := hook.HTTP.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout(hook))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, hook.HTTP.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range hook.HTTP.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Only the start of the response goes into the status of the ClusterScalingState
+	response, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered with %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(response)))
+	}
+	return nil
+}
+
+// CreateJob creates the Job of a Job hook and returns its name
+func CreateJob(ctx context.Context, _client client.Client, hook v1alpha1.TransitionHook, transition Transition) (string, error) {
+	if hook.Job == nil {
+		return "", fmt.Errorf("hook %s is no Job hook", hook.Name)
+	}
+	template := hook.Job.Template.DeepCopy()
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: jobPrefix(transition),
+			Namespace:    JobNamespace(hook),
+			Labels:       template.Labels,
+			Annotations:  template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[constants.HookLabel] = hook.Name
+	job.Labels[constants.HookScalingClassLabel] = transition.ScalingClass
+	// Kubernetes stops the Job on timeout too, so it doesn't keep running after the hook failed
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		deadline := int64(Timeout(hook).Seconds())
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	env := []corev1.EnvVar{
+		{Name: EnvScalingClass, Value: transition.ScalingClass},
+		{Name: EnvFromState, Value: transition.FromState},
+		{Name: EnvState, Value: transition.State},
+	}
+	for i := range job.Spec.Template.Spec.InitContainers {
+		job.Spec.Template.Spec.InitContainers[i].Env = append(job.Spec.Template.Spec.InitContainers[i].Env, env...)
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, env...)
+	}
+
+	err := _client.Create(ctx, job)
+	return job.Name, err
+}
+
+// JobNamespace is the namespace the Job of the hook runs in
+func JobNamespace(hook v1alpha1.TransitionHook) string {
+	if hook.Job != nil && hook.Job.Namespace != "" {
+		return hook.Job.Namespace
+	}
+	return reconciler.OperatorNamespace()
+}
+
+// JobResult tells if the Job finished. A failed Job returns an error with the reason.
+func JobResult(job batchv1.Job) (bool, error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return true, fmt.Errorf("Job %s failed: %s %s", job.Name, condition.Reason, condition.Message)
+		}
+	}
+	return false, nil
+}
+
+// jobPrefix is the GenerateName of the Jobs of a transition, like default-peak-warm-caches-
+func jobPrefix(transition Transition) string {
+	prefix := strings.ToLower(fmt.Sprintf("%s-%s-%s", transition.ScalingClass, transition.State, transition.Hook))
+	// The name of the Job is a label of its pods. Leave room for the random suffix.
+	if max := validation.DNS1123LabelMaxLength - 6; len(prefix) > max {
+		prefix = prefix[:max]
+	}
+	return strings.TrimRight(prefix, "-.") + "-"
+}
@@ -0,0 +1,315 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func jobHook(name string) v1alpha1.TransitionHook {
+	return v1alpha1.TransitionHook{
+		Name: name,
+		Job: &v1alpha1.JobHook{
+			Namespace: "operator",
+			Template: batchv1beta1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "drain", Image: "busybox"}},
+			}}}},
+		},
+	}
+}
+
+func httpHook(name string, url string) v1alpha1.TransitionHook {
+	return v1alpha1.TransitionHook{Name: name, HTTP: &v1alpha1.HTTPHook{URL: url}}
+}
+
+func newClient(definition v1alpha1.ClusterScalingStateDefinition) client.Client {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	return fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+			&definition,
+			&v1alpha1.ClusterScalingState{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec:       v1alpha1.ClusterScalingStateSpec{State: "peak"},
+				Status: v1alpha1.ClusterScalingStateStatus{
+					Transition: &v1alpha1.TransitionStatus{State: "bau", Phase: v1alpha1.TransitionCompleted},
+				},
+			},
+		).
+		Build()
+}
+
+// advance calls Advance with the ClusterScalingState as the controller gets it
+func advance(t *testing.T, r *Runner) (v1alpha1.ClusterScalingState, bool) {
+	css := v1alpha1.ClusterScalingState{}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Name: "default"}, &css); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	proceed, _, err := r.Advance(context.TODO(), &css)
+	if err != nil {
+		t.Fatalf("Advance() error = %v", err)
+	}
+	_ = r.client.Get(context.TODO(), client.ObjectKey{Name: "default"}, &css)
+	return css, proceed
+}
+
+func TestFor(t *testing.T) {
+	definition := v1alpha1.ClusterScalingStateDefinition{
+		Hooks: &v1alpha1.TransitionHooks{Pre: []v1alpha1.TransitionHook{httpHook("audit", "http://audit")}},
+		Spec: []v1alpha1.States{
+			{Name: "peak", Hooks: &v1alpha1.TransitionHooks{Pre: []v1alpha1.TransitionHook{jobHook("warm-caches")}}},
+			{Name: "bau", Hooks: &v1alpha1.TransitionHooks{Post: []v1alpha1.TransitionHook{jobHook("drain-queues")}}},
+		},
+	}
+	pre, post := For(definition, "peak")
+	if len(pre) != 2 || pre[0].Name != "audit" || pre[1].Name != "warm-caches" || len(post) != 0 {
+		t.Errorf("For() = %v, %v, want the hooks of the definition and then of the state", pre, post)
+	}
+}
+
+func TestCreateJob(t *testing.T) {
+	_client := newClient(v1alpha1.ClusterScalingStateDefinition{ObjectMeta: metav1.ObjectMeta{Name: "states"}})
+	name, err := CreateJob(context.TODO(), _client, jobHook("warm-caches"), Transition{ScalingClass: "default", FromState: "bau", State: "peak", Hook: "warm-caches"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job := batchv1.Job{}
+	if err := _client.Get(context.TODO(), client.ObjectKey{Namespace: "operator", Name: name}, &job); err != nil {
+		t.Fatalf("CreateJob() created no Job %s: %v", name, err)
+	}
+	if !strings.HasPrefix(name, "default-peak-warm-caches-") {
+		t.Errorf("CreateJob() name = %s, want the prefix default-peak-warm-caches-", name)
+	}
+	if job.Spec.ActiveDeadlineSeconds == nil || *job.Spec.ActiveDeadlineSeconds != 600 || job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("CreateJob() didn't default the deadline and the restart policy: %+v", job.Spec)
+	}
+	env := map[string]string{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env[EnvFromState] != "bau" || env[EnvState] != "peak" || env[EnvScalingClass] != "default" {
+		t.Errorf("CreateJob() env = %v, want the transition", env)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	var calls []Transition
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		transition := Transition{}
+		_ = json.Unmarshal(body, &transition)
+		calls = append(calls, transition)
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			http.Error(w, "connection limit not raised", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("TestCompleted", func(t *testing.T) {
+		calls = nil
+		r := NewRunner(newClient(v1alpha1.ClusterScalingStateDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "states"},
+			Spec: []v1alpha1.States{
+				{Name: "peak", Hooks: &v1alpha1.TransitionHooks{
+					Pre:  []v1alpha1.TransitionHook{httpHook("raise-connections", server.URL)},
+					Post: []v1alpha1.TransitionHook{jobHook("warm-caches")},
+				}},
+				{Name: "bau"},
+			},
+		}), record.NewFakeRecorder(10))
+		startRunner(t, r)
+
+		css, proceed := advance(t, r)
+		if proceed || css.Status.Transition.Phase != v1alpha1.TransitionPreHooks || css.Status.Transition.FromState != "bau" {
+			t.Fatalf("Advance() on a new state = %v, %+v, want the pre hooks to start", proceed, css.Status.Transition)
+		}
+		if state := states.EffectiveState(css); state != "bau" {
+			t.Errorf("EffectiveState() during the pre hooks = %s, want bau", state)
+		}
+
+		// The HTTP hook is called in the background
+		css, proceed = advance(t, r)
+		if proceed || css.Status.Transition.Hooks[0].Phase != v1alpha1.HookRunning {
+			t.Fatalf("Advance() during the pre hooks = %v, %+v, want the HTTP hook running", proceed, css.Status.Transition)
+		}
+		waitForCalls(r)
+
+		css, proceed = advance(t, r)
+		if proceed || css.Status.Transition.Phase != v1alpha1.TransitionScaling || css.Status.Transition.Hooks[0].Phase != v1alpha1.HookSucceeded {
+			t.Fatalf("Advance() after the pre hooks = %v, %+v, want the scaling to start", proceed, css.Status.Transition)
+		}
+		if len(calls) != 1 || calls[0].FromState != "bau" || calls[0].State != "peak" {
+			t.Errorf("The HTTP hook got %+v, want one call for the transition", calls)
+		}
+		if state := states.EffectiveState(css); state != "peak" {
+			t.Errorf("EffectiveState() after the pre hooks = %s, want peak", state)
+		}
+
+		// Without items the scaling is done right away and the Job of the post hook starts
+		css, proceed = advance(t, r)
+		post := css.Status.Transition.Hooks[1]
+		if !proceed || css.Status.Transition.Phase != v1alpha1.TransitionPostHooks || post.Phase != v1alpha1.HookRunning || post.JobName == "" {
+			t.Fatalf("Advance() after scaling = %v, %+v, want the post hook running", proceed, css.Status.Transition)
+		}
+
+		job := batchv1.Job{}
+		_ = r.client.Get(context.TODO(), client.ObjectKey{Namespace: "operator", Name: post.JobName}, &job)
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		if err := r.client.Status().Update(context.TODO(), &job); err != nil {
+			t.Fatalf("Failed to complete the Job: %v", err)
+		}
+		css, proceed = advance(t, r)
+		if !proceed || css.Status.Transition.Phase != v1alpha1.TransitionCompleted || css.Status.Transition.CompletionTime == nil {
+			t.Errorf("Advance() after the Job completed = %v, %+v, want the transition completed", proceed, css.Status.Transition)
+		}
+	})
+
+	t.Run("TestAborted", func(t *testing.T) {
+		continued := httpHook("audit", server.URL+"/broken")
+		continued.FailurePolicy = v1alpha1.HookFailureContinue
+		r := NewRunner(newClient(v1alpha1.ClusterScalingStateDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "states"},
+			Hooks:      &v1alpha1.TransitionHooks{Pre: []v1alpha1.TransitionHook{continued}},
+			Spec: []v1alpha1.States{
+				{Name: "peak", Hooks: &v1alpha1.TransitionHooks{Pre: []v1alpha1.TransitionHook{
+					httpHook("raise-connections", server.URL+"/broken"),
+					httpHook("never-called", server.URL),
+				}}},
+				{Name: "bau"},
+			},
+		}), record.NewFakeRecorder(10))
+		startRunner(t, r)
+
+		advance(t, r)
+		// The first two hooks are called one after the other in the background
+		for i := 0; i < 2; i++ {
+			advance(t, r)
+			waitForCalls(r)
+		}
+		css, proceed := advance(t, r)
+		transition := css.Status.Transition
+		if !proceed || transition.Phase != v1alpha1.TransitionAborted {
+			t.Fatalf("Advance() with a failing hook = %v, %+v, want the transition aborted", proceed, transition)
+		}
+		if transition.Hooks[0].Phase != v1alpha1.HookFailed || transition.Hooks[1].Phase != v1alpha1.HookFailed || transition.Hooks[2].Phase != v1alpha1.HookPending {
+			t.Errorf("Advance() hooks = %+v, want the first two failed and the last not run", transition.Hooks)
+		}
+		if !strings.Contains(transition.Hooks[1].Message, "connection limit not raised") {
+			t.Errorf("Advance() message = %s, want the response", transition.Hooks[1].Message)
+		}
+		if state := states.EffectiveState(css); state != "bau" {
+			t.Errorf("EffectiveState() after the abort = %s, want bau", state)
+		}
+	})
+}
+
+func TestRunnerCancelsCalls(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+
+	newRunner := func() *Runner {
+		return NewRunner(newClient(v1alpha1.ClusterScalingStateDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "states"},
+			Spec: []v1alpha1.States{
+				{Name: "peak", Hooks: &v1alpha1.TransitionHooks{Pre: []v1alpha1.TransitionHook{httpHook("raise-connections", server.URL)}}},
+				{Name: "bau"},
+			},
+		}), record.NewFakeRecorder(10))
+	}
+	// runningCall starts the transition and returns the HTTP call of its hook
+	runningCall := func(r *Runner) *httpCall {
+		advance(t, r)
+		advance(t, r)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, call := range r.calls {
+			return call
+		}
+		t.Fatalf("The HTTP hook wasn't called")
+		return nil
+	}
+
+	t.Run("TestSuperseded", func(t *testing.T) {
+		r := newRunner()
+		startRunner(t, r)
+		call := runningCall(r)
+
+		css := v1alpha1.ClusterScalingState{}
+		_ = r.client.Get(context.TODO(), client.ObjectKey{Name: "default"}, &css)
+		css.Spec.State = "bau"
+		if err := r.client.Update(context.TODO(), &css); err != nil {
+			t.Fatalf("Failed to change the state: %v", err)
+		}
+		advance(t, r)
+
+		<-call.done
+		if call.err == nil || len(r.calls) != 0 {
+			t.Errorf("The call of the superseded transition wasn't cancelled and forgotten. Error %v, %d calls", call.err, len(r.calls))
+		}
+	})
+
+	t.Run("TestStopped", func(t *testing.T) {
+		r := newRunner()
+		cancel := startRunner(t, r)
+		call := runningCall(r)
+
+		cancel()
+		<-call.done
+		if call.err == nil {
+			t.Errorf("The call wasn't cancelled when the Runner stopped")
+		}
+	})
+}
+
+// startRunner starts the Runner like the manager does and stops it at the end of the test
+func startRunner(t *testing.T, r *Runner) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = r.Start(ctx)
+	}()
+	for started := false; !started; {
+		r.mu.Lock()
+		started = r.ctx != nil
+		r.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	return cancel
+}
+
+// waitForCalls waits until the HTTP hooks called in the background returned
+func waitForCalls(r *Runner) {
+	r.mu.Lock()
+	calls := []*httpCall{}
+	for _, call := range r.calls {
+		calls = append(calls, call)
+	}
+	r.mu.Unlock()
+	for _, call := range calls {
+		<-call.done
+	}
+}
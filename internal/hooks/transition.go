@@ -0,0 +1,428 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/states"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// hookPollPeriod is how often a running Job or HTTP hook is checked
+	hookPollPeriod = 5 * time.Second
+	// progressPeriod is how often the items are checked while they are scaled
+	progressPeriod = 10 * time.Second
+)
+
+// Runner moves the transitions of ClusterScalingStates through their hooks. It is created once and added to the manager,
+// so the HTTP hooks it calls in the background are cancelled on shutdown or when the operator loses the leadership.
+type Runner struct {
+	client     client.Client
+	recorder   record.EventRecorder
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// ctx is the context the Runner was started with. HTTP hooks are only called while it runs.
+	ctx context.Context
+	// calls are the HTTP hooks that are running or whose result wasn't picked up yet, by the key of callKey
+	calls map[string]*httpCall
+}
+
+// NewRunner creates a Runner. HTTP hooks are only called once the manager started it.
+func NewRunner(_client client.Client, recorder record.EventRecorder) *Runner {
+	return &Runner{client: _client, recorder: recorder, httpClient: &http.Client{}, calls: map[string]*httpCall{}}
+}
+
+// Start keeps the context for the HTTP hooks until it's cancelled. It implements manager.Runnable.
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	// The calls are cancelled with the context. The next leader makes the calls of the running hooks again.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = nil
+	r.calls = map[string]*httpCall{}
+	return nil
+}
+
+// NeedLeaderElection makes sure only the leader calls hooks, as only the leader advances the transitions.
+// It implements manager.LeaderElectionRunnable.
+func (r *Runner) NeedLeaderElection() bool {
+	return true
+}
+
+// httpCall is an HTTP hook called in the background, so a slow endpoint doesn't block the controller
+type httpCall struct {
+	// transition is the key of the transition the call belongs to, see transitionKey
+	transition string
+	cancel     context.CancelFunc
+	done       chan struct{}
+	err        error
+}
+
+// transitionKey identifies a transition of a scaling class. The start time tells repeated transitions apart.
+func transitionKey(class string, transition *v1alpha1.TransitionStatus) string {
+	return fmt.Sprintf("%s/%s/%s/%d", class, transition.FromState, transition.State, transition.StartTime.Unix())
+}
+
+// callKey identifies the call of a hook within its transition
+func callKey(transition string, status *v1alpha1.HookStatus) string {
+	return fmt.Sprintf("%s/%s/%d", transition, status.Name, status.StartTime.Unix())
+}
+
+// startCall runs the call in the background, unless it's already running or the Runner isn't started
+func (r *Runner) startCall(key string, transition string, call func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.calls[key]; found || r.ctx == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	running := &httpCall{transition: transition, cancel: cancel, done: make(chan struct{})}
+	r.calls[key] = running
+	go func() {
+		defer cancel()
+		running.err = call(ctx)
+		close(running.done)
+	}()
+}
+
+// callResult returns if the call finished and its error. A finished call is forgotten.
+// Calls cancelled because the Runner stopped don't count as finished.
+func (r *Runner) callResult(key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	running, found := r.calls[key]
+	if !found || r.ctx == nil || r.ctx.Err() != nil {
+		return false, nil
+	}
+	select {
+	case <-running.done:
+		delete(r.calls, key)
+		return true, running.err
+	default:
+		return false, nil
+	}
+}
+
+// forgetCalls cancels the calls of a transition that was superseded or finished and forgets them
+func (r *Runner) forgetCalls(transition string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, running := range r.calls {
+		if running.transition == transition {
+			running.cancel()
+			delete(r.calls, key)
+		}
+	}
+}
+
+// Advance starts a transition if the state of the ClusterScalingState changed and moves it on as far as possible.
+// The status of the transition is written to the ClusterScalingState. It returns if the items of the class should be
+// scaled now and when to call it again for a transition in progress.
+func (r *Runner) Advance(ctx context.Context, css *v1alpha1.ClusterScalingState) (bool, time.Duration, error) {
+	// Dry runs don't scale, so they don't run hooks either
+	if css.Config.DryRun {
+		return true, 0, nil
+	}
+
+	now := metav1.Now()
+	transition := css.Status.Transition.DeepCopy()
+	if transition == nil {
+		// The first state of the class is taken as is
+		transition = &v1alpha1.TransitionStatus{State: css.Spec.State, Phase: v1alpha1.TransitionCompleted, StartTime: now, CompletionTime: &now}
+		return true, 0, r.setTransition(ctx, css.Name, transition)
+	}
+
+	definitions, err := states.GetClusterScalingStateDefinitionsList(ctx, r.client)
+	if err != nil {
+		return true, 0, err
+	}
+	pre, post := For(definitions.Items[0], css.Spec.State)
+
+	if transition.State != css.Spec.State {
+		// The hooks of the superseded transition are no longer waited for
+		r.forgetCalls(transitionKey(states.GetAppliedScalingClassFromClusterScalingState(*css).Name, transition))
+		transition = newTransition(states.TransitionState(*transition), css.Spec.State, pre, post)
+		if transition.Phase == v1alpha1.TransitionPreHooks {
+			r.recorder.Event(css, "Normal", "TransitionHooks", fmt.Sprintf("Running the pre hooks of the transition from %s to %s", transition.FromState, transition.State))
+		}
+		// Only scale once the status of the transition was written, so the new state is applied after the pre hooks
+		return false, time.Second, r.setTransition(ctx, css.Name, transition)
+	}
+
+	old := transition.DeepCopy()
+	proceed, requeue := r.advance(ctx, css, transition, pre, post)
+	if !reflect.DeepEqual(old, transition) {
+		err = r.setTransition(ctx, css.Name, transition)
+		if old.Phase == v1alpha1.TransitionPreHooks && transition.Phase == v1alpha1.TransitionScaling {
+			// The new state only applies once the status is written. The update triggers the next reconcile.
+			return false, time.Second, err
+		}
+	}
+	return proceed, requeue, err
+}
+
+func newTransition(from string, to string, pre []v1alpha1.TransitionHook, post []v1alpha1.TransitionHook) *v1alpha1.TransitionStatus {
+	now := metav1.Now()
+	transition := &v1alpha1.TransitionStatus{FromState: from, State: to, Phase: v1alpha1.TransitionPreHooks, StartTime: now}
+	for _, hook := range pre {
+		transition.Hooks = append(transition.Hooks, v1alpha1.HookStatus{Name: hook.Name, Stage: v1alpha1.HookStagePre, Phase: v1alpha1.HookPending})
+	}
+	for _, hook := range post {
+		transition.Hooks = append(transition.Hooks, v1alpha1.HookStatus{Name: hook.Name, Stage: v1alpha1.HookStagePost, Phase: v1alpha1.HookPending})
+	}
+	// Nothing to wait for without hooks. Going back to the state the items are in doesn't run them either.
+	if len(transition.Hooks) == 0 || from == to {
+		transition.Phase = v1alpha1.TransitionCompleted
+		transition.CompletionTime = &now
+	}
+	return transition
+}
+
+func (r *Runner) advance(ctx context.Context, css *v1alpha1.ClusterScalingState, transition *v1alpha1.TransitionStatus, pre []v1alpha1.TransitionHook, post []v1alpha1.TransitionHook) (bool, time.Duration) {
+	log := ctrl.Log.WithName("hooks").WithValues("ClusterScalingState", css.Name)
+	class := states.GetAppliedScalingClassFromClusterScalingState(*css).Name
+	for {
+		switch transition.Phase {
+		case v1alpha1.TransitionPreHooks:
+			done, failed := r.runHooks(ctx, css, transition, v1alpha1.HookStagePre, pre)
+			if !done {
+				return false, hookPollPeriod
+			}
+			if failed {
+				r.finish(css, transition, v1alpha1.TransitionAborted)
+				return true, 0
+			}
+			transition.Phase = v1alpha1.TransitionScaling
+			return true, progressPeriod
+
+		case v1alpha1.TransitionScaling:
+			pending, err := r.pendingItems(ctx, class)
+			if err != nil {
+				log.Error(err, "Failed to check the progress of the items")
+				return true, progressPeriod
+			}
+			if pending > 0 {
+				return true, progressPeriod
+			}
+			transition.Phase = v1alpha1.TransitionPostHooks
+
+		case v1alpha1.TransitionPostHooks:
+			done, failed := r.runHooks(ctx, css, transition, v1alpha1.HookStagePost, post)
+			if !done {
+				return true, hookPollPeriod
+			}
+			if failed {
+				r.finish(css, transition, v1alpha1.TransitionFailed)
+			} else {
+				r.finish(css, transition, v1alpha1.TransitionCompleted)
+			}
+			return true, 0
+
+		default:
+			return true, 0
+		}
+	}
+}
+
+func (r *Runner) finish(css *v1alpha1.ClusterScalingState, transition *v1alpha1.TransitionStatus, phase string) {
+	// Hooks with the Continue policy might still be called, for example after an abort
+	r.forgetCalls(transitionKey(states.GetAppliedScalingClassFromClusterScalingState(*css).Name, transition))
+	now := metav1.Now()
+	transition.Phase = phase
+	transition.CompletionTime = &now
+	switch phase {
+	case v1alpha1.TransitionAborted:
+		r.recorder.Event(css, "Warning", "TransitionAborted", fmt.Sprintf("A pre hook failed. Staying in state %s instead of %s", transition.FromState, transition.State))
+	case v1alpha1.TransitionFailed:
+		r.recorder.Event(css, "Warning", "TransitionFailed", fmt.Sprintf("Scaled to state %s, but a post hook failed", transition.State))
+	default:
+		r.recorder.Event(css, "Normal", "TransitionCompleted", fmt.Sprintf("Scaled to state %s and ran all hooks", transition.State))
+	}
+}
+
+// runHooks runs the hooks of the stage one after the other. It returns if they are all done and if one failed the transition.
+func (r *Runner) runHooks(ctx context.Context, css *v1alpha1.ClusterScalingState, transition *v1alpha1.TransitionStatus, stage string, hooks []v1alpha1.TransitionHook) (bool, bool) {
+	specs := map[string]v1alpha1.TransitionHook{}
+	for _, hook := range hooks {
+		if _, found := specs[hook.Name]; !found {
+			specs[hook.Name] = hook
+		}
+	}
+	class := states.GetAppliedScalingClassFromClusterScalingState(*css).Name
+
+	for i := range transition.Hooks {
+		status := &transition.Hooks[i]
+		// A failed hook we come across again didn't abort the transition, so it has the Continue policy
+		if status.Stage != stage || status.Phase == v1alpha1.HookSucceeded || status.Phase == v1alpha1.HookSkipped || status.Phase == v1alpha1.HookFailed {
+			continue
+		}
+		hook, found := specs[status.Name]
+		if !found {
+			status.Phase = v1alpha1.HookSkipped
+			status.Message = "The hook was removed from the ClusterScalingStateDefinition"
+			continue
+		}
+
+		hookTransition := Transition{ScalingClass: class, FromState: transition.FromState, State: transition.State, Hook: hook.Name}
+		if status.Phase == v1alpha1.HookPending {
+			r.startHook(ctx, hook, status, hookTransition)
+		}
+		if status.Phase == v1alpha1.HookRunning {
+			r.checkHook(ctx, hook, status, hookTransition, transitionKey(class, transition))
+		}
+
+		switch status.Phase {
+		case v1alpha1.HookRunning:
+			return false, false
+		case v1alpha1.HookFailed:
+			r.recorder.Event(css, "Warning", "HookFailed", fmt.Sprintf("%s hook %s failed: %s", stage, hook.Name, status.Message))
+			if hook.FailurePolicy != v1alpha1.HookFailureContinue {
+				return true, true
+			}
+		}
+	}
+	return true, false
+}
+
+func (r *Runner) startHook(ctx context.Context, hook v1alpha1.TransitionHook, status *v1alpha1.HookStatus, transition Transition) {
+	now := metav1.Now()
+	status.StartTime = &now
+	switch {
+	case hook.HTTP != nil:
+		// The call is made in the background by checkCall
+		status.Phase = v1alpha1.HookRunning
+	case hook.Job != nil:
+		name, err := CreateJob(ctx, r.client, hook, transition)
+		if err != nil {
+			finishHook(status, fmt.Errorf("failed to create the Job: %w", err))
+			return
+		}
+		status.Phase = v1alpha1.HookRunning
+		status.JobName = name
+	default:
+		finishHook(status, fmt.Errorf("hook %s has neither a job nor an http call", hook.Name))
+	}
+}
+
+func (r *Runner) checkHook(ctx context.Context, hook v1alpha1.TransitionHook, status *v1alpha1.HookStatus, transition Transition, transitionKey string) {
+	if hook.HTTP != nil {
+		r.checkCall(hook, status, transition, transitionKey)
+		return
+	}
+	r.checkJob(ctx, hook, status)
+}
+
+// checkCall finishes the hook once its HTTP call returned. A call that got lost because the operator restarted
+// is made again.
+func (r *Runner) checkCall(hook v1alpha1.TransitionHook, status *v1alpha1.HookStatus, transition Transition, transitionKey string) {
+	key := callKey(transitionKey, status)
+	httpClient := r.httpClient
+	// The call isn't bound to the reconcile. It ends after the timeout of the hook, when the Runner stops or
+	// when the transition is superseded.
+	r.startCall(key, transitionKey, func(ctx context.Context) error {
+		return Call(ctx, httpClient, hook, transition)
+	})
+	if finished, err := r.callResult(key); finished {
+		finishHook(status, err)
+	}
+}
+
+func (r *Runner) checkJob(ctx context.Context, hook v1alpha1.TransitionHook, status *v1alpha1.HookStatus) {
+	job := batchv1.Job{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: JobNamespace(hook), Name: status.JobName}, &job)
+	if errors.IsNotFound(err) {
+		finishHook(status, fmt.Errorf("Job %s was deleted", status.JobName))
+		return
+	}
+	if err != nil {
+		// Checked again on the next poll
+		ctrl.Log.WithName("hooks").Error(err, "Failed to get the Job of the hook", "hook", hook.Name)
+		return
+	}
+	if finished, err := JobResult(job); finished {
+		finishHook(status, err)
+		return
+	}
+	if time.Since(status.StartTime.Time) > Timeout(hook) {
+		finishHook(status, fmt.Errorf("Job %s timed out after %s", status.JobName, Timeout(hook)))
+	}
+}
+
+func finishHook(status *v1alpha1.HookStatus, err error) {
+	now := metav1.Now()
+	status.CompletionTime = &now
+	if err != nil {
+		status.Phase = v1alpha1.HookFailed
+		status.Message = err.Error()
+		return
+	}
+	status.Phase = v1alpha1.HookSucceeded
+}
+
+// pendingItems counts the items of the scaling class which didn't reach their desired replicas yet. Failed items don't count.
+func (r *Runner) pendingItems(ctx context.Context, class string) (int, error) {
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, r.client)
+	if err != nil {
+		return 0, err
+	}
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err = r.client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return 0, err
+	}
+	items, err := resources.ScalingItemNamespaceLister(ctx, r.client, "", config.OptInSelector())
+	if err != nil {
+		return 0, err
+	}
+	items, _, err = resources.WithTargets(ctx, r.client, items, stateDefinitions, clusterScalingStates)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, item := range items {
+		if states.GetAppliedScalingClassFromScalingItem(item).Name != class {
+			continue
+		}
+		if progress := resources.Progress(item); progress == resources.ProgressPending || progress == resources.ProgressScaling {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+func (r *Runner) setTransition(ctx context.Context, name string, transition *v1alpha1.TransitionStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		css := v1alpha1.ClusterScalingState{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: name}, &css)
+		if err != nil {
+			// The ClusterScalingState might've been deleted
+			return client.IgnoreNotFound(err)
+		}
+		if reflect.DeepEqual(css.Status.Transition, transition) {
+			return nil
+		}
+		css.Status.Transition = transition
+		return r.client.Status().Update(ctx, &css)
+	})
+}
@@ -0,0 +1,298 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "prescale"
+
+var (
+	// ClassState is 1 for the state a scaling class is currently set to
+	ClassState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "class_state",
+		Help:      "The state set on the ClusterScalingState of a scaling class.",
+	}, []string{"class", "state"})
+
+	// NamespaceState is 1 for the state a ScalingState sets on a namespace
+	NamespaceState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "namespace_state",
+		Help:      "The state set on the ScalingState of a namespace.",
+	}, []string{"namespace", "state"})
+
+	// ItemReplicas holds the desired, spec and ready replicas of every known scaling item
+	ItemReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "item_replicas",
+		Help:      "Replicas of a scaling item by type: desired (from the applied state), spec and ready.",
+	}, []string{"namespace", "name", "kind", "type"})
+
+	// TransitionDuration observes how long it took until all items of a namespace reached a new state
+	TransitionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transition_duration_seconds",
+		Help:      "Time from the start of a state transition in a namespace until all its items finished scaling.",
+		Buckets:   []float64{10, 30, 60, 120, 300, 600, 900, 1800, 3600},
+	}, []string{"namespace", "state"})
+
+	// TransitionStartTime is set while a transition is in progress. Useful to alert on transitions that don't converge in time.
+	TransitionStartTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "transition_start_time_seconds",
+		Help:      "Unix timestamp of the start of a state transition that is still in progress.",
+	}, []string{"namespace", "state"})
+
+	// ScalingSteps counts the replica updates done by the step and rapid scaler
+	ScalingSteps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scaling_steps_total",
+		Help:      "Number of replica updates done on scaling items.",
+	}, []string{"namespace", "kind", "mode"})
+
+	// QuotaRejections counts scaling decisions that were denied because of the ResourceQuotas
+	QuotaRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_rejections_total",
+		Help:      "Number of scaling decisions rejected by the ResourceQuota check.",
+	}, []string{"namespace"})
+
+	// DryRunReplicaDelta holds the replica change a dry run computed for a scaling item
+	DryRunReplicaDelta = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dry_run_replica_delta",
+		Help:      "Replica change (desired - spec) the last dry run computed for a scaling item.",
+	}, []string{"namespace", "name", "kind", "state"})
+
+	// ReplicaDrifts counts replica changes of others the operator reported instead of reverting
+	ReplicaDrifts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "replica_drifts_total",
+		Help:      "Number of replica changes by others on scaling items with the warn enforcement mode.",
+	}, []string{"namespace", "kind", "actor"})
+)
+
+var (
+	itemsInFailureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "items_failure_state"),
+		"Number of scaling items in failure state.", nil, nil)
+	itemsGaveUpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "items_gave_up"),
+		"Number of scaling items the operator gave up scaling.", nil, nil)
+	itemsOnDenyListDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "items_deny_list"),
+		"Number of scaling items on the deny list.", nil, nil)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		ClassState,
+		NamespaceState,
+		ItemReplicas,
+		TransitionDuration,
+		TransitionStartTime,
+		ScalingSteps,
+		QuotaRejections,
+		DryRunReplicaDelta,
+		ReplicaDrifts,
+		denyListCollector{},
+	)
+}
+
+// denyListCollector reads the deny list on every scrape, so the numbers can't drift from the list
+type denyListCollector struct{}
+
+func (c denyListCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- itemsInFailureDesc
+	ch <- itemsGaveUpDesc
+	ch <- itemsOnDenyListDesc
+}
+
+func (c denyListCollector) Collect(ch chan<- prometheus.Metric) {
+	var onList, inFailure, gaveUp float64
+	for inList := range g.GetDenyList().Iter() {
+		onList++
+		if inList.Value.Failure {
+			inFailure++
+		}
+		if inList.Value.GaveUp {
+			gaveUp++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(itemsOnDenyListDesc, prometheus.GaugeValue, onList)
+	ch <- prometheus.MustNewConstMetric(itemsInFailureDesc, prometheus.GaugeValue, inFailure)
+	ch <- prometheus.MustNewConstMetric(itemsGaveUpDesc, prometheus.GaugeValue, gaveUp)
+}
+
+// The vectors above can't delete by partial labels, so we remember the last value we set
+var (
+	mu             sync.Mutex
+	classStates    = make(map[string]string)
+	namespaceState = make(map[string]string)
+	dryRunItems    = make(map[string][]prometheus.Labels)
+	transitions    = make(map[string]*transition)
+)
+
+type transition struct {
+	state   string
+	start   time.Time
+	pending map[string]bool
+	items   []g.ScalingInfo
+}
+
+// Transition is a transition of a namespace to a state, for the notifications about its start and end
+type Transition struct {
+	Namespace string
+	State     string
+	// Items are all the items that took part in the transition so far
+	Items []g.ScalingInfo
+	// Duration is set once the transition finished
+	Duration time.Duration
+}
+
+func transitionKey(item g.ScalingInfo) string {
+	return item.ScalingItemType.ItemTypeName + "/" + item.Name
+}
+
+// SetClassState sets the active state of a scaling class
+func SetClassState(class string, state string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if old, found := classStates[class]; found && old != state {
+		ClassState.DeleteLabelValues(class, old)
+	}
+	classStates[class] = state
+	ClassState.WithLabelValues(class, state).Set(1)
+}
+
+// SetNamespaceState sets the state of a namespace. An empty state removes it.
+func SetNamespaceState(ns string, state string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if old, found := namespaceState[ns]; found && old != state {
+		NamespaceState.DeleteLabelValues(ns, old)
+		delete(namespaceState, ns)
+	}
+	if state == "" {
+		return
+	}
+	namespaceState[ns] = state
+	NamespaceState.WithLabelValues(ns, state).Set(1)
+}
+
+// SetItemReplicas sets the desired, spec and ready replicas of a scaling item
+func SetItemReplicas(item g.ScalingInfo) {
+	kind := item.ScalingItemType.ItemTypeName
+	if item.DesiredReplicas >= 0 {
+		ItemReplicas.WithLabelValues(item.Namespace, item.Name, kind, "desired").Set(float64(item.DesiredReplicas))
+	}
+	ItemReplicas.WithLabelValues(item.Namespace, item.Name, kind, "spec").Set(float64(item.SpecReplica))
+	ItemReplicas.WithLabelValues(item.Namespace, item.Name, kind, "ready").Set(float64(item.ReadyReplicas))
+}
+
+// ForgetItem removes all series of a scaling item, for example once it's deleted or opted out
+func ForgetItem(item g.ScalingInfo) {
+	for _, kind := range []string{"Deployment", "DeploymentConfig", "RedisCluster"} {
+		for _, replicaType := range []string{"desired", "spec", "ready"} {
+			ItemReplicas.DeleteLabelValues(item.Namespace, item.Name, kind, replicaType)
+		}
+	}
+	ItemFinished(item)
+}
+
+// IncScalingSteps counts one replica update on the item
+func IncScalingSteps(item g.ScalingInfo, mode string) {
+	ScalingSteps.WithLabelValues(item.Namespace, item.ScalingItemType.ItemTypeName, mode).Inc()
+}
+
+// IncReplicaDrifts counts one replica change of the actor on the item
+func IncReplicaDrifts(item g.ScalingInfo, actor string) {
+	ReplicaDrifts.WithLabelValues(item.Namespace, item.ScalingItemType.ItemTypeName, actor).Inc()
+}
+
+// IncQuotaRejections counts a scaling decision denied by the ResourceQuotas of the namespace
+func IncQuotaRejections(ns string) {
+	QuotaRejections.WithLabelValues(ns).Inc()
+}
+
+// SetDryRunDeltas replaces the dry run results of a namespace with the given items
+func SetDryRunDeltas(ns string, items []g.ScalingInfo) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, labels := range dryRunItems[ns] {
+		DryRunReplicaDelta.Delete(labels)
+	}
+	dryRunItems[ns] = nil
+	for _, item := range items {
+		labels := prometheus.Labels{
+			"namespace": ns,
+			"name":      item.Name,
+			"kind":      item.ScalingItemType.ItemTypeName,
+			"state":     item.State,
+		}
+		DryRunReplicaDelta.With(labels).Set(float64(item.DesiredReplicas - item.SpecReplica))
+		dryRunItems[ns] = append(dryRunItems[ns], labels)
+	}
+}
+
+// TransitionStarted starts measuring a transition of the namespace to the state of the given items.
+// The transition is complete once all the items called ItemFinished. A transition to the same state
+// which is still in progress is extended by the items instead. It returns true if a new transition started.
+func TransitionStarted(ns string, items []g.ScalingInfo) (Transition, bool) {
+	if len(items) == 0 {
+		return Transition{}, false
+	}
+	state := items[0].State
+	for _, item := range items {
+		if item.State != state {
+			// Items of different scaling classes can move to different states at the same time
+			state = "mixed"
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	t, found := transitions[ns]
+	started := !found || t.state != state
+	if started {
+		if found {
+			TransitionStartTime.DeleteLabelValues(ns, t.state)
+		}
+		t = &transition{
+			state:   state,
+			start:   time.Now(),
+			pending: make(map[string]bool),
+		}
+		transitions[ns] = t
+		TransitionStartTime.WithLabelValues(ns, state).Set(float64(t.start.Unix()))
+	}
+	for _, item := range items {
+		t.pending[transitionKey(item)] = true
+	}
+	t.items = append(t.items, items...)
+	return Transition{Namespace: ns, State: state, Items: t.items}, started
+}
+
+// ItemFinished marks the item as done for the transition of its namespace.
+// It returns true if the item was the last one, so the transition finished.
+func ItemFinished(item g.ScalingInfo) (Transition, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	t, found := transitions[item.Namespace]
+	if !found {
+		return Transition{}, false
+	}
+	delete(t.pending, transitionKey(item))
+	if len(t.pending) > 0 {
+		return Transition{}, false
+	}
+	duration := time.Since(t.start)
+	TransitionDuration.WithLabelValues(item.Namespace, t.state).Observe(duration.Seconds())
+	TransitionStartTime.DeleteLabelValues(item.Namespace, t.state)
+	delete(transitions, item.Namespace)
+	return Transition{Namespace: item.Namespace, State: t.state, Items: t.items, Duration: duration}, true
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSetClassStateReplacesOldState(t *testing.T) {
+	SetClassState("unittest", "bau")
+	SetClassState("unittest", "peak")
+
+	if got := testutil.ToFloat64(ClassState.WithLabelValues("unittest", "peak")); got != 1 {
+		t.Errorf("Expected the new state to be set. Got %v", got)
+	}
+	// WithLabelValues above would create the series again, so count what's left for the class
+	ClassState.DeleteLabelValues("unittest", "peak")
+	if got := testutil.CollectAndCount(ClassState); got != 0 {
+		t.Errorf("The old state of the class was not removed. Got %d series", got)
+	}
+}
+
+func TestTransitionFinishesWithLastItem(t *testing.T) {
+	first := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "transition",
+		State:           "peak",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+	}
+	second := first
+	second.Name = "bar"
+
+	if _, started := TransitionStarted("transition", []g.ScalingInfo{first, second}); !started {
+		t.Fatalf("Transition was not started")
+	}
+	if _, started := TransitionStarted("transition", []g.ScalingInfo{first}); started {
+		t.Errorf("Transition to the same state started again")
+	}
+
+	if _, finished := ItemFinished(first); finished {
+		t.Errorf("Transition finished before all items were scaled")
+	}
+
+	finished, done := ItemFinished(second)
+	if !done || finished.State != "peak" || len(finished.Items) == 0 {
+		t.Errorf("Transition did not finish after all items were scaled")
+	}
+	if _, found := transitions["transition"]; found {
+		t.Errorf("Transition is still measured after all items were scaled")
+	}
+	if got := testutil.CollectAndCount(TransitionStartTime); got != 0 {
+		t.Errorf("Start time of the finished transition was not removed. Got %d series", got)
+	}
+}
@@ -0,0 +1,104 @@
+package namespace_defaults
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	sr "github.com/containersol/prescale-operator/internal/state_replicas"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Annotations returns the annotations of the namespace. A namespace that doesn't exist has none.
+func Annotations(ctx context.Context, reader client.Reader, namespace string) (map[string]string, error) {
+	ns := corev1.Namespace{}
+	err := reader.Get(ctx, client.ObjectKey{Name: namespace}, &ns)
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return ns.Annotations, nil
+}
+
+// OptedIn tells if the namespace opts in all of its workloads
+func OptedIn(namespaceAnnotations map[string]string) bool {
+	optIn, _ := strconv.ParseBool(namespaceAnnotations[constants.NamespaceOptInAnnotation])
+	return optIn
+}
+
+// OptedOut tells if the workload opts out explicitly by setting the opt-in label to anything but true
+func OptedOut(workloadLabels map[string]string) bool {
+	value, found := workloadLabels[config.Get().OptInLabel]
+	if !found {
+		return false
+	}
+	optIn, err := strconv.ParseBool(value)
+	return err != nil || !optIn
+}
+
+// WorkloadOptedIn tells if the operator scales a workload with these labels in a namespace with these annotations
+func WorkloadOptedIn(workloadLabels map[string]string, namespaceAnnotations map[string]string) bool {
+	return config.OptedIn(workloadLabels) || (OptedIn(namespaceAnnotations) && !OptedOut(workloadLabels))
+}
+
+// Apply gives the item the defaults of its namespace. The state replicas and the rapid scaling setting of the
+// namespace are used where the item has none. A state multiplier of the namespace multiplies the default state
+// replicas of the item for the states the item has no replicas for itself.
+func Apply(item g.ScalingInfo, namespaceAnnotations map[string]string) g.ScalingInfo {
+	item.NamespaceOptIn = OptedIn(namespaceAnnotations) && !OptedOut(item.Labels)
+	if len(namespaceAnnotations) == 0 {
+		return item
+	}
+
+	// The annotations of the item belong to the object in the cache and must not be changed
+	annotations := make(map[string]string, len(item.Annotations))
+	for key, value := range item.Annotations {
+		annotations[key] = value
+	}
+	for key, value := range namespaceAnnotations {
+		if _, found := annotations[key]; !found && inherited(key) {
+			annotations[key] = value
+		}
+	}
+
+	base, err := strconv.Atoi(annotations[replicasKey(constants.DefaultReplicaAnnotation)])
+	if err == nil {
+		for key, value := range namespaceAnnotations {
+			if !strings.HasPrefix(key, sr.StateReplicaAnnotationPrefix) || !strings.HasSuffix(key, sr.StateMultiplierAnnotationSuffix) {
+				continue
+			}
+			state := key[len(sr.StateReplicaAnnotationPrefix) : len(key)-len(sr.StateMultiplierAnnotationSuffix)]
+			if state == constants.DefaultReplicaAnnotation {
+				continue
+			}
+			if _, found := item.Annotations[replicasKey(state)]; found {
+				continue
+			}
+			multiplier, err := strconv.ParseFloat(value, 64)
+			if err != nil || multiplier < 0 {
+				continue
+			}
+			annotations[replicasKey(state)] = strconv.Itoa(int(math.Ceil(multiplier * float64(base))))
+		}
+	}
+
+	item.Annotations = annotations
+	return item
+}
+
+// inherited tells if an annotation of the namespace is a default for the workloads
+func inherited(key string) bool {
+	switch key {
+	case constants.RapidScalingAnnotation, constants.EnforcementAnnotation, constants.WakeUpTimeoutAnnotation:
+		return true
+	}
+	return strings.HasPrefix(key, sr.StateReplicaAnnotationPrefix) && strings.HasSuffix(key, sr.StateReplicaAnnotationSuffix)
+}
+
+func replicasKey(state string) string {
+	return sr.StateReplicaAnnotationPrefix + state + sr.StateReplicaAnnotationSuffix
+}
@@ -0,0 +1,113 @@
+package namespace_defaults
+
+import (
+	"reflect"
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+)
+
+func TestWorkloadOptedIn(t *testing.T) {
+	optedInNamespace := map[string]string{"scaler/opt-in": "true"}
+	tests := []struct {
+		name                 string
+		workloadLabels       map[string]string
+		namespaceAnnotations map[string]string
+		want                 bool
+	}{
+		{name: "TestWorkloadLabel", workloadLabels: map[string]string{"scaler/opt-in": "true"}, namespaceAnnotations: nil, want: true},
+		{name: "TestNoOptIn", workloadLabels: map[string]string{}, namespaceAnnotations: map[string]string{}, want: false},
+		{name: "TestNamespaceOptIn", workloadLabels: map[string]string{}, namespaceAnnotations: optedInNamespace, want: true},
+		{name: "TestWorkloadOptsOut", workloadLabels: map[string]string{"scaler/opt-in": "false"}, namespaceAnnotations: optedInNamespace, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WorkloadOptedIn(tt.workloadLabels, tt.namespaceAnnotations); got != tt.want {
+				t.Errorf("WorkloadOptedIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	namespaceAnnotations := map[string]string{
+		"scaler/opt-in":                 "true",
+		"scaler/state-default-replicas": "1",
+		"scaler/state-bau-replicas":     "2",
+		"scaler/state-peak-replicas":    "4",
+		"scaler/state-peak-multiplier":  "2.5",
+		"scaler/rapid-scaling":          "true",
+		"scaler/enforcement":            "warn",
+		"unrelated":                     "value",
+	}
+	tests := []struct {
+		name        string
+		item        g.ScalingInfo
+		want        map[string]string
+		wantOptedIn bool
+	}{
+		{
+			name: "TestNamespaceDefaults",
+			item: g.ScalingInfo{Annotations: map[string]string{}},
+			want: map[string]string{
+				"scaler/state-default-replicas": "1",
+				"scaler/state-bau-replicas":     "2",
+				"scaler/state-peak-replicas":    "3",
+				"scaler/rapid-scaling":          "true",
+				"scaler/enforcement":            "warn",
+			},
+			wantOptedIn: true,
+		},
+		{
+			name: "TestWorkloadOverrides",
+			item: g.ScalingInfo{
+				Labels: map[string]string{"scaler/opt-in": "false"},
+				Annotations: map[string]string{
+					"scaler/state-default-replicas": "4",
+					"scaler/state-bau-replicas":     "5",
+					"scaler/rapid-scaling":          "false",
+					"scaler/enforcement":            "enforce",
+				},
+			},
+			want: map[string]string{
+				"scaler/state-default-replicas": "4",
+				"scaler/state-bau-replicas":     "5",
+				"scaler/state-peak-replicas":    "10",
+				"scaler/rapid-scaling":          "false",
+				"scaler/enforcement":            "enforce",
+			},
+			wantOptedIn: false,
+		},
+		{
+			name: "TestWorkloadReplicasWinOverMultiplier",
+			item: g.ScalingInfo{Annotations: map[string]string{"scaler/state-peak-replicas": "7"}},
+			want: map[string]string{
+				"scaler/state-default-replicas": "1",
+				"scaler/state-bau-replicas":     "2",
+				"scaler/state-peak-replicas":    "7",
+				"scaler/rapid-scaling":          "true",
+				"scaler/enforcement":            "warn",
+			},
+			wantOptedIn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := map[string]string{}
+			for key, value := range tt.item.Annotations {
+				original[key] = value
+			}
+
+			got := Apply(tt.item, namespaceAnnotations)
+			if !reflect.DeepEqual(got.Annotations, tt.want) {
+				t.Errorf("Apply() annotations = %v, want %v", got.Annotations, tt.want)
+			}
+			if got.NamespaceOptIn != tt.wantOptedIn {
+				t.Errorf("Apply() NamespaceOptIn = %v, want %v", got.NamespaceOptIn, tt.wantOptedIn)
+			}
+			if !reflect.DeepEqual(tt.item.Annotations, original) {
+				t.Errorf("Apply() changed the annotations of the item to %v", tt.item.Annotations)
+			}
+		})
+	}
+}
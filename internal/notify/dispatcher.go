@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingnotifications,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scaling.prescale.com,resources=scalingnotifications/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+const (
+	// queueSize is the number of events that can wait for the notifiers before new ones are dropped
+	queueSize = 100
+	// sendTimeout limits the time a single notification may take
+	sendTimeout = 10 * time.Second
+)
+
+// Dispatcher sends the published events to the notifiers of the matching ScalingNotifications.
+// The events are sent one by one, so a slow receiver delays the notifications but never the scaling.
+type Dispatcher struct {
+	client client.Client
+	// secrets reads the Secrets of the URLs. The cache of the manager doesn't watch Secrets.
+	secrets    client.Reader
+	namespace  string
+	httpClient *http.Client
+	events     chan Event
+	log        logr.Logger
+}
+
+// NewDispatcher creates a Dispatcher which reads the URLs from Secrets in the given namespace
+func NewDispatcher(_client client.Client, secrets client.Reader, namespace string, log logr.Logger) *Dispatcher {
+	return &Dispatcher{
+		client:     _client,
+		secrets:    secrets,
+		namespace:  namespace,
+		httpClient: &http.Client{Timeout: sendTimeout},
+		events:     make(chan Event, queueSize),
+		log:        log,
+	}
+}
+
+// Start receives the published events until the context is cancelled. It implements manager.Runnable.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	mu.Lock()
+	current = d
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		current = nil
+		mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-d.events:
+			d.dispatch(ctx, event)
+		}
+	}
+}
+
+// NeedLeaderElection makes sure only the leader sends notifications, as only the leader scales.
+// It implements manager.LeaderElectionRunnable.
+func (d *Dispatcher) NeedLeaderElection() bool {
+	return true
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, event Event) {
+	notifications := v1alpha1.ScalingNotificationList{}
+	if err := d.client.List(ctx, &notifications); err != nil {
+		d.log.Error(err, "Failed to list the ScalingNotifications")
+		return
+	}
+	for i := range notifications.Items {
+		notification := &notifications.Items[i]
+		if !Matches(notification.Spec, event) {
+			continue
+		}
+		err := d.send(ctx, notification.Spec, event)
+		if err != nil {
+			d.log.Error(err, "Failed to send notification", "notification", notification.Name, "event", event.Type)
+		}
+		d.updateStatus(ctx, notification, event, err)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, spec v1alpha1.ScalingNotificationSpec, event Event) error {
+	notifier, found := Notifiers[spec.Type]
+	if !found {
+		return fmt.Errorf("unknown notifier type %q", spec.Type)
+	}
+	url, err := d.url(ctx, spec)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	return notifier.Notify(ctx, d.httpClient, url, event)
+}
+
+func (d *Dispatcher) url(ctx context.Context, spec v1alpha1.ScalingNotificationSpec) (string, error) {
+	if spec.URLFrom == nil {
+		if spec.URL == "" {
+			return "", fmt.Errorf("neither url nor urlFrom is set")
+		}
+		return spec.URL, nil
+	}
+	secret := corev1.Secret{}
+	err := d.secrets.Get(ctx, client.ObjectKey{Namespace: d.namespace, Name: spec.URLFrom.Name}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the url from Secret %s/%s: %w", d.namespace, spec.URLFrom.Name, err)
+	}
+	url, found := secret.Data[spec.URLFrom.Key]
+	if !found || len(url) == 0 {
+		return "", fmt.Errorf("secret %s/%s has no key %s", d.namespace, spec.URLFrom.Name, spec.URLFrom.Key)
+	}
+	return string(url), nil
+}
+
+// updateStatus records the outcome on the ScalingNotification. It's best effort, a failed update only loses the status.
+func (d *Dispatcher) updateStatus(ctx context.Context, notification *v1alpha1.ScalingNotification, event Event, sendErr error) {
+	if sendErr != nil {
+		notification.Status.LastError = sendErr.Error()
+	} else {
+		now := metav1.Now()
+		notification.Status.LastSentTime = &now
+		notification.Status.LastEvent = event.Type
+		notification.Status.LastError = ""
+	}
+	if err := d.client.Status().Update(ctx, notification); err != nil {
+		d.log.Error(err, "Failed to update the status of the ScalingNotification", "notification", notification.Name)
+	}
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	// cloudEventsSource is the source attribute of the CloudEvents the operator sends
+	cloudEventsSource = "prescale-operator"
+	// cloudEventsTypePrefix is put in front of the event type, like com.prescale.scaling.TransitionStarted
+	cloudEventsTypePrefix = "com.prescale.scaling."
+)
+
+// Notifier sends an event to an URL
+type Notifier interface {
+	Notify(ctx context.Context, httpClient *http.Client, url string, event Event) error
+}
+
+// Notifiers holds the notifiers by the type of the ScalingNotification. Add to it to support more types.
+var Notifiers = map[string]Notifier{
+	v1alpha1.NotifierWebhook:     WebhookNotifier{},
+	v1alpha1.NotifierSlack:       SlackNotifier{},
+	v1alpha1.NotifierCloudEvents: CloudEventsNotifier{},
+}
+
+// WebhookNotifier posts the event as JSON
+type WebhookNotifier struct{}
+
+// Notify implements Notifier
+func (WebhookNotifier) Notify(ctx context.Context, httpClient *http.Client, url string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return post(ctx, httpClient, url, body, map[string]string{"Content-Type": "application/json"})
+}
+
+// SlackNotifier posts the message of the event to a Slack compatible incoming webhook
+type SlackNotifier struct{}
+
+// Notify implements Notifier
+func (SlackNotifier) Notify(ctx context.Context, httpClient *http.Client, url string, event Event) error {
+	body, err := json.Marshal(map[string]string{"text": slackText(event)})
+	if err != nil {
+		return err
+	}
+	return post(ctx, httpClient, url, body, map[string]string{"Content-Type": "application/json"})
+}
+
+func slackText(event Event) string {
+	icon := ":information_source:"
+	switch event.Type {
+	case v1alpha1.EventTransitionFinished:
+		icon = ":white_check_mark:"
+	case v1alpha1.EventQuotaRejected, v1alpha1.EventItemFailed:
+		icon = ":warning:"
+	}
+	text := fmt.Sprintf("%s *%s* %s", icon, event.Type, event.Message)
+	if len(event.ScalingClasses) > 0 {
+		text += fmt.Sprintf(" (scaling class %s)", strings.Join(event.ScalingClasses, ", "))
+	}
+	return text
+}
+
+// CloudEventsNotifier posts the event as a CloudEvent over HTTP in binary content mode:
+// the attributes are headers and the body is the event as JSON
+type CloudEventsNotifier struct{}
+
+// Notify implements Notifier
+func (CloudEventsNotifier) Notify(ctx context.Context, httpClient *http.Client, url string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return post(ctx, httpClient, url, body, map[string]string{
+		"Content-Type":   "application/json",
+		"ce-specversion": "1.0",
+		"ce-id":          string(uuid.NewUUID()),
+		"ce-source":      cloudEventsSource,
+		"ce-type":        cloudEventsTypePrefix + event.Type,
+		"ce-subject":     event.Namespace,
+		"ce-time":        event.Time.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func post(ctx context.Context, httpClient *http.Client, url string, body []byte, headers map[string]string) error {
+	// The URL is the secret of the ScalingNotification, so the errors only name its host
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("the URL of the notification is invalid")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*neturl.Error); ok {
+			return fmt.Errorf("%s %s: %v", urlErr.Op, req.URL.Host, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	// Only the start of the response goes into the error on the status of the ScalingNotification
+	response, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered with %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(response)))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+)
+
+// Event is what the notifiers send. It's encoded as JSON by the webhook and CloudEvents notifiers.
+type Event struct {
+	// Type is one of the events of the ScalingNotification, like TransitionStarted
+	Type           string    `json:"type"`
+	Time           time.Time `json:"time"`
+	Namespace      string    `json:"namespace"`
+	ScalingClasses []string  `json:"scalingClasses,omitempty"`
+	State          string    `json:"state,omitempty"`
+	// Items is the number of items of a transition
+	Items int `json:"items,omitempty"`
+	// DurationSeconds is how long a finished transition took
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+	Item            *Item   `json:"item,omitempty"`
+	Message         string  `json:"message"`
+}
+
+// Item is the scaling item an event is about
+type Item struct {
+	Kind            string `json:"kind"`
+	Name            string `json:"name"`
+	SpecReplicas    int32  `json:"specReplicas"`
+	ReadyReplicas   int32  `json:"readyReplicas"`
+	DesiredReplicas int32  `json:"desiredReplicas"`
+}
+
+var (
+	mu      sync.RWMutex
+	current *Dispatcher
+)
+
+// Publish hands the event to the running Dispatcher. It never blocks the scaling: without a Dispatcher,
+// or with a full queue, the event is dropped.
+func Publish(event Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case current.events <- event:
+	default:
+		current.log.Info("Dropping notification, the queue is full", "event", event.Type, "namespace", event.Namespace)
+	}
+}
+
+// TransitionStarted publishes the start of a transition of the namespace to the state of the items
+func TransitionStarted(ns string, state string, items []g.ScalingInfo) {
+	Publish(Event{
+		Type:           v1alpha1.EventTransitionStarted,
+		Namespace:      ns,
+		ScalingClasses: Classes(items),
+		State:          state,
+		Items:          len(items),
+		Message:        fmt.Sprintf("Scaling %d items of namespace %s to state %s", len(items), ns, state),
+	})
+}
+
+// TransitionFinished publishes the end of a transition once all its items finished scaling
+func TransitionFinished(ns string, state string, classes []string, duration time.Duration) {
+	Publish(Event{
+		Type:            v1alpha1.EventTransitionFinished,
+		Namespace:       ns,
+		ScalingClasses:  classes,
+		State:           state,
+		DurationSeconds: duration.Seconds(),
+		Message:         fmt.Sprintf("Namespace %s finished scaling to state %s in %s", ns, state, duration.Round(time.Second)),
+	})
+}
+
+// QuotaRejected publishes a scaling decision of the namespace denied by its ResourceQuotas
+func QuotaRejected(ns string, items []g.ScalingInfo) {
+	event := Event{
+		Type:           v1alpha1.EventQuotaRejected,
+		Namespace:      ns,
+		ScalingClasses: Classes(items),
+		Message:        fmt.Sprintf("The ResourceQuotas of namespace %s don't allow the scaling", ns),
+	}
+	if len(items) == 1 {
+		event.State = items[0].State
+		event.Item = newItem(items[0])
+		event.Message = fmt.Sprintf("The ResourceQuotas of namespace %s don't allow scaling %s %s to %d replicas", ns, items[0].ItemTypeName, items[0].Name, items[0].DesiredReplicas)
+	}
+	Publish(event)
+}
+
+// ItemFailed publishes an item the scaler put in failure state
+func ItemFailed(item g.ScalingInfo, message string) {
+	Publish(Event{
+		Type:           v1alpha1.EventItemFailed,
+		Namespace:      item.Namespace,
+		ScalingClasses: Classes([]g.ScalingInfo{item}),
+		State:          item.State,
+		Item:           newItem(item),
+		Message:        fmt.Sprintf("Failed to scale %s %s to %d replicas: %s", item.ItemTypeName, item.Name, item.DesiredReplicas, message),
+	})
+}
+
+// Classes returns the sorted scaling classes of the items
+func Classes(items []g.ScalingInfo) []string {
+	found := map[string]bool{}
+	classes := []string{}
+	for _, item := range items {
+		class := states.GetAppliedScalingClassFromScalingItem(item).Name
+		if !found[class] {
+			found[class] = true
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+func newItem(item g.ScalingInfo) *Item {
+	return &Item{
+		Kind:            item.ItemTypeName,
+		Name:            item.Name,
+		SpecReplicas:    item.SpecReplica,
+		ReadyReplicas:   item.ReadyReplicas,
+		DesiredReplicas: item.DesiredReplicas,
+	}
+}
+
+// Matches tells if the ScalingNotification subscribed to the event
+func Matches(spec v1alpha1.ScalingNotificationSpec, event Event) bool {
+	if len(spec.Events) > 0 && !contains(spec.Events, event.Type) {
+		return false
+	}
+	if len(spec.Namespaces) > 0 && !contains(spec.Namespaces, event.Namespace) {
+		return false
+	}
+	if len(spec.ScalingClasses) == 0 {
+		return true
+	}
+	for _, class := range event.ScalingClasses {
+		if contains(spec.ScalingClasses, class) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
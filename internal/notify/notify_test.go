@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type received struct {
+	header http.Header
+	body   string
+}
+
+func receiver(t *testing.T) (*httptest.Server, chan received) {
+	requests := make(chan received, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: string(body)}
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			http.Error(w, "no such hook", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestMatches(t *testing.T) {
+	event := Event{Type: v1alpha1.EventItemFailed, Namespace: "shop", ScalingClasses: []string{"batch", "default"}}
+	tests := []struct {
+		name string
+		spec v1alpha1.ScalingNotificationSpec
+		want bool
+	}{
+		{"no filters", v1alpha1.ScalingNotificationSpec{}, true},
+		{"event", v1alpha1.ScalingNotificationSpec{Events: []string{v1alpha1.EventItemFailed}}, true},
+		{"other event", v1alpha1.ScalingNotificationSpec{Events: []string{v1alpha1.EventTransitionStarted}}, false},
+		{"namespace", v1alpha1.ScalingNotificationSpec{Namespaces: []string{"shop"}}, true},
+		{"other namespace", v1alpha1.ScalingNotificationSpec{Namespaces: []string{"payments"}}, false},
+		{"one of the classes", v1alpha1.ScalingNotificationSpec{ScalingClasses: []string{"batch"}}, true},
+		{"other class", v1alpha1.ScalingNotificationSpec{ScalingClasses: []string{"web"}}, false},
+	}
+	for _, test := range tests {
+		if got := Matches(test.spec, event); got != test.want {
+			t.Errorf("Matches() with %s = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	server, requests := receiver(t)
+	event := Event{
+		Type:           v1alpha1.EventTransitionFinished,
+		Time:           time.Now(),
+		Namespace:      "shop",
+		ScalingClasses: []string{"default"},
+		State:          "peak",
+		Message:        "Namespace shop finished scaling to state peak in 1m0s",
+	}
+
+	if err := (WebhookNotifier{}).Notify(context.TODO(), server.Client(), server.URL, event); err != nil {
+		t.Fatalf("WebhookNotifier.Notify() = %v", err)
+	}
+	request := <-requests
+	sent := Event{}
+	if err := json.Unmarshal([]byte(request.body), &sent); err != nil || sent.State != "peak" || sent.Namespace != "shop" {
+		t.Errorf("WebhookNotifier sent %s, want the event as JSON", request.body)
+	}
+
+	if err := (SlackNotifier{}).Notify(context.TODO(), server.Client(), server.URL, event); err != nil {
+		t.Fatalf("SlackNotifier.Notify() = %v", err)
+	}
+	request = <-requests
+	message := map[string]string{}
+	if err := json.Unmarshal([]byte(request.body), &message); err != nil || !strings.Contains(message["text"], event.Message) {
+		t.Errorf("SlackNotifier sent %s, want a text with the message", request.body)
+	}
+
+	if err := (CloudEventsNotifier{}).Notify(context.TODO(), server.Client(), server.URL, event); err != nil {
+		t.Fatalf("CloudEventsNotifier.Notify() = %v", err)
+	}
+	request = <-requests
+	if request.header.Get("ce-type") != "com.prescale.scaling.TransitionFinished" || request.header.Get("ce-specversion") != "1.0" || request.header.Get("ce-id") == "" {
+		t.Errorf("CloudEventsNotifier sent headers %v, want the CloudEvents attributes", request.header)
+	}
+
+	if err := (WebhookNotifier{}).Notify(context.TODO(), server.Client(), server.URL+"/broken", event); err == nil || !strings.Contains(err.Error(), "no such hook") {
+		t.Errorf("WebhookNotifier.Notify() to a failing receiver = %v, want the response in the error", err)
+	}
+
+	// The URL is a secret and mustn't end up in the error on the status
+	server.Close()
+	if err := (WebhookNotifier{}).Notify(context.TODO(), server.Client(), server.URL+"/T000/secret-token", event); err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("WebhookNotifier.Notify() to an unreachable receiver = %v, want an error without the URL", err)
+	}
+	if err := (WebhookNotifier{}).Notify(context.TODO(), server.Client(), "http://hooks.example.com/secret-token\x7f", event); err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("WebhookNotifier.Notify() to an invalid URL = %v, want an error without the URL", err)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	server, requests := receiver(t)
+	_client := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "hooks", Namespace: "operator"},
+				Data:       map[string][]byte{"slack": []byte(server.URL + "/slack")},
+			},
+			&v1alpha1.ScalingNotification{
+				ObjectMeta: metav1.ObjectMeta{Name: "slack"},
+				Spec: v1alpha1.ScalingNotificationSpec{
+					Type:    v1alpha1.NotifierSlack,
+					URLFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hooks"}, Key: "slack"},
+					Events:  []string{v1alpha1.EventItemFailed},
+				},
+			},
+			&v1alpha1.ScalingNotification{
+				ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+				Spec:       v1alpha1.ScalingNotificationSpec{Type: v1alpha1.NotifierWebhook, URL: server.URL + "/broken"},
+			},
+		).
+		Build()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	d := NewDispatcher(_client, _client, "operator", ctrllog.NullLogger{})
+	go func() { _ = d.Start(ctx) }()
+	// Publish drops events until the dispatcher runs
+	for i := 0; i < 50; i++ {
+		mu.RLock()
+		started := current != nil
+		mu.RUnlock()
+		if started {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	item := g.ScalingInfo{Name: "web", Namespace: "shop", State: "peak", DesiredReplicas: 6, ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}}
+	ItemFailed(item, "ProgressDeadlineExceeded")
+	for bodies := map[string]bool{}; len(bodies) < 2; {
+		select {
+		case request := <-requests:
+			if strings.Contains(request.body, "ProgressDeadlineExceeded") {
+				bodies[request.body] = true
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Only %d of 2 notifications were sent", len(bodies))
+		}
+	}
+
+	// The status is updated after the request was answered
+	get := func(name string) v1alpha1.ScalingNotificationStatus {
+		notification := v1alpha1.ScalingNotification{}
+		_ = _client.Get(ctx, client.ObjectKey{Name: name}, &notification)
+		return notification.Status
+	}
+	for i := 0; i < 50 && (get("slack").LastSentTime == nil || get("broken").LastError == ""); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := get("slack"); status.LastSentTime == nil || status.LastEvent != v1alpha1.EventItemFailed || status.LastError != "" {
+		t.Errorf("Status of the slack notification = %+v, want sent ItemFailed", status)
+	}
+	if status := get("broken"); status.LastSentTime != nil || !strings.Contains(status.LastError, "404") {
+		t.Errorf("Status of the broken notification = %+v, want the error", status)
+	}
+}
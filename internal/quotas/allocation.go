@@ -0,0 +1,155 @@
+package quotas
+
+import (
+	"sort"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/containersol/prescale-operator/pkg/utils/math"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Policies for scale-ups that exceed the ResourceQuotas of a namespace
+const (
+	// QuotaPolicyAllOrNothing doesn't scale any item of the namespace
+	QuotaPolicyAllOrNothing = "all-or-nothing"
+	// QuotaPolicyProportional gives every item the same share of its missing replicas the quota allows
+	QuotaPolicyProportional = "proportional"
+	// QuotaPolicyPriority gives the quota to the items with the highest priority first
+	QuotaPolicyPriority = "priority"
+)
+
+// IsQuotaPolicy tells if the policy is known
+func IsQuotaPolicy(policy string) bool {
+	switch policy {
+	case QuotaPolicyAllOrNothing, QuotaPolicyProportional, QuotaPolicyPriority:
+		return true
+	}
+	return false
+}
+
+// Allocate lowers the desired replicas of the items to what fits into the headroom, following the best-effort policy
+func Allocate(policy string, headroom corev1.ResourceList, items []g.ScalingInfo) []g.ScalingInfo {
+	if policy == QuotaPolicyPriority {
+		return AllocateQuotaByPriority(headroom, items)
+	}
+	return AllocateQuota(headroom, items)
+}
+
+// AllocateQuota lowers the desired replicas of the items that scale up so that all of them together fit into the headroom
+// of the ResourceQuotas. Every item gets the same share of the replicas it's missing. What is left after that is handed out
+// replica by replica, highest priority first. Items that don't reach their target record how many replicas they are short in QuotaShortfall.
+// The headroom only contains the resources limited by a quota. Scale-downs are not counted, as their resources are only
+// freed once the pods are gone.
+func AllocateQuota(headroom corev1.ResourceList, items []g.ScalingInfo) []g.ScalingInfo {
+	result := make([]g.ScalingInfo, len(items))
+	copy(result, items)
+
+	order, missing := scaleUps(result)
+	totalNeeded := corev1.ResourceList{}
+	for _, i := range order {
+		totalNeeded = math.Add(totalNeeded, math.Mul(missing[i], result[i].ResourceList))
+	}
+
+	share := 1.0
+	for name, needed := range totalNeeded {
+		available, found := headroom[name]
+		if !found || needed.MilliValue() <= 0 {
+			continue
+		}
+		ratio := float64(available.MilliValue()) / float64(needed.MilliValue())
+		if ratio < share {
+			share = ratio
+		}
+	}
+	if share < 0 {
+		share = 0
+	}
+
+	left := headroom.DeepCopy()
+	granted := make([]int32, len(result))
+	for _, i := range order {
+		granted[i] = int32(share * float64(missing[i]))
+		left = subtractLimited(left, math.Mul(granted[i], result[i].ResourceList))
+	}
+
+	// Rounding down leaves some headroom. Hand it out one replica at a time.
+	for progress := true; progress; {
+		progress = false
+		for _, i := range order {
+			if granted[i] < missing[i] && withinHeadroom(left, result[i].ResourceList) {
+				granted[i]++
+				left = subtractLimited(left, result[i].ResourceList)
+				progress = true
+			}
+		}
+	}
+
+	for _, i := range order {
+		result[i].QuotaShortfall = missing[i] - granted[i]
+		result[i].DesiredReplicas = result[i].SpecReplica + granted[i]
+	}
+	return result
+}
+
+// AllocateQuotaByPriority lowers the desired replicas of the items that scale up so that all of them together fit into the
+// headroom of the ResourceQuotas. The items with the highest priority get all the replicas that fit first, the next ones get
+// what is left. Items that don't reach their target record how many replicas they are short in QuotaShortfall.
+func AllocateQuotaByPriority(headroom corev1.ResourceList, items []g.ScalingInfo) []g.ScalingInfo {
+	result := make([]g.ScalingInfo, len(items))
+	copy(result, items)
+
+	order, missing := scaleUps(result)
+	left := headroom.DeepCopy()
+	for _, i := range order {
+		granted := int32(0)
+		for granted < missing[i] && withinHeadroom(left, result[i].ResourceList) {
+			granted++
+			left = subtractLimited(left, result[i].ResourceList)
+		}
+		result[i].QuotaShortfall = missing[i] - granted
+		result[i].DesiredReplicas = result[i].SpecReplica + granted
+	}
+	return result
+}
+
+// scaleUps returns the indexes of the items that scale up, highest priority first, and how many replicas each of them is missing
+func scaleUps(items []g.ScalingInfo) ([]int, []int32) {
+	order := make([]int, 0, len(items))
+	missing := make([]int32, len(items))
+	for i, item := range items {
+		if item.DesiredReplicas == -1 || item.DesiredReplicas <= item.SpecReplica {
+			continue
+		}
+		missing[i] = item.DesiredReplicas - item.SpecReplica
+		order = append(order, i)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if items[order[a]].Priority != items[order[b]].Priority {
+			return items[order[a]].Priority > items[order[b]].Priority
+		}
+		return items[order[a]].Name < items[order[b]].Name
+	})
+	return order, missing
+}
+
+// withinHeadroom tells if the resources fit into what is left. Resources without a quota are not limited.
+func withinHeadroom(left corev1.ResourceList, resources corev1.ResourceList) bool {
+	for name, quantity := range resources {
+		if available, found := left[name]; found && available.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// subtractLimited subtracts the resources from what is left, leaving out the ones without a quota
+func subtractLimited(left corev1.ResourceList, resources corev1.ResourceList) corev1.ResourceList {
+	result := left.DeepCopy()
+	for name, quantity := range resources {
+		if available, found := result[name]; found {
+			available.Sub(quantity)
+			result[name] = available
+		}
+	}
+	return result
+}
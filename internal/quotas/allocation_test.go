@@ -0,0 +1,120 @@
+package quotas
+
+import (
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAllocateQuota(t *testing.T) {
+	item := func(name string, current, desired int32, cpu string) g.ScalingInfo {
+		return g.ScalingInfo{
+			Name:            name,
+			SpecReplica:     current,
+			DesiredReplicas: desired,
+			ResourceList: corev1.ResourceList{
+				corev1.ResourceLimitsCPU: resource.MustParse(cpu),
+				corev1.ResourcePods:      resource.MustParse("1"),
+			},
+		}
+	}
+	cpu := func(quantity string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse(quantity)}
+	}
+
+	tests := []struct {
+		name          string
+		headroom      corev1.ResourceList
+		items         []g.ScalingInfo
+		wantDesired   []int32
+		wantShortfall []int32
+	}{
+		{
+			name:          "TestEverythingFits",
+			headroom:      cpu("10"),
+			items:         []g.ScalingInfo{item("a", 1, 3, "1"), item("b", 1, 3, "1")},
+			wantDesired:   []int32{3, 3},
+			wantShortfall: []int32{0, 0},
+		},
+		{
+			name:          "TestProportionalShare",
+			headroom:      cpu("6"),
+			items:         []g.ScalingInfo{item("a", 0, 4, "1"), item("b", 0, 8, "1")},
+			wantDesired:   []int32{2, 4},
+			wantShortfall: []int32{2, 4},
+		},
+		{
+			name:          "TestLeftoversAreHandedOut",
+			headroom:      cpu("5"),
+			items:         []g.ScalingInfo{item("a", 0, 4, "1"), item("b", 0, 4, "1")},
+			wantDesired:   []int32{3, 2},
+			wantShortfall: []int32{1, 2},
+		},
+		{
+			name:          "TestScaleDownsAreUntouched",
+			headroom:      cpu("1"),
+			items:         []g.ScalingInfo{item("a", 5, 2, "1"), item("b", 0, 3, "1")},
+			wantDesired:   []int32{2, 1},
+			wantShortfall: []int32{0, 2},
+		},
+		{
+			name:          "TestNoHeadroomLeft",
+			headroom:      cpu("-1"),
+			items:         []g.ScalingInfo{item("a", 1, 3, "1")},
+			wantDesired:   []int32{1},
+			wantShortfall: []int32{2},
+		},
+		{
+			name:          "TestResourcesWithoutQuotaAreNotLimited",
+			headroom:      corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+			items:         []g.ScalingInfo{item("a", 1, 3, "1")},
+			wantDesired:   []int32{3},
+			wantShortfall: []int32{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllocateQuota(tt.headroom, tt.items)
+			for i := range got {
+				if got[i].DesiredReplicas != tt.wantDesired[i] {
+					t.Errorf("AllocateQuota() %s desired = %d, want %d", got[i].Name, got[i].DesiredReplicas, tt.wantDesired[i])
+				}
+				if got[i].QuotaShortfall != tt.wantShortfall[i] {
+					t.Errorf("AllocateQuota() %s shortfall = %d, want %d", got[i].Name, got[i].QuotaShortfall, tt.wantShortfall[i])
+				}
+			}
+			if tt.items[0].QuotaShortfall != 0 {
+				t.Errorf("AllocateQuota() changed the given items")
+			}
+		})
+	}
+}
+
+func TestAllocateQuotaByPriority(t *testing.T) {
+	item := func(name string, desired, priority int32) g.ScalingInfo {
+		return g.ScalingInfo{
+			Name:            name,
+			DesiredReplicas: desired,
+			Priority:        priority,
+			ResourceList: corev1.ResourceList{
+				corev1.ResourceLimitsCPU: resource.MustParse("1"),
+			},
+		}
+	}
+	headroom := corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("5")}
+	items := []g.ScalingInfo{item("low", 4, 1), item("high", 4, 10), item("none", 2, 0)}
+	wantDesired := []int32{1, 4, 0}
+	wantShortfall := []int32{3, 0, 2}
+
+	got := Allocate(QuotaPolicyPriority, headroom, items)
+	for i := range got {
+		if got[i].DesiredReplicas != wantDesired[i] {
+			t.Errorf("AllocateQuotaByPriority() %s desired = %d, want %d", got[i].Name, got[i].DesiredReplicas, wantDesired[i])
+		}
+		if got[i].QuotaShortfall != wantShortfall[i] {
+			t.Errorf("AllocateQuotaByPriority() %s shortfall = %d, want %d", got[i].Name, got[i].QuotaShortfall, wantShortfall[i])
+		}
+	}
+}
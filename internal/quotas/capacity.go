@@ -0,0 +1,232 @@
+package quotas
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/pkg/utils/client"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/containersol/prescale-operator/pkg/utils/math"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=list;
+// +kubebuilder:rbac:groups="",resources=pods,verbs=list;
+
+// ClusterCapacityCheck checks if the nodes have enough allocatable resources left for the pods the items would add when
+// scaled to their desired replicas. The new pods are placed on the nodes one by one, honouring node selectors, taints and
+// required node affinity. Pod affinity, topology spread and other scheduler features are not taken into account.
+func ClusterCapacityCheck(ctx context.Context, items []g.ScalingInfo) (v1alpha1.ClusterCapacity, error) {
+	kubernetesclient, err := client.GetClientSet()
+	if err != nil {
+		return v1alpha1.ClusterCapacity{}, err
+	}
+
+	nodes, err := kubernetesclient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return v1alpha1.ClusterCapacity{}, err
+	}
+
+	// Finished pods don't take any resources anymore
+	pods, err := kubernetesclient.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return v1alpha1.ClusterCapacity{}, err
+	}
+
+	return clusterCapacity(nodes.Items, pods.Items, items), nil
+}
+
+func clusterCapacity(nodes []corev1.Node, pods []corev1.Pod, items []g.ScalingInfo) v1alpha1.ClusterCapacity {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	// What is left on every node after the requests of the pods running there
+	free := make(map[string]corev1.ResourceList)
+	for _, node := range nodes {
+		free[node.Name] = schedulerResources(node.Status.Allocatable)
+	}
+	for _, pod := range pods {
+		if _, found := free[pod.Spec.NodeName]; !found {
+			continue
+		}
+		free[pod.Spec.NodeName] = math.Subtract(free[pod.Spec.NodeName], podRequests(math.PodQuotaResources(pod.Spec)))
+	}
+
+	// Place the items in a stable order, so the same transition always gets the same result
+	items = append([]g.ScalingInfo{}, items...)
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	result := v1alpha1.ClusterCapacity{Fits: true}
+	shortfall := corev1.ResourceList{}
+	for _, item := range items {
+		newPods := item.DesiredReplicas - item.SpecReplica
+		if item.DesiredReplicas == -1 || newPods <= 0 {
+			continue
+		}
+		requests := podRequests(item.ResourceList)
+		for i := int32(0); i < newPods; i++ {
+			result.NewPods++
+			placed := false
+			for _, node := range nodes {
+				if !schedulable(node, item.PodTemplate) || !fits(free[node.Name], requests) {
+					continue
+				}
+				free[node.Name] = math.Subtract(free[node.Name], requests)
+				placed = true
+				break
+			}
+			if !placed {
+				result.Fits = false
+				result.UnschedulablePods++
+				shortfall = math.Add(shortfall, requests)
+			}
+		}
+	}
+
+	if len(shortfall) > 0 {
+		result.Shortfall = make(map[string]string)
+		for name, quantity := range shortfall {
+			result.Shortfall[string(name)] = quantity.String()
+		}
+	}
+	return result
+}
+
+// podRequests translates the quota resources of a pod to the resources the scheduler checks
+func podRequests(quotaResources corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	if quantity, ok := quotaResources[corev1.ResourceRequestsCPU]; ok {
+		result[corev1.ResourceCPU] = quantity
+	}
+	if quantity, ok := quotaResources[corev1.ResourceRequestsMemory]; ok {
+		result[corev1.ResourceMemory] = quantity
+	}
+	if quantity, ok := quotaResources[corev1.ResourcePods]; ok {
+		result[corev1.ResourcePods] = quantity
+	}
+	return result
+}
+
+func schedulerResources(allocatable corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+		if quantity, ok := allocatable[name]; ok {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
+func fits(free corev1.ResourceList, requests corev1.ResourceList) bool {
+	for name, quantity := range requests {
+		left, found := free[name]
+		if !found || left.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// schedulable tells if a pod of the spec may be put on the node at all
+func schedulable(node corev1.Node, spec corev1.PodSpec) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+
+	for key, value := range spec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+
+	for i := range node.Spec.Taints {
+		taint := node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range spec.Tolerations {
+			if spec.Tolerations[j].ToleratesTaint(&taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+
+	if spec.Affinity != nil && spec.Affinity.NodeAffinity != nil && spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		return matchesNodeSelectorTerms(node.Labels, spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+	}
+	return true
+}
+
+// matchesNodeSelectorTerms checks the label expressions of the terms. The terms are ORed, the expressions of a term ANDed.
+// Like in the scheduler, an empty list of terms matches no node.
+func matchesNodeSelectorTerms(labels map[string]string, terms []corev1.NodeSelectorTerm) bool {
+	for _, term := range terms {
+		matches := true
+		for _, expression := range term.MatchExpressions {
+			if !matchesNodeSelectorRequirement(labels, expression) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesNodeSelectorRequirement(labels map[string]string, requirement corev1.NodeSelectorRequirement) bool {
+	value, found := labels[requirement.Key]
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		return found && contains(requirement.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !found || !contains(requirement.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return found
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !found
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !found || len(requirement.Values) != 1 {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == corev1.NodeSelectorOpGt {
+			return labelValue > bound
+		}
+		return labelValue < bound
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
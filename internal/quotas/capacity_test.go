@@ -0,0 +1,139 @@
+package quotas
+
+import (
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_clusterCapacity(t *testing.T) {
+	node := func(name string, cpu string, labels map[string]string, taints ...corev1.Taint) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       corev1.NodeSpec{Taints: taints},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+			},
+		}
+	}
+	running := corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeName: "a",
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			}},
+		},
+	}
+	item := func(current, desired int32, spec corev1.PodSpec) g.ScalingInfo {
+		spec.Containers = []corev1.Container{{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		}}
+		return g.ScalingInfo{
+			Name:            "foo",
+			Namespace:       "bar",
+			SpecReplica:     current,
+			DesiredReplicas: desired,
+			PodTemplate:     spec,
+			ResourceList: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("1"),
+				corev1.ResourcePods:        resource.MustParse("1"),
+			},
+		}
+	}
+	gpuTaint := corev1.Taint{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+	tests := []struct {
+		name              string
+		nodes             []corev1.Node
+		item              g.ScalingInfo
+		wantFits          bool
+		wantUnschedulable int32
+		wantShortfallCPU  string
+	}{
+		{
+			name:     "TestFitsOnFreeResources",
+			nodes:    []corev1.Node{node("a", "2", nil), node("b", "2", nil)},
+			item:     item(1, 4, corev1.PodSpec{}),
+			wantFits: true,
+		},
+		{
+			name:              "TestRunningPodsAreSubtracted",
+			nodes:             []corev1.Node{node("a", "2", nil), node("b", "2", nil)},
+			item:              item(1, 5, corev1.PodSpec{}),
+			wantFits:          false,
+			wantUnschedulable: 1,
+			wantShortfallCPU:  "1",
+		},
+		{
+			name:              "TestNodeSelectorIsHonoured",
+			nodes:             []corev1.Node{node("a", "2", nil), node("b", "2", map[string]string{"zone": "x"})},
+			item:              item(0, 3, corev1.PodSpec{NodeSelector: map[string]string{"zone": "x"}}),
+			wantFits:          false,
+			wantUnschedulable: 1,
+			wantShortfallCPU:  "1",
+		},
+		{
+			name:              "TestTaintsAreHonoured",
+			nodes:             []corev1.Node{node("a", "2", nil), node("b", "2", nil, gpuTaint)},
+			item:              item(0, 2, corev1.PodSpec{}),
+			wantFits:          false,
+			wantUnschedulable: 1,
+			wantShortfallCPU:  "1",
+		},
+		{
+			name:  "TestTolerationsAreHonoured",
+			nodes: []corev1.Node{node("a", "2", nil), node("b", "2", nil, gpuTaint)},
+			item: item(0, 3, corev1.PodSpec{
+				Tolerations: []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+			}),
+			wantFits: true,
+		},
+		{
+			name:  "TestRequiredNodeAffinityIsHonoured",
+			nodes: []corev1.Node{node("a", "2", nil), node("b", "4", map[string]string{"zone": "x"})},
+			item: item(0, 5, corev1.PodSpec{
+				Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"x", "y"}}},
+						}},
+					},
+				}},
+			}),
+			wantFits:          false,
+			wantUnschedulable: 1,
+			wantShortfallCPU:  "1",
+		},
+		{
+			name:     "TestScaleDownNeedsNothing",
+			nodes:    []corev1.Node{},
+			item:     item(4, 1, corev1.PodSpec{}),
+			wantFits: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clusterCapacity(tt.nodes, []corev1.Pod{running}, []g.ScalingInfo{tt.item})
+			if got.Fits != tt.wantFits {
+				t.Errorf("clusterCapacity() fits = %v, want %v", got.Fits, tt.wantFits)
+			}
+			if got.UnschedulablePods != tt.wantUnschedulable {
+				t.Errorf("clusterCapacity() unschedulable pods = %d, want %d", got.UnschedulablePods, tt.wantUnschedulable)
+			}
+			if got.Shortfall[string(corev1.ResourceCPU)] != tt.wantShortfallCPU {
+				t.Errorf("clusterCapacity() cpu shortfall = %q, want %q", got.Shortfall[string(corev1.ResourceCPU)], tt.wantShortfallCPU)
+			}
+		})
+	}
+}
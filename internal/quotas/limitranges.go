@@ -0,0 +1,99 @@
+package quotas
+
+import (
+	"context"
+
+	"github.com/containersol/prescale-operator/pkg/utils/client"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/containersol/prescale-operator/pkg/utils/math"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// +kubebuilder:rbac:groups="",resources=limitranges,verbs=list;
+
+// ApplyLimitRangeDefaults recomputes the resources of the scaling items with the default requests and limits the LimitRanges of the namespace
+// inject into containers that don't specify them. Without these defaults, the quota check would miss what the new pods are charged.
+// The items are returned unchanged if the namespace has no LimitRanges.
+func ApplyLimitRangeDefaults(ctx context.Context, namespace string, items []g.ScalingInfo) ([]g.ScalingInfo, error) {
+	kubernetesclient, err := client.GetClientSet()
+	if err != nil {
+		return items, err
+	}
+
+	lrl, err := limitRanges(ctx, namespace, kubernetesclient)
+	if err != nil {
+		return items, err
+	}
+	if len(lrl.Items) == 0 {
+		return items, nil
+	}
+
+	result := make([]g.ScalingInfo, len(items))
+	for i, item := range items {
+		result[i] = item
+		if len(item.PodTemplate.Containers) == 0 {
+			continue
+		}
+		result[i].ResourceList = math.PodQuotaResources(withLimitRangeDefaults(item.PodTemplate, lrl.Items))
+	}
+	return result, nil
+}
+
+// withLimitRangeDefaults returns a copy of the pod spec with the resources the API server would set on admission
+func withLimitRangeDefaults(spec corev1.PodSpec, limitRanges []corev1.LimitRange) corev1.PodSpec {
+	defaulted := *spec.DeepCopy()
+	for i := range defaulted.Containers {
+		defaulted.Containers[i].Resources = withContainerDefaults(defaulted.Containers[i].Resources, limitRanges)
+	}
+	for i := range defaulted.InitContainers {
+		defaulted.InitContainers[i].Resources = withContainerDefaults(defaulted.InitContainers[i].Resources, limitRanges)
+	}
+	return defaulted
+}
+
+func withContainerDefaults(resources corev1.ResourceRequirements, limitRanges []corev1.LimitRange) corev1.ResourceRequirements {
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+
+	// Requests default to the limits of the container itself before the LimitRange defaults apply
+	for key, value := range resources.Limits {
+		if _, found := resources.Requests[key]; !found {
+			resources.Requests[key] = value.DeepCopy()
+		}
+	}
+
+	for _, lr := range limitRanges {
+		for _, limit := range lr.Spec.Limits {
+			if limit.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for key, value := range limit.Default {
+				if _, found := resources.Limits[key]; !found {
+					resources.Limits[key] = value.DeepCopy()
+				}
+			}
+			for key, value := range limit.DefaultRequest {
+				if _, found := resources.Requests[key]; !found {
+					resources.Requests[key] = value.DeepCopy()
+				}
+			}
+		}
+	}
+	return resources
+}
+
+func limitRanges(ctx context.Context, namespace string, kubernetesclient kubernetes.Interface) (*corev1.LimitRangeList, error) {
+
+	lrl, err := kubernetesclient.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return &corev1.LimitRangeList{}, err
+	}
+
+	return lrl, nil
+}
@@ -0,0 +1,131 @@
+package quotas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containersol/prescale-operator/pkg/utils/math"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_withLimitRangeDefaults(t *testing.T) {
+	limitRange := corev1.LimitRange{
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:    corev1.LimitTypePod,
+					Default: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")},
+				},
+				{
+					Type:           corev1.LimitTypeContainer,
+					Default:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					DefaultRequest: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		resources    corev1.ResourceRequirements
+		wantRequests string
+		wantLimits   string
+	}{
+		{
+			name:         "TestDefaultsForEmptyContainer",
+			resources:    corev1.ResourceRequirements{},
+			wantRequests: "500m",
+			wantLimits:   "1",
+		},
+		{
+			name: "TestRequestsDefaultToOwnLimits",
+			resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			},
+			wantRequests: "2",
+			wantLimits:   "2",
+		},
+		{
+			name: "TestOwnRequestsAreKept",
+			resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+			},
+			wantRequests: "200m",
+			wantLimits:   "1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := corev1.PodSpec{Containers: []corev1.Container{{Resources: tt.resources}}}
+
+			got := withLimitRangeDefaults(spec, []corev1.LimitRange{limitRange}).Containers[0].Resources
+			requests := got.Requests[corev1.ResourceCPU]
+			limits := got.Limits[corev1.ResourceCPU]
+			if requests.Cmp(resource.MustParse(tt.wantRequests)) != 0 {
+				t.Errorf("withLimitRangeDefaults() requests = %s, want %s", requests.String(), tt.wantRequests)
+			}
+			if limits.Cmp(resource.MustParse(tt.wantLimits)) != 0 {
+				t.Errorf("withLimitRangeDefaults() limits = %s, want %s", limits.String(), tt.wantLimits)
+			}
+			if len(spec.Containers[0].Resources.Limits) != len(tt.resources.Limits) {
+				t.Errorf("withLimitRangeDefaults() changed the given pod spec")
+			}
+		})
+	}
+}
+
+func Test_withLimitRangeDefaultsCountsAgainstQuota(t *testing.T) {
+	limitRange := corev1.LimitRange{
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				Default:        corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+				DefaultRequest: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+			}},
+		},
+	}
+	rql := &corev1.ResourceQuotaList{
+		Items: []corev1.ResourceQuota{{
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("1Gi")},
+				Used: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("0")},
+			},
+		}},
+	}
+	spec := corev1.PodSpec{Containers: []corev1.Container{{}}}
+
+	// Without the defaults 3 pods don't need any memory
+	_, _, allowed, _ := isAllowed(rql, scaledBy(math.Mul(3, math.PodQuotaResources(spec))))
+	if !allowed {
+		t.Errorf("isAllowed() without LimitRange defaults = %v, want %v", allowed, true)
+	}
+
+	// With the defaults 3 pods need 1.5Gi of memory limits
+	_, _, allowed, _ = isAllowed(rql, scaledBy(math.Mul(3, math.PodQuotaResources(withLimitRangeDefaults(spec, []corev1.LimitRange{limitRange})))))
+	if allowed {
+		t.Errorf("isAllowed() with LimitRange defaults = %v, want %v", allowed, false)
+	}
+}
+
+func Test_limitRanges(t *testing.T) {
+	kubernetesclient := fake.NewSimpleClientset(&corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "foo"},
+	})
+
+	got, err := limitRanges(context.TODO(), "foo", kubernetesclient)
+	if err != nil {
+		t.Errorf("limitRanges() error = %v", err)
+		return
+	}
+	if len(got.Items) != 1 {
+		t.Errorf("limitRanges() = %d items, want %d", len(got.Items), 1)
+	}
+
+	got, err = limitRanges(context.TODO(), "bar", kubernetesclient)
+	if err != nil || len(got.Items) != 0 {
+		t.Errorf("limitRanges() = %d items, error %v, want no items", len(got.Items), err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	constants "github.com/containersol/prescale-operator/internal"
 	"github.com/containersol/prescale-operator/pkg/utils/client"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
 	"github.com/containersol/prescale-operator/pkg/utils/math"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -18,8 +19,8 @@ import (
 
 // +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=list;
 
-//ResourceQuotaCheck function first checks if there are resourceQuota objects present and then validates if the namespace has enough resources to scale
-func ResourceQuotaCheck(ctx context.Context, namespace string, limitsneeded corev1.ResourceList) (string, string, bool, error) {
+//ResourceQuotaCheck function first checks if there are resourceQuota objects present and then validates if the namespace has enough resources to scale the items
+func ResourceQuotaCheck(ctx context.Context, namespace string, items []g.ScalingInfo) (string, string, bool, error) {
 
 	var allowed bool
 	var finalLimitsCPU, finalLimitsMemory string
@@ -38,16 +39,11 @@ func ResourceQuotaCheck(ctx context.Context, namespace string, limitsneeded core
 		return finalLimitsCPU, finalLimitsMemory, false, err
 	}
 
-	if len(math.IsNegative(limitsneeded)) > 0 {
+	if onlyFrees(items) {
 		return finalLimitsCPU, finalLimitsMemory, true, nil
 	}
 
-	if math.IsZero(limitsneeded) {
-		ctrl.Log.Info("WARNING: No Resource limits are specified in the target object")
-		return finalLimitsCPU, finalLimitsMemory, true, nil
-	}
-
-	finalLimitsCPU, finalLimitsMemory, allowed, err = isAllowed(rq, limitsneeded)
+	finalLimitsCPU, finalLimitsMemory, allowed, err = isAllowed(rq, items)
 	if err != nil {
 		ctrl.Log.Error(err, "Cannot find namespace quotas")
 		return finalLimitsCPU, finalLimitsMemory, false, err
@@ -56,30 +52,50 @@ func ResourceQuotaCheck(ctx context.Context, namespace string, limitsneeded core
 	return finalLimitsCPU, finalLimitsMemory, allowed, nil
 }
 
+// ResourcesNeeded returns what scaling the items to their desired replicas adds to the quota usage
+func ResourcesNeeded(items []g.ScalingInfo) corev1.ResourceList {
+
+	var limitsneeded corev1.ResourceList
+	for _, item := range items {
+		limitsneeded = math.Add(limitsneeded, math.Mul(math.ReplicaCalc(item.DesiredReplicas, item.SpecReplica), item.ResourceList))
+	}
+	return limitsneeded
+}
+
+// onlyFrees tells if scaling the items needs no more of any resource, like when they scale down, to zero or not at all.
+// A resource the items free doesn't make up for another one they need more of.
+func onlyFrees(items []g.ScalingInfo) bool {
+	return len(math.IsPositive(ResourcesNeeded(items))) == 0
+}
+
 //This function will determine if we exceed the available resources in at least one resourcequota object
-func isAllowed(rql *corev1.ResourceQuotaList, limitsneeded corev1.ResourceList) (string, string, bool, error) {
+func isAllowed(rql *corev1.ResourceQuotaList, items []g.ScalingInfo) (string, string, bool, error) {
 
 	var leftovers corev1.ResourceList
 	var checklimits corev1.ResourceList
 	var finalLimitsCPU, finalLimitsMemory string
 
 	log := ctrl.Log.
-		WithValues("Limits needed", limitsneeded)
+		WithValues("Limits needed", ResourcesNeeded(items))
 	log.Info("Identified Resources")
 
 	for _, rq := range rql.Items {
-		// we don't care about ResourceQuotas that contain persistentvolumeclaims or storageRequests
 		rq = sanitizeRQ(rq)
 		if len(rq.Status.Hard) == 0 && len(rq.Status.Used) == 0 {
 			continue
 		}
+		// Scoped quotas only track the pods of some items
+		limitsneeded := ResourcesNeeded(matchingItems(rq, items))
+		if limitsneeded == nil {
+			continue
+		}
 		leftovers = math.Subtract(rq.Status.Hard, rq.Status.Used)
 		log = ctrl.Log.
 			WithValues("Rq name", rq.Name).
 			WithValues("Leftover resources", leftovers)
 		log.Info("Resource Quota")
 
-		checklimits = math.Subtract(leftovers, math.TranslateResourcesToQuotaResources(limitsneeded))
+		checklimits = charged(leftovers, math.TranslateResourcesToQuotaResources(limitsneeded))
 
 		log = ctrl.Log.
 			WithValues("Limits", checklimits)
@@ -95,6 +111,81 @@ func isAllowed(rql *corev1.ResourceQuotaList, limitsneeded corev1.ResourceList)
 	return finalLimitsCPU, finalLimitsMemory, true, nil
 }
 
+//ResourceQuotaHeadroom returns the resources left in the namespace for the items. With afterScaling, what the items need to scale is subtracted.
+//With several ResourceQuota objects the smallest leftover per resource counts. Scoped quotas count if they track at least one of the items.
+//Returns nil if the namespace has no ResourceQuotas.
+func ResourceQuotaHeadroom(ctx context.Context, namespace string, items []g.ScalingInfo, afterScaling bool) (corev1.ResourceList, error) {
+	kubernetesclient, err := client.GetClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rq, err := resourceQuota(ctx, namespace, kubernetesclient)
+	if err != nil {
+		if strings.Contains(err.Error(), constants.RQNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return headroom(rq, items, afterScaling), nil
+}
+
+func headroom(rql *corev1.ResourceQuotaList, items []g.ScalingInfo, afterScaling bool) corev1.ResourceList {
+	var result corev1.ResourceList
+
+	for _, rq := range rql.Items {
+		rq = sanitizeRQ(rq)
+		if len(rq.Status.Hard) == 0 && len(rq.Status.Used) == 0 {
+			continue
+		}
+		matching := matchingItems(rq, items)
+		if len(matching) == 0 {
+			continue
+		}
+		left := math.Subtract(rq.Status.Hard, rq.Status.Used)
+		if afterScaling {
+			left = subtractLimited(left, math.TranslateResourcesToQuotaResources(ResourcesNeeded(matching)))
+		}
+		if result == nil {
+			result = left
+			continue
+		}
+		for key, quantity := range left {
+			if current, found := result[key]; !found || quantity.Cmp(current) < 0 {
+				result[key] = quantity
+			}
+		}
+	}
+
+	return result
+}
+
+// matchingItems returns the items whose pods are tracked by the quota
+func matchingItems(rq corev1.ResourceQuota, items []g.ScalingInfo) []g.ScalingInfo {
+	result := []g.ScalingInfo{}
+	for _, item := range items {
+		if matchesScopes(rq, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// charged returns what is left of the resources the items need after charging them. Resources the quota doesn't limit are
+// left out, and so are the ones the items don't need, like object counts of other kinds.
+func charged(leftovers corev1.ResourceList, limitsneeded corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, needed := range limitsneeded {
+		if left, found := leftovers[name]; found {
+			quantity := left.DeepCopy()
+			quantity.Sub(needed)
+			result[name] = quantity
+		}
+	}
+	return result
+}
+
 func specificResourceQuotas(limits corev1.ResourceList) (string, string) {
 
 	var finalLimitsCPU, finalLimitsMemory resource.Quantity
@@ -111,33 +202,55 @@ func specificResourceQuotas(limits corev1.ResourceList) (string, string) {
 	return finalLimitsCPU.String(), finalLimitsMemory.String()
 }
 
-func sanitizeRQ(rq corev1.ResourceQuota) corev1.ResourceQuota {
-	listHard := rq.Status.Hard
-	listUsed := rq.Status.Used
+// quotaAliases are ResourceQuota names that are charged the same as the names the pod footprint uses
+var quotaAliases = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceCPU:              corev1.ResourceRequestsCPU,
+	corev1.ResourceMemory:           corev1.ResourceRequestsMemory,
+	corev1.ResourceEphemeralStorage: corev1.ResourceRequestsEphemeralStorage,
+	"count/pods":                    corev1.ResourcePods,
+	"count/persistentvolumeclaims":  corev1.ResourcePersistentVolumeClaims,
+}
 
-	for key, _ := range listHard {
-		switch key {
-		case "limits.cpu":
-		case "limits.memory":
-		case "requests.cpu":
-		case "requests.memory":
-		default:
-			delete(listHard, key)
+// sanitizeRQ renames the resources of the quota to the names the pod footprint uses. If a quota limits a resource under
+// two names, the smaller leftover counts.
+func sanitizeRQ(rq corev1.ResourceQuota) corev1.ResourceQuota {
+	hard, used := corev1.ResourceList{}, corev1.ResourceList{}
+	for key, value := range rq.Status.Hard {
+		name := key
+		if alias, found := quotaAliases[key]; found {
+			name = alias
+		}
+		usedValue := rq.Status.Used[key]
+		if current, found := hard[name]; found {
+			currentLeft := current.DeepCopy()
+			currentLeft.Sub(used[name])
+			left := value.DeepCopy()
+			left.Sub(usedValue)
+			if currentLeft.Cmp(left) <= 0 {
+				continue
+			}
+		}
+		hard[name] = value
+		if _, found := rq.Status.Used[key]; found {
+			used[name] = usedValue
+		} else {
+			delete(used, name)
 		}
 	}
-	rq.Status.Hard = listHard
-
-	for key, _ := range listUsed {
-		switch key {
-		case "limits.cpu":
-		case "limits.memory":
-		case "requests.cpu":
-		case "requests.memory":
-		default:
-			delete(listUsed, key)
+	for key, value := range rq.Status.Used {
+		if _, found := rq.Status.Hard[key]; found {
+			continue
+		}
+		name := key
+		if alias, found := quotaAliases[key]; found {
+			name = alias
+		}
+		if _, found := used[name]; !found {
+			used[name] = value
 		}
 	}
-	rq.Status.Used = listUsed
+	rq.Status.Hard = hard
+	rq.Status.Used = used
 	return rq
 }
 
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,6 +13,11 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 )
 
+// scaledBy returns an item that needs the resources to scale
+func scaledBy(needed corev1.ResourceList) []g.ScalingInfo {
+	return []g.ScalingInfo{{SpecReplica: 0, DesiredReplicas: 1, ResourceList: needed}}
+}
+
 func Test_isAllowed(t *testing.T) {
 	type args struct {
 		rq           *corev1.ResourceQuotaList
@@ -64,7 +70,7 @@ func Test_isAllowed(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, got, err := isAllowed(tt.args.rq, tt.args.limitsneeded)
+			_, _, got, err := isAllowed(tt.args.rq, scaledBy(tt.args.limitsneeded))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("isAllowed() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -139,7 +145,7 @@ func TestResourceQuotaCheck(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, got, err := ResourceQuotaCheck(tt.args.ctx, tt.args.namespace, tt.args.limitsneeded)
+			_, _, got, err := ResourceQuotaCheck(tt.args.ctx, tt.args.namespace, scaledBy(tt.args.limitsneeded))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ResourceQuotaCheck() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -150,3 +156,140 @@ func TestResourceQuotaCheck(t *testing.T) {
 		})
 	}
 }
+
+func Test_onlyFrees(t *testing.T) {
+	item := func(current int32, desired int32, cpu string, memory string) g.ScalingInfo {
+		return g.ScalingInfo{SpecReplica: current, DesiredReplicas: desired, ResourceList: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+			corev1.ResourcePods:   resource.MustParse("1"),
+		}}
+	}
+	tests := []struct {
+		name  string
+		items []g.ScalingInfo
+		want  bool
+	}{
+		{"TestScaleToZero", []g.ScalingInfo{item(3, 0, "1", "1Gi")}, true},
+		{"TestNoChange", []g.ScalingInfo{item(3, 3, "1", "1Gi")}, true},
+		{"TestScaleUp", []g.ScalingInfo{item(0, 2, "1", "1Gi")}, false},
+		{"TestScaleToZeroMakesRoom", []g.ScalingInfo{item(3, 0, "1", "1Gi"), item(0, 2, "1", "1Gi")}, true},
+		{"TestFreedMemoryDoesntPayForCPU", []g.ScalingInfo{item(3, 0, "100m", "4Gi"), item(0, 1, "2", "1Gi")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := onlyFrees(tt.items); got != tt.want {
+				t.Errorf("onlyFrees() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_headroom(t *testing.T) {
+	quota := func(hard, used string) corev1.ResourceQuota {
+		return corev1.ResourceQuota{
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse(hard)},
+				Used: corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse(used)},
+			},
+		}
+	}
+	rql := &corev1.ResourceQuotaList{
+		Items: []corev1.ResourceQuota{quota("4", "1"), quota("2", "1")},
+	}
+	needed := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}
+
+	got := headroom(rql, scaledBy(needed), true)
+	left := got[corev1.ResourceLimitsCPU]
+	if left.Cmp(resource.MustParse("500m")) != 0 {
+		t.Errorf("headroom() = %s, want %s", left.String(), "500m")
+	}
+
+	if headroom(&corev1.ResourceQuotaList{}, scaledBy(needed), true) != nil {
+		t.Errorf("headroom() without ResourceQuotas should be nil")
+	}
+}
+
+func Test_isAllowedChecksRequestsAndPods(t *testing.T) {
+	quota := func(name corev1.ResourceName, hard, used string) *corev1.ResourceQuotaList {
+		return &corev1.ResourceQuotaList{
+			Items: []corev1.ResourceQuota{{
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{name: resource.MustParse(hard)},
+					Used: corev1.ResourceList{name: resource.MustParse(used)},
+				},
+			}},
+		}
+	}
+	needed := corev1.ResourceList{
+		corev1.ResourceRequestsCPU: resource.MustParse("500m"),
+		corev1.ResourcePods:        resource.MustParse("2"),
+	}
+
+	tests := []struct {
+		name string
+		rq   *corev1.ResourceQuotaList
+		want bool
+	}{
+		{name: "TestRequestsWithinQuota", rq: quota(corev1.ResourceRequestsCPU, "2", "1"), want: true},
+		{name: "TestRequestsExceedQuota", rq: quota(corev1.ResourceRequestsCPU, "2", "1800m"), want: false},
+		{name: "TestPodsExceedQuota", rq: quota(corev1.ResourcePods, "10", "9"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, got, err := isAllowed(tt.rq, scaledBy(needed))
+			if err != nil {
+				t.Errorf("isAllowed() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("isAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isAllowedExtendedResources(t *testing.T) {
+	quota := func(name corev1.ResourceName, hard, used string) *corev1.ResourceQuotaList {
+		return &corev1.ResourceQuotaList{
+			Items: []corev1.ResourceQuota{{
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{name: resource.MustParse(hard)},
+					Used: corev1.ResourceList{name: resource.MustParse(used)},
+				},
+			}},
+		}
+	}
+	needed := corev1.ResourceList{
+		"requests.nvidia.com/gpu":               resource.MustParse("2"),
+		corev1.ResourceRequestsCPU:              resource.MustParse("1"),
+		corev1.ResourceRequestsEphemeralStorage: resource.MustParse("2Gi"),
+		corev1.ResourcePods:                     resource.MustParse("2"),
+	}
+
+	tests := []struct {
+		name string
+		rq   *corev1.ResourceQuotaList
+		want bool
+	}{
+		{name: "TestGPUWithinQuota", rq: quota("requests.nvidia.com/gpu", "4", "2"), want: true},
+		{name: "TestGPUExceedsQuota", rq: quota("requests.nvidia.com/gpu", "4", "3"), want: false},
+		{name: "TestEphemeralStorageExceedsQuota", rq: quota(corev1.ResourceRequestsEphemeralStorage, "10Gi", "9Gi"), want: false},
+		{name: "TestPlainCPUCountsAsRequests", rq: quota(corev1.ResourceCPU, "2", "1500m"), want: false},
+		{name: "TestPodCountExceedsQuota", rq: quota("count/pods", "5", "4"), want: false},
+		{name: "TestUntrackedResourcesAreIgnored", rq: quota(corev1.ResourceLimitsMemory, "1Gi", "1Gi"), want: true},
+		{name: "TestUnrelatedObjectCountsAreIgnored", rq: quota("count/configmaps", "1", "2"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, got, err := isAllowed(tt.rq, scaledBy(needed))
+			if err != nil {
+				t.Errorf("isAllowed() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("isAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
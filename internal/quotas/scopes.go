@@ -0,0 +1,66 @@
+package quotas
+
+import (
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// matchesScopes tells if the pods of the item are tracked by the ResourceQuota. A quota without scopes tracks every pod.
+// With scopes, the pods have to match all of them, like the quota admission does it.
+func matchesScopes(rq corev1.ResourceQuota, item g.ScalingInfo) bool {
+	for _, scope := range rq.Spec.Scopes {
+		if !matchesScope(corev1.ScopedResourceSelectorRequirement{ScopeName: scope, Operator: corev1.ScopeSelectorOpExists}, item) {
+			return false
+		}
+	}
+	if rq.Spec.ScopeSelector != nil {
+		for _, requirement := range rq.Spec.ScopeSelector.MatchExpressions {
+			if !matchesScope(requirement, item) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesScope(requirement corev1.ScopedResourceSelectorRequirement, item g.ScalingInfo) bool {
+	switch requirement.ScopeName {
+	case corev1.ResourceQuotaScopeTerminating:
+		return item.PodTemplate.ActiveDeadlineSeconds != nil && *item.PodTemplate.ActiveDeadlineSeconds >= 0
+	case corev1.ResourceQuotaScopeNotTerminating:
+		return item.PodTemplate.ActiveDeadlineSeconds == nil || *item.PodTemplate.ActiveDeadlineSeconds < 0
+	case corev1.ResourceQuotaScopeBestEffort:
+		return isBestEffort(item)
+	case corev1.ResourceQuotaScopeNotBestEffort:
+		return !isBestEffort(item)
+	case corev1.ResourceQuotaScopePriorityClass:
+		priorityClassName := item.PodTemplate.PriorityClassName
+		switch requirement.Operator {
+		case corev1.ScopeSelectorOpIn:
+			return contains(requirement.Values, priorityClassName)
+		case corev1.ScopeSelectorOpNotIn:
+			return !contains(requirement.Values, priorityClassName)
+		case corev1.ScopeSelectorOpExists:
+			return priorityClassName != ""
+		case corev1.ScopeSelectorOpDoesNotExist:
+			return priorityClassName == ""
+		}
+	}
+	// Scopes the operator doesn't know are assumed to match. That may block a scale-up, but never lets one exceed a quota.
+	return true
+}
+
+// isBestEffort tells if the pods of the item have the BestEffort QoS class, meaning no container sets cpu or memory
+func isBestEffort(item g.ScalingInfo) bool {
+	for _, name := range []corev1.ResourceName{
+		corev1.ResourceRequestsCPU,
+		corev1.ResourceRequestsMemory,
+		corev1.ResourceLimitsCPU,
+		corev1.ResourceLimitsMemory,
+	} {
+		if quantity, found := item.ResourceList[name]; found && !quantity.IsZero() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package quotas
+
+import (
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_matchesScopes(t *testing.T) {
+	deadline := int64(60)
+	burstable := g.ScalingInfo{
+		PodTemplate:  corev1.PodSpec{PriorityClassName: "high"},
+		ResourceList: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1"), corev1.ResourcePods: resource.MustParse("1")},
+	}
+	bestEffort := g.ScalingInfo{
+		PodTemplate:  corev1.PodSpec{ActiveDeadlineSeconds: &deadline},
+		ResourceList: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")},
+	}
+	scoped := func(scopes ...corev1.ResourceQuotaScope) corev1.ResourceQuota {
+		return corev1.ResourceQuota{Spec: corev1.ResourceQuotaSpec{Scopes: scopes}}
+	}
+	selected := func(operator corev1.ScopeSelectorOperator, values ...string) corev1.ResourceQuota {
+		return corev1.ResourceQuota{Spec: corev1.ResourceQuotaSpec{ScopeSelector: &corev1.ScopeSelector{
+			MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+				ScopeName: corev1.ResourceQuotaScopePriorityClass,
+				Operator:  operator,
+				Values:    values,
+			}},
+		}}}
+	}
+
+	tests := []struct {
+		name           string
+		rq             corev1.ResourceQuota
+		wantBurstable  bool
+		wantBestEffort bool
+	}{
+		{name: "TestNoScopes", rq: scoped(), wantBurstable: true, wantBestEffort: true},
+		{name: "TestBestEffort", rq: scoped(corev1.ResourceQuotaScopeBestEffort), wantBurstable: false, wantBestEffort: true},
+		{name: "TestNotBestEffort", rq: scoped(corev1.ResourceQuotaScopeNotBestEffort), wantBurstable: true, wantBestEffort: false},
+		{name: "TestTerminating", rq: scoped(corev1.ResourceQuotaScopeTerminating), wantBurstable: false, wantBestEffort: true},
+		{name: "TestAllScopesMustMatch", rq: scoped(corev1.ResourceQuotaScopeNotTerminating, corev1.ResourceQuotaScopeBestEffort), wantBurstable: false, wantBestEffort: false},
+		{name: "TestPriorityClassIn", rq: selected(corev1.ScopeSelectorOpIn, "high"), wantBurstable: true, wantBestEffort: false},
+		{name: "TestPriorityClassNotIn", rq: selected(corev1.ScopeSelectorOpNotIn, "high"), wantBurstable: false, wantBestEffort: true},
+		{name: "TestPriorityClassExists", rq: selected(corev1.ScopeSelectorOpExists), wantBurstable: true, wantBestEffort: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesScopes(tt.rq, burstable); got != tt.wantBurstable {
+				t.Errorf("matchesScopes() burstable = %v, want %v", got, tt.wantBurstable)
+			}
+			if got := matchesScopes(tt.rq, bestEffort); got != tt.wantBestEffort {
+				t.Errorf("matchesScopes() best effort = %v, want %v", got, tt.wantBestEffort)
+			}
+		})
+	}
+}
+
+func Test_isAllowedOnlyChargesMatchingItems(t *testing.T) {
+	rql := &corev1.ResourceQuotaList{
+		Items: []corev1.ResourceQuota{{
+			Spec: corev1.ResourceQuotaSpec{
+				ScopeSelector: &corev1.ScopeSelector{
+					MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+						ScopeName: corev1.ResourceQuotaScopePriorityClass,
+						Operator:  corev1.ScopeSelectorOpIn,
+						Values:    []string{"high"},
+					}},
+				},
+			},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("2")},
+				Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")},
+			},
+		}},
+	}
+	item := func(priorityClassName string) g.ScalingInfo {
+		return g.ScalingInfo{
+			SpecReplica:     0,
+			DesiredReplicas: 3,
+			PodTemplate:     corev1.PodSpec{PriorityClassName: priorityClassName},
+			ResourceList:    corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")},
+		}
+	}
+
+	if _, _, allowed, _ := isAllowed(rql, []g.ScalingInfo{item("low")}); !allowed {
+		t.Errorf("isAllowed() for an item outside the scope = %v, want %v", allowed, true)
+	}
+	if _, _, allowed, _ := isAllowed(rql, []g.ScalingInfo{item("low"), item("high")}); allowed {
+		t.Errorf("isAllowed() for an item in the scope = %v, want %v", allowed, false)
+	}
+}
@@ -0,0 +1,121 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	"github.com/containersol/prescale-operator/internal/resources"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// knownDrifts remembers the drift of the items whose replicas are left alone. A drift is only reported when it changes,
+// and the desired replicas it was detected with tell a new state of the item apart from the drift.
+var knownDrifts = struct {
+	sync.Mutex
+	items map[scalingKey]v1alpha1.ReplicaDrift
+}{items: make(map[scalingKey]v1alpha1.ReplicaDrift)}
+
+// handleDrift decides if the replicas of the item, which differ from its desired replicas, are left as they are.
+// That's the case if the enforcement mode of the item is warn or ignore, the replicas weren't set by the operator
+// and the desired replicas didn't change since the drift was detected.
+// With warn, a new drift is reported with an event, a metric and an entry on the status of the ClusterScalingState.
+func handleDrift(ctx context.Context, _client client.Client, item g.ScalingInfo, recorder record.EventRecorder) bool {
+	mode := resources.Enforcement(item)
+	if mode == resources.EnforcementEnforce || g.GetDenyList().IsInConcurrentList(item) {
+		forgetDrift(ctx, _client, item)
+		return false
+	}
+	obj, err := resources.ScalingItemObject(ctx, _client, item)
+	if err != nil {
+		return false
+	}
+	actor := resources.ReplicaActor(obj)
+	if actor == resources.OperatorFieldManager {
+		// The replicas are the operator's, for example after the state replicas of the item changed
+		forgetDrift(ctx, _client, item)
+		return false
+	}
+
+	drift := v1alpha1.ReplicaDrift{
+		Name:            item.Name,
+		Namespace:       item.Namespace,
+		Kind:            item.ScalingItemType.ItemTypeName,
+		Replicas:        item.SpecReplica,
+		DesiredReplicas: item.DesiredReplicas,
+		Actor:           actor,
+		DetectedTime:    metav1.Now(),
+	}
+	previous, known := knownDrift(ctx, _client, item, mode)
+	if known && previous.DesiredReplicas != drift.DesiredReplicas {
+		// The desired replicas changed since the drift was detected, for example as the state changed. They're applied.
+		forgetDrift(ctx, _client, item)
+		return false
+	}
+	if known && previous.Replicas == drift.Replicas && previous.Actor == drift.Actor {
+		return true
+	}
+	rememberDrift(item, drift)
+	if mode == resources.EnforcementIgnore {
+		return true
+	}
+
+	log := ctrl.Log.
+		WithValues("Name", item.Name).
+		WithValues("Namespace", item.Namespace).
+		WithValues("Actor", actor)
+	log.Info(fmt.Sprintf("Replicas were changed to %d. Not reverting them to %d, as the enforcement mode is warn", item.SpecReplica, item.DesiredReplicas))
+
+	changedBy := actor
+	if changedBy == "" {
+		changedBy = "unknown"
+	}
+	recorder.Event(obj, "Warning", "ReplicaDrift", fmt.Sprintf("Replicas were changed to %d by %s. The %s state wants %d replicas. Not reverting them, as the enforcement mode is warn", item.SpecReplica, changedBy, item.State, item.DesiredReplicas))
+	metrics.IncReplicaDrifts(item, changedBy)
+	if err := SetDriftedItemOnStatus(ctx, _client, item, &drift); err != nil {
+		log.Error(err, "Failed to put the replica drift on the status of the ClusterScalingState")
+	}
+	return true
+}
+
+// knownDrift returns the drift detected before for the item. After a restart, a drift reported with warn is taken from
+// the status of the ClusterScalingState.
+func knownDrift(ctx context.Context, _client client.Client, item g.ScalingInfo, mode string) (v1alpha1.ReplicaDrift, bool) {
+	knownDrifts.Lock()
+	drift, known := knownDrifts.items[keyForItem(item)]
+	knownDrifts.Unlock()
+	if known || mode != resources.EnforcementWarn {
+		return drift, known
+	}
+	reported, err := driftedItemOnStatus(ctx, _client, item)
+	if err != nil || reported == nil {
+		return drift, false
+	}
+	rememberDrift(item, *reported)
+	return *reported, true
+}
+
+func rememberDrift(item g.ScalingInfo, drift v1alpha1.ReplicaDrift) {
+	knownDrifts.Lock()
+	defer knownDrifts.Unlock()
+	knownDrifts.items[keyForItem(item)] = drift
+}
+
+// forgetDrift removes the item from the drifted items once the operator manages its replicas again
+func forgetDrift(ctx context.Context, _client client.Client, item g.ScalingInfo) {
+	knownDrifts.Lock()
+	delete(knownDrifts.items, keyForItem(item))
+	knownDrifts.Unlock()
+
+	if err := SetDriftedItemOnStatus(ctx, _client, item, nil); err != nil {
+		ctrl.Log.WithValues("Name", item.Name).
+			WithValues("Namespace", item.Namespace).
+			Error(err, "Failed to remove the replica drift from the status of the ClusterScalingState")
+	}
+}
@@ -0,0 +1,146 @@
+package reconciler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleDrift(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	deployment := func(manager string) *v1.Deployment {
+		return &v1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "shop",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl-client-side-apply", Operation: metav1.ManagedFieldsOperationUpdate, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{}}}`)}},
+				{Manager: manager, Operation: metav1.ManagedFieldsOperationUpdate, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}},
+			},
+		}}
+	}
+	css := &v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "bau"}}
+	item := func(mode string) g.ScalingInfo {
+		return g.ScalingInfo{
+			Name:            "web",
+			Namespace:       "shop",
+			Annotations:     map[string]string{"scaler/enforcement": mode},
+			ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+			State:           "bau",
+			SpecReplica:     5,
+			DesiredReplicas: 2,
+		}
+	}
+	driftedItems := func(_client client.Client) []v1alpha1.ReplicaDrift {
+		current := v1alpha1.ClusterScalingState{}
+		_ = _client.Get(context.TODO(), client.ObjectKey{Name: "default"}, &current)
+		return current.Status.DriftedItems
+	}
+
+	_client := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment("kubectl"), css.DeepCopy()).Build()
+	recorder := record.NewFakeRecorder(10)
+	if !handleDrift(context.TODO(), _client, item("warn"), recorder) {
+		t.Fatalf("handleDrift() with warn = false, want the replicas left alone")
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ReplicaDrift") || !strings.Contains(event, "changed to 5 by kubectl") {
+		t.Errorf("handleDrift() with warn recorded %q, want a ReplicaDrift event naming kubectl", event)
+	}
+	drifted := driftedItems(_client)
+	if len(drifted) != 1 || drifted[0].Actor != "kubectl" || drifted[0].Replicas != 5 || drifted[0].DesiredReplicas != 2 {
+		t.Errorf("handleDrift() with warn put %+v on the status, want the drift of web", drifted)
+	}
+
+	// The same drift is only reported once
+	if !handleDrift(context.TODO(), _client, item("warn"), recorder) || len(recorder.Events) != 0 {
+		t.Errorf("handleDrift() reported the same drift again")
+	}
+	if again := driftedItems(_client); len(again) != 1 || !again[0].DetectedTime.Equal(&drifted[0].DetectedTime) {
+		t.Errorf("handleDrift() replaced the drift entry with %+v", again)
+	}
+	// A new state of the item is applied
+	newState := item("warn")
+	newState.DesiredReplicas = 6
+	if handleDrift(context.TODO(), _client, newState, recorder) {
+		t.Errorf("handleDrift() after the desired replicas changed = true, want them applied")
+	}
+	if drifted := driftedItems(_client); len(drifted) != 0 {
+		t.Errorf("handleDrift() after the desired replicas changed left %+v on the status", drifted)
+	}
+	if !handleDrift(context.TODO(), _client, item("warn"), recorder) {
+		t.Fatalf("handleDrift() with warn = false, want the replicas left alone")
+	}
+	<-recorder.Events
+
+	// Enforcing again reverts the replicas and forgets the drift
+	if handleDrift(context.TODO(), _client, item("enforce"), recorder) {
+		t.Errorf("handleDrift() with enforce = true, want the replicas reverted")
+	}
+	if drifted := driftedItems(_client); len(drifted) != 0 {
+		t.Errorf("handleDrift() with enforce left %+v on the status", drifted)
+	}
+
+	if !handleDrift(context.TODO(), _client, item("ignore"), recorder) || len(recorder.Events) != 0 || len(driftedItems(_client)) != 0 {
+		t.Errorf("handleDrift() with ignore didn't leave the replicas alone silently")
+	}
+
+	// Replicas the operator set itself are no drift, for example after the state replicas of the item changed
+	_client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment(resources.OperatorFieldManager), css.DeepCopy()).Build()
+	if handleDrift(context.TODO(), _client, item("warn"), recorder) {
+		t.Errorf("handleDrift() of replicas set by the operator = true, want them reconciled")
+	}
+}
+
+func TestReconcileNamespaceLeavesDriftAlone(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	deployment := &v1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "web",
+		Namespace: "drift",
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}},
+		},
+	}}
+	css := &v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "bau"}}
+	_client := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment, css).Build()
+	e := NewScalingExecutor(_client, record.NewFakeRecorder(10), 1, DefaultMaxScalingAttempts)
+	recorder := record.NewFakeRecorder(10)
+
+	item := g.ScalingInfo{
+		Name:            "web",
+		Namespace:       "drift",
+		Annotations:     map[string]string{"scaler/enforcement": "warn"},
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+		State:           "bau",
+		SpecReplica:     5,
+		DesiredReplicas: 2,
+	}
+	defer forgetDrift(context.TODO(), _client, item)
+
+	// A change of the ClusterScalingState, like the status write of the drift, reconciles the namespace again
+	for i := 0; i < 2; i++ {
+		ReconcileNamespace(context.TODO(), _client, "drift", []g.ScalingInfo{item}, states.State{Name: "bau"}, recorder, false)
+		if e.Len() != 0 {
+			t.Fatalf("ReconcileNamespace() enqueued the drifted item, want its replicas left alone")
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("ReconcileNamespace() recorded %d events, want the drift reported once", len(recorder.Events))
+	}
+
+	// A new state is applied
+	item.State = "peak"
+	item.DesiredReplicas = 6
+	ReconcileNamespace(context.TODO(), _client, "drift", []g.ScalingInfo{item}, states.State{Name: "peak"}, recorder, false)
+	if e.Len() != 1 {
+		t.Errorf("ReconcileNamespace() didn't enqueue the item for its new state")
+	}
+}
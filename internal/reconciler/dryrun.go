@@ -0,0 +1,83 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/resources"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// NewDryRunReport collects the dry run results of all namespaces and the capacity check, if there is one, into one report
+func NewDryRunReport(source string, nsInfos map[string]NamespaceInfo, capacity *v1alpha1.ClusterCapacity) resources.DryRunReport {
+	report := resources.DryRunReport{
+		Source:     source,
+		Namespaces: []resources.NamespaceReport{},
+		Capacity:   capacity,
+	}
+	for _, nsInfo := range nsInfos {
+		if nsInfo.DryRunReport != nil {
+			report.Namespaces = append(report.Namespaces, *nsInfo.DryRunReport)
+		}
+	}
+	report.SortNamespaceReports()
+	return report
+}
+
+// DryRunReportName is the name of the ConfigMap holding the dry run report of a custom resource
+func DryRunReportName(kind string, name string) string {
+	return fmt.Sprintf("prescale-dry-run-%s-%s", strings.ToLower(kind), name)
+}
+
+// OperatorNamespace returns the namespace the operator runs in
+func OperatorNamespace() string {
+	if namespace := os.Getenv(constants.EnvOperatorNamespace); namespace != "" {
+		return namespace
+	}
+	return constants.DefaultOperatorNamespace
+}
+
+// WriteDryRunReport creates or updates the ConfigMap with the report as JSON. The ConfigMap is read with the reader,
+// like the API reader of the manager, so the cache of the operator doesn't have to watch all ConfigMaps.
+func WriteDryRunReport(ctx context.Context, _client client.Client, reader client.Reader, namespace string, name string, report resources.DryRunReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+		setDryRunReport(cm, data)
+		return _client.Create(ctx, cm)
+	}
+	if err != nil {
+		return err
+	}
+	setDryRunReport(cm, data)
+	return _client.Update(ctx, cm)
+}
+
+func setDryRunReport(cm *corev1.ConfigMap, data []byte) {
+	if cm.Labels == nil {
+		cm.Labels = make(map[string]string)
+	}
+	cm.Labels["app.kubernetes.io/managed-by"] = "pre-scaling-operator"
+	cm.Data = map[string]string{constants.DryRunReportKey: string(data)}
+}
@@ -0,0 +1,51 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWriteDryRunReport(t *testing.T) {
+	_client := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	nsInfos := map[string]NamespaceInfo{
+		"b": {DryRunReport: &resources.NamespaceReport{Namespace: "b", QuotaAllowed: true}},
+		"a": {DryRunReport: &resources.NamespaceReport{Namespace: "a", QuotaAllowed: true}},
+		"c": {},
+	}
+	capacity := &v1alpha1.ClusterCapacity{Fits: false, NewPods: 3, UnschedulablePods: 1, Shortfall: map[string]string{"cpu": "500m"}}
+	name := DryRunReportName("ClusterScalingState", "peak")
+
+	// Writing twice updates the existing ConfigMap
+	for i := 0; i < 2; i++ {
+		err := WriteDryRunReport(context.TODO(), _client, _client, "default", name, NewDryRunReport("ClusterScalingState/peak", nsInfos, capacity))
+		if err != nil {
+			t.Fatalf("Failed to write the dry run report: %s", err)
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := _client.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "prescale-dry-run-clusterscalingstate-peak"}, cm)
+	if err != nil {
+		t.Fatalf("Dry run report ConfigMap not found: %s", err)
+	}
+
+	report := resources.DryRunReport{}
+	if err := json.Unmarshal([]byte(cm.Data[constants.DryRunReportKey]), &report); err != nil {
+		t.Fatalf("Report is not valid JSON: %s", err)
+	}
+	if len(report.Namespaces) != 2 || report.Namespaces[0].Namespace != "a" {
+		t.Errorf("Expected the reports of namespaces a and b in order. Got %+v", report.Namespaces)
+	}
+	if report.Capacity == nil || report.Capacity.UnschedulablePods != 1 {
+		t.Errorf("Expected the capacity check in the report. Got %+v", report.Capacity)
+	}
+}
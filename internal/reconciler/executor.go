@@ -0,0 +1,350 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	"github.com/containersol/prescale-operator/internal/notify"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/tracing"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultScalingWorkers is the number of items the executor scales in parallel if nothing else is configured
+	DefaultScalingWorkers = 20
+
+	// DefaultMaxScalingAttempts is the number of times an item is tried before the operator gives up on it
+	DefaultMaxScalingAttempts = 10
+
+	// Backoff boundaries for retrying items which failed to scale
+	failureBaseDelay = 5 * time.Second
+	failureMaxDelay  = 5 * time.Minute
+
+	// How often items waiting for the ResourceQuota to grant them replicas are checked again
+	quotaWaitPeriod = 30 * time.Second
+)
+
+// Global executor all controllers hand their scaling work to
+var executor *ScalingExecutor
+
+// scalingKey identifies one scaling item on the queue. The workqueue makes sure
+// that the same key is never processed by two workers at the same time.
+type scalingKey struct {
+	namespace string
+	name      string
+	kind      string
+}
+
+// scalingTask is the latest piece of work requested for a scalingKey
+type scalingTask struct {
+	item       g.ScalingInfo
+	reconcile  bool
+	origin     string
+	generation uint64
+	// Span of the transition that requested the task, so the scaling shows up in the same trace
+	parent trace.SpanContext
+}
+
+// ScalingExecutor runs all scaling work on a rate-limited workqueue.
+// It is started by the manager, so it stops on shutdown or leader loss and cancels the scalers through the context.
+type ScalingExecutor struct {
+	client      client.Client
+	recorder    record.EventRecorder
+	workers     int
+	maxAttempts int
+	rateLimiter workqueue.RateLimiter
+	queue       workqueue.RateLimitingInterface
+
+	mu         sync.Mutex
+	tasks      map[scalingKey]scalingTask
+	gaveUp     map[scalingKey]bool
+	generation uint64
+}
+
+// NewScalingExecutor creates the global scaling executor. It needs to be added to the manager in order to start processing.
+func NewScalingExecutor(_client client.Client, recorder record.EventRecorder, workers int, maxAttempts int) *ScalingExecutor {
+	if workers <= 0 {
+		workers = DefaultScalingWorkers
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxScalingAttempts
+	}
+	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(failureBaseDelay, failureMaxDelay)
+
+	e := &ScalingExecutor{
+		client:      _client,
+		recorder:    recorder,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		rateLimiter: rateLimiter,
+		queue:       workqueue.NewNamedRateLimitingQueue(rateLimiter, "scaling-executor"),
+		tasks:       make(map[scalingKey]scalingTask),
+		gaveUp:      make(map[scalingKey]bool),
+	}
+
+	executor = e
+	return e
+}
+
+// GetScalingExecutor returns the global scaling executor. Work is only processed once the executor is started.
+// It panics if NewScalingExecutor wasn't called, as work handed to an executor nobody starts would be lost silently.
+func GetScalingExecutor() *ScalingExecutor {
+	if executor == nil {
+		panic("the scaling executor is not set up, NewScalingExecutor has to be called and the executor added to the manager")
+	}
+	return executor
+}
+
+// EnqueueReconcile queues the item to determine its desired replicas and scale it accordingly
+func (e *ScalingExecutor) EnqueueReconcile(ctx context.Context, item g.ScalingInfo, origin string) {
+	e.enqueue(scalingTask{item: item, reconcile: true, origin: origin, parent: trace.SpanContextFromContext(ctx)})
+}
+
+// EnqueueScale queues the item to be scaled to its already determined desired replicas
+func (e *ScalingExecutor) EnqueueScale(ctx context.Context, item g.ScalingInfo, origin string) {
+	e.enqueue(scalingTask{item: item, reconcile: false, origin: origin, parent: trace.SpanContextFromContext(ctx)})
+}
+
+func (e *ScalingExecutor) enqueue(task scalingTask) {
+	key := keyForItem(task.item)
+
+	e.mu.Lock()
+	e.generation++
+	task.generation = e.generation
+	// A newer request for the same item replaces the pending one
+	e.tasks[key] = task
+	e.mu.Unlock()
+
+	e.queue.Add(key)
+}
+
+// Len returns the number of distinct items waiting on the queue
+func (e *ScalingExecutor) Len() int {
+	return e.queue.Len()
+}
+
+// Start runs the workers until the context is cancelled. It implements manager.Runnable.
+func (e *ScalingExecutor) Start(ctx context.Context) error {
+	defer e.queue.ShutDown()
+
+	log := ctrl.Log.WithName("ScalingExecutor")
+	log.WithValues("workers", e.workers).Info("Starting scaling executor")
+
+	for i := 0; i < e.workers; i++ {
+		go wait.UntilWithContext(ctx, e.worker, time.Second)
+	}
+
+	<-ctx.Done()
+	log.Info("Stopping scaling executor")
+	return nil
+}
+
+// NeedLeaderElection makes sure only the leader scales. It implements manager.LeaderElectionRunnable.
+func (e *ScalingExecutor) NeedLeaderElection() bool {
+	return true
+}
+
+func (e *ScalingExecutor) worker(ctx context.Context) {
+	for e.processNextItem(ctx) {
+	}
+}
+
+func (e *ScalingExecutor) processNextItem(ctx context.Context) bool {
+	obj, shutdown := e.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer e.queue.Done(obj)
+
+	key := obj.(scalingKey)
+	e.mu.Lock()
+	task, found := e.tasks[key]
+	e.mu.Unlock()
+	// Items the operator gave up on are only picked up again after a manual retry
+	if !found || g.GetDenyList().HasGivenUp(task.item) {
+		e.queue.Forget(key)
+		e.finishTask(key, task)
+		return true
+	}
+
+	// A manual retry took the item off the deny list, so it's not given up on anymore
+	e.clearGaveUp(ctx, key, task.item)
+
+	attempts := e.queue.NumRequeues(key) + 1
+
+	spanCtx := ctx
+	if task.parent.IsValid() {
+		spanCtx = trace.ContextWithSpanContext(ctx, task.parent)
+	}
+	spanCtx, span := tracing.Start(spanCtx, "ScaleItem", tracing.ItemAttributes(task.item)...)
+	span.SetAttributes(
+		tracing.OriginKey.String(task.origin),
+		attribute.Bool("prescale.reconcile", task.reconcile),
+		attribute.Int("prescale.attempt", attempts),
+	)
+	err := e.process(spanCtx, task)
+	tracing.End(span, err)
+
+	log := ctrl.Log.
+		WithName("ScalingExecutor").
+		WithValues("Name", key.name).
+		WithValues("Namespace", key.namespace).
+		WithValues("Object", key.kind)
+
+	switch {
+	case ctx.Err() != nil:
+		// Shutting down or lost leadership. The next leader picks the item up again on startup.
+		return false
+	case err == nil || apierrors.IsNotFound(err):
+		e.queue.Forget(key)
+		e.finishTask(key, task)
+		// The item leaves the transition it was enqueued for once it's scaled or given up on.
+		// Items retried with backoff are still part of it.
+		finishTransitionItem(task.item)
+	case errors.Is(err, errQuotaWait):
+		// Waiting for the quota doesn't count as an attempt. The item is still part of its transition.
+		log.Info(fmt.Sprintf("Waiting for the ResourceQuota | Checking again in %s", quotaWaitPeriod.String()))
+		e.queue.Forget(key)
+		e.retryTask(key, task)
+		e.queue.AddAfter(key, quotaWaitPeriod)
+	case attempts >= e.maxAttempts:
+		log.WithValues("Attempts", attempts).
+			Error(err, "Scaling failed too many times. Giving up until a manual retry is requested")
+		e.queue.Forget(key)
+		e.finishTask(key, task)
+		e.giveUp(ctx, key, task.item, int32(attempts), err)
+		finishTransitionItem(task.item)
+	default:
+		backoff := e.rateLimiter.When(key)
+		log.WithValues("Attempt", attempts).
+			WithValues("MaxAttempts", e.maxAttempts).
+			WithValues("Backoff", backoff.String()).
+			Info(fmt.Sprintf("Scaling failed: %s | Retrying with backoff", err.Error()))
+		e.setRetryAttempts(task.item, int32(attempts))
+		e.retryTask(key, task)
+		e.queue.AddAfter(key, backoff)
+	}
+	return true
+}
+
+// process decides what to do with an item. Paused items are skipped, items in failure state are rectified,
+// all others reconciled or scaled.
+func (e *ScalingExecutor) process(ctx context.Context, task scalingTask) error {
+	if reason, paused := resources.Paused(ctx, e.client, task.item); paused {
+		// Nothing is retried while paused. Unpausing reconciles the item, failed items keep their attempts for that.
+		ctrl.Log.WithName("ScalingExecutor").
+			WithValues("Name", task.item.Name).
+			WithValues("Namespace", task.item.Namespace).
+			Info("Skipping item. Scaling is paused by " + reason)
+		g.GetDenyList().RemoveUnlessFailed(task.item)
+		return nil
+	}
+	if g.GetDenyList().IsDeploymentInFailureState(task.item) {
+		item, _ := g.GetDenyList().GetDeploymentInfoFromList(task.item)
+		return RectifyScalingItem(ctx, e.client, item, e.recorder)
+	}
+	if task.reconcile {
+		return ReconcileScalingItem(ctx, e.client, task.item, false, e.recorder, task.origin)
+	}
+	return resources.ScaleOrStepScale(ctx, e.client, task.item, task.origin, e.recorder)
+}
+
+// finishTransitionItem takes the item off the transition of its namespace and publishes the end of the transition,
+// if the item was the last one
+func finishTransitionItem(item g.ScalingInfo) {
+	if transition, finished := metrics.ItemFinished(item); finished {
+		notify.TransitionFinished(transition.Namespace, transition.State, notify.Classes(transition.Items), transition.Duration)
+	}
+}
+
+// finishTask forgets the task unless a newer one for the same item arrived while it was processed
+func (e *ScalingExecutor) finishTask(key scalingKey, task scalingTask) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if current, found := e.tasks[key]; found && current.generation == task.generation {
+		delete(e.tasks, key)
+	}
+}
+
+// retryTask turns a failed task into a full reconcile, so the retry works with fresh states and replicas
+func (e *ScalingExecutor) retryTask(key scalingKey, task scalingTask) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if current, found := e.tasks[key]; found && current.generation == task.generation {
+		current.reconcile = true
+		e.tasks[key] = current
+	}
+}
+
+// setRetryAttempts keeps track of the attempts on the item, in case it's still on the deny list
+func (e *ScalingExecutor) setRetryAttempts(item g.ScalingInfo, attempts int32) {
+	itemFromList, notFoundErr := g.GetDenyList().GetDeploymentInfoFromList(item)
+	if notFoundErr != nil {
+		return
+	}
+	itemFromList.RetryAttempts = attempts
+	g.GetDenyList().Update(itemFromList)
+}
+
+// giveUp puts the item in the terminal GaveUp state and tells about it via events and the ClusterScalingState status
+func (e *ScalingExecutor) giveUp(ctx context.Context, key scalingKey, item g.ScalingInfo, attempts int32, scaleErr error) {
+	itemFromList, _ := g.GetDenyList().GetDeploymentInfoFromList(item)
+	itemFromList = g.GetDenyList().SetGaveUp(itemFromList, attempts, scaleErr.Error())
+
+	e.mu.Lock()
+	e.gaveUp[key] = true
+	e.mu.Unlock()
+
+	resources.RegisterGaveUpEvent(ctx, e.client, e.recorder, itemFromList, scaleErr)
+	err := SetFailedItemOnStatus(ctx, e.client, itemFromList, &v1alpha1.ScalingItemFailure{
+		Name:       itemFromList.Name,
+		Namespace:  itemFromList.Namespace,
+		Kind:       itemFromList.ScalingItemType.ItemTypeName,
+		Attempts:   attempts,
+		Message:    scaleErr.Error(),
+		GaveUpTime: metav1.Now(),
+	})
+	if err != nil {
+		ctrl.Log.Error(err, "Failed to put the failed item on the ClusterScalingState status")
+	}
+}
+
+// clearGaveUp forgets that the operator gave up on the item and removes it from the status. A manual retry takes
+// the item off the deny list, which makes it processed again.
+func (e *ScalingExecutor) clearGaveUp(ctx context.Context, key scalingKey, item g.ScalingInfo) {
+	e.mu.Lock()
+	gaveUp := e.gaveUp[key]
+	delete(e.gaveUp, key)
+	e.mu.Unlock()
+
+	if !gaveUp {
+		return
+	}
+	err := SetFailedItemOnStatus(ctx, e.client, item, nil)
+	if err != nil {
+		ctrl.Log.Error(err, "Failed to remove the rectified item from the ClusterScalingState status")
+	}
+}
+
+func keyForItem(item g.ScalingInfo) scalingKey {
+	return scalingKey{
+		namespace: item.Namespace,
+		name:      item.Name,
+		kind:      item.ScalingItemType.ItemTypeName,
+	}
+}
@@ -0,0 +1,197 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/containersol/prescale-operator/internal/metrics"
+	"github.com/containersol/prescale-operator/internal/tracing"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScalingExecutorDeduplicatesItems(t *testing.T) {
+	e := NewScalingExecutor(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), record.NewFakeRecorder(10), 1, DefaultMaxScalingAttempts)
+
+	item := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "bar",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+		SpecReplica:     1,
+		DesiredReplicas: 2,
+	}
+	otherItem := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "baz",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+	}
+
+	e.EnqueueScale(context.TODO(), item, "UNIT TEST")
+	item.DesiredReplicas = 3
+	e.EnqueueReconcile(context.TODO(), item, "UNIT TEST")
+	e.EnqueueScale(context.TODO(), otherItem, "UNIT TEST")
+
+	if e.Len() != 2 {
+		t.Errorf("Items were not deduplicated on the queue. Expected %d, got %d", 2, e.Len())
+	}
+
+	task := e.tasks[keyForItem(item)]
+	if !task.reconcile || task.item.DesiredReplicas != 3 {
+		t.Errorf("The latest task for the item did not replace the pending one. Got %+v", task)
+	}
+}
+
+func TestScalingExecutorStopsOnCancel(t *testing.T) {
+	e := NewScalingExecutor(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), record.NewFakeRecorder(10), 2, DefaultMaxScalingAttempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- e.Start(ctx)
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Executor returned an error on shutdown: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Executor did not stop after the context was cancelled")
+	}
+
+	if !e.queue.ShuttingDown() {
+		t.Errorf("The queue was not shut down with the executor")
+	}
+}
+
+func TestScalingExecutorGivesUp(t *testing.T) {
+	e := NewScalingExecutor(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), record.NewFakeRecorder(10), 1, 2)
+
+	item := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "giveup",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+		SpecReplica:     1,
+		DesiredReplicas: 2,
+		State:           "peak",
+	}
+	defer g.GetDenyList().RemoveFromList(item)
+	metrics.TransitionStarted(item.Namespace, []g.ScalingInfo{item})
+	transitions := testutil.CollectAndCount(metrics.TransitionStartTime)
+
+	// Without ClusterScalingStateDefinitions every attempt fails
+	e.EnqueueReconcile(context.TODO(), item, "UNIT TEST")
+	e.processNextItem(context.TODO())
+
+	if g.GetDenyList().HasGivenUp(item) {
+		t.Errorf("The executor gave up after the first attempt already")
+	}
+	if got := testutil.CollectAndCount(metrics.TransitionStartTime); got != transitions {
+		t.Errorf("The transition finished while the item is retried")
+	}
+	if e.queue.NumRequeues(keyForItem(item)) != 1 {
+		t.Errorf("The failed item was not requeued. Expected %d retries, got %d", 1, e.queue.NumRequeues(keyForItem(item)))
+	}
+
+	// Don't wait for the backoff
+	e.queue.Add(keyForItem(item))
+	e.processNextItem(context.TODO())
+
+	if !g.GetDenyList().HasGivenUp(item) {
+		t.Errorf("The executor did not give up after the maximum number of attempts")
+	}
+	if got := testutil.CollectAndCount(metrics.TransitionStartTime); got != transitions-1 {
+		t.Errorf("The transition did not finish when the executor gave up on its last item")
+	}
+	itemFromList, _ := g.GetDenyList().GetDeploymentInfoFromList(item)
+	if itemFromList.RetryAttempts != 2 {
+		t.Errorf("Retry attempts not recorded on the item. Expected %d, got %d", 2, itemFromList.RetryAttempts)
+	}
+
+	// A manual retry takes the item off the deny list
+	g.GetDenyList().RemoveFromList(item)
+	e.EnqueueReconcile(context.TODO(), item, "UNIT TEST")
+	e.processNextItem(context.TODO())
+	if e.gaveUp[keyForItem(item)] {
+		t.Errorf("The executor still marks the manually retried item as given up")
+	}
+}
+
+func TestScalingExecutorSkipsPausedItems(t *testing.T) {
+	paused := func(name string) *v1.Deployment {
+		return &v1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "paused", Annotations: map[string]string{"scaler/paused": "true"}}}
+	}
+	e := NewScalingExecutor(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(paused("foo"), paused("bar")).Build(), record.NewFakeRecorder(10), 1, DefaultMaxScalingAttempts)
+
+	item := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "paused",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+		SpecReplica:     1,
+		DesiredReplicas: 2,
+	}
+	// A failing item isn't retried while paused either, but it keeps its attempts for when it's unpaused
+	item.RetryAttempts = 3
+	g.GetDenyList().SetScalingItemOnList(item, true, "ProgressDeadlineExceeded", 2)
+	defer g.GetDenyList().RemoveFromList(item)
+
+	e.EnqueueReconcile(context.TODO(), item, "UNIT TEST")
+	e.processNextItem(context.TODO())
+
+	if e.queue.NumRequeues(keyForItem(item)) != 0 || e.Len() != 0 {
+		t.Errorf("The paused item was requeued")
+	}
+	if itemFromList, err := g.GetDenyList().GetDeploymentInfoFromList(item); err != nil || !itemFromList.Failure || itemFromList.RetryAttempts != 3 {
+		t.Errorf("Pausing dropped the failure of the item from the deny list")
+	}
+
+	// An item that's only being scaled is dropped
+	inFlight := item
+	inFlight.Name = "bar"
+	inFlight.IsBeingScaled = true
+	g.GetDenyList().SetScalingItemOnList(inFlight, false, "", 2)
+	defer g.GetDenyList().RemoveFromList(inFlight)
+
+	e.EnqueueReconcile(context.TODO(), inFlight, "UNIT TEST")
+	e.processNextItem(context.TODO())
+
+	if g.GetDenyList().IsInConcurrentList(inFlight) {
+		t.Errorf("The paused item is still on the deny list")
+	}
+}
+
+func TestScalingExecutorJoinsTransitionTrace(t *testing.T) {
+	exp := tracing.SetupInMemory()
+	e := NewScalingExecutor(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), record.NewFakeRecorder(10), 1, DefaultMaxScalingAttempts)
+
+	item := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "tracing",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+		SpecReplica:     2,
+		DesiredReplicas: 2,
+	}
+
+	ctx, transition := tracing.Start(context.Background(), "UnitTestTransition")
+	e.EnqueueScale(ctx, item, "UNIT TEST")
+	transition.End()
+	e.processNextItem(context.TODO())
+
+	for _, span := range exp.GetSpans() {
+		if span.Name != "ScaleItem" {
+			continue
+		}
+		if span.Parent.SpanID() != transition.SpanContext().SpanID() {
+			t.Errorf("The item span is not a child of the transition that queued it")
+		}
+		return
+	}
+	t.Errorf("No span was recorded for the scaled item")
+}
@@ -0,0 +1,132 @@
+package reconciler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/resources"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PlanScalingClass returns the scaling class a plan is scoped to
+func PlanScalingClass(plan v1alpha1.ScalingPlan) string {
+	if plan.Spec.ScalingClass == "" {
+		return constants.DefaultScalingClass.Name
+	}
+	return plan.Spec.ScalingClass
+}
+
+// ComputeScalingPlan computes the changes setting the state of the plan on the ClusterScalingState of its class would lead to.
+// Nothing is changed on the cluster.
+func ComputeScalingPlan(ctx context.Context, _client client.Client, plan v1alpha1.ScalingPlan) ([]v1alpha1.ScalingPlanNamespace, error) {
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, _client)
+	if err != nil {
+		return nil, err
+	}
+	targetState := states.State{}
+	err = stateDefinitions.FindState(plan.Spec.State, &targetState)
+	if err != nil {
+		return nil, err
+	}
+
+	class := PlanScalingClass(plan)
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err = _client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return nil, err
+	}
+	clusterScalingStates = withPlannedState(clusterScalingStates, class, targetState.Name)
+
+	scalingItems, err := resources.ScalingItemNamespaceLister(ctx, _client, "", config.OptInSelector())
+	if err != nil {
+		return nil, err
+	}
+	itemsOfClass := []g.ScalingInfo{}
+	for _, item := range scalingItems {
+		if states.GetAppliedScalingClassFromScalingItem(item).Name == class {
+			itemsOfClass = append(itemsOfClass, item)
+		}
+	}
+
+	namespaces := []v1alpha1.ScalingPlanNamespace{}
+	if len(itemsOfClass) == 0 {
+		return namespaces, nil
+	}
+
+	// A dry run gives us the reports of all namespaces with changes
+	nsInfos, err := resources.MakeNamespacesScaleDecisionsWithClusterStates(ctx, _client, resources.GroupScalingItemByNamespace(itemsOfClass), stateDefinitions, clusterScalingStates, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, nsInfo := range nsInfos.NSScaleInfo {
+		if nsInfo.DryRunReport != nil {
+			namespaces = append(namespaces, planNamespaceFromReport(*nsInfo.DryRunReport))
+		}
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Namespace < namespaces[j].Namespace
+	})
+
+	return namespaces, nil
+}
+
+// PlanHash identifies the planned changes. The quota headroom is left out on purpose, as it changes with every pod in the namespace.
+func PlanHash(namespaces []v1alpha1.ScalingPlanNamespace) string {
+	withoutHeadroom := make([]v1alpha1.ScalingPlanNamespace, len(namespaces))
+	for i, namespace := range namespaces {
+		withoutHeadroom[i] = namespace
+		withoutHeadroom[i].QuotaHeadroom = nil
+	}
+	data, _ := json.Marshal(withoutHeadroom)
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16]
+}
+
+// ApplyScalingPlan sets the state of the plan on the ClusterScalingState of its class. It's created if there is none yet.
+func ApplyScalingPlan(ctx context.Context, _client client.Client, plan v1alpha1.ScalingPlan) error {
+	return SwitchClusterState(ctx, _client, plan.Spec.ScalingClass, plan.Spec.State, nil)
+}
+
+// withPlannedState returns a copy of the ClusterScalingStates with the planned state set on the one of the class
+func withPlannedState(clusterScalingStates v1alpha1.ClusterScalingStateList, class string, state string) v1alpha1.ClusterScalingStateList {
+	planned := *clusterScalingStates.DeepCopy()
+	for i, css := range planned.Items {
+		if states.GetAppliedScalingClassFromClusterScalingState(css).Name == class {
+			planned.Items[i].Spec.State = state
+			return planned
+		}
+	}
+	planned.Items = append(planned.Items, v1alpha1.ClusterScalingState{
+		Spec: v1alpha1.ClusterScalingStateSpec{
+			State:        state,
+			ScalingClass: class,
+		},
+	})
+	return planned
+}
+
+func planNamespaceFromReport(report resources.NamespaceReport) v1alpha1.ScalingPlanNamespace {
+	namespace := v1alpha1.ScalingPlanNamespace{
+		Namespace:     report.Namespace,
+		QuotaAllowed:  report.QuotaAllowed,
+		QuotaHeadroom: report.QuotaHeadroom,
+	}
+	for _, item := range report.Items {
+		namespace.Items = append(namespace.Items, v1alpha1.ScalingPlanItem{
+			Name:            item.Name,
+			Kind:            item.Kind,
+			CurrentReplicas: item.CurrentReplicas,
+			DesiredReplicas: item.DesiredReplicas,
+			Mode:            item.Mode,
+			Blockers:        item.Blockers,
+		})
+	}
+	return namespace
+}
@@ -0,0 +1,54 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_withPlannedState(t *testing.T) {
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{
+		Items: []v1alpha1.ClusterScalingState{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec:       v1alpha1.ClusterScalingStateSpec{State: "bau"},
+			},
+		},
+	}
+
+	planned := withPlannedState(clusterScalingStates, "default", "peak")
+	if planned.Items[0].Spec.State != "peak" {
+		t.Errorf("Planned state not set on the ClusterScalingState of the class. Got %s", planned.Items[0].Spec.State)
+	}
+	if clusterScalingStates.Items[0].Spec.State != "bau" {
+		t.Errorf("The original ClusterScalingStates were changed")
+	}
+
+	planned = withPlannedState(clusterScalingStates, "batch", "peak")
+	if len(planned.Items) != 2 || planned.Items[1].Spec.ScalingClass != "batch" {
+		t.Errorf("Expected a ClusterScalingState for the new class to be added. Got %+v", planned.Items)
+	}
+}
+
+func TestPlanHash(t *testing.T) {
+	namespaces := []v1alpha1.ScalingPlanNamespace{
+		{
+			Namespace:     "foo",
+			QuotaAllowed:  true,
+			QuotaHeadroom: map[string]string{"limits.cpu": "1"},
+			Items:         []v1alpha1.ScalingPlanItem{{Name: "bar", Kind: "Deployment", CurrentReplicas: 1, DesiredReplicas: 3}},
+		},
+	}
+	hash := PlanHash(namespaces)
+
+	namespaces[0].QuotaHeadroom["limits.cpu"] = "500m"
+	if PlanHash(namespaces) != hash {
+		t.Errorf("A changed quota headroom should not make the plan stale")
+	}
+
+	namespaces[0].Items[0].CurrentReplicas = 2
+	if PlanHash(namespaces) == hash {
+		t.Errorf("Changed replicas should make the plan stale")
+	}
+}
@@ -6,12 +6,16 @@ import (
 	"fmt"
 
 	"github.com/containersol/prescale-operator/api/v1alpha1"
-	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	"github.com/containersol/prescale-operator/internal/notify"
 	"github.com/containersol/prescale-operator/internal/quotas"
 	"github.com/containersol/prescale-operator/internal/resources"
 	"github.com/containersol/prescale-operator/internal/states"
+	"github.com/containersol/prescale-operator/internal/tracing"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
 
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,6 +27,7 @@ type NamespaceInfo struct {
 	Error          error
 	RetriggerMe    bool
 	ScaleNamespace bool
+	DryRunReport   *resources.NamespaceReport
 }
 
 type ReconcilerError struct {
@@ -33,7 +38,11 @@ func (err ReconcilerError) Error() string {
 	return err.msg
 }
 
-func PrepareForNamespaceReconcile(ctx context.Context, _client client.Client, namespace string, stateDefinitions states.States, clusterState states.State, recorder record.EventRecorder, dryRun bool) (map[string]NamespaceInfo, bool, error) {
+// errQuotaWait tells that the best-effort quota policy doesn't grant the item a single replica at the moment.
+// The item waits for the ResourceQuota to change, which is not counted as a failed attempt.
+var errQuotaWait = errors.New("waiting for the ResourceQuota to allow more replicas")
+
+func PrepareForNamespaceReconcile(ctx context.Context, _client client.Client, namespace string, stateDefinitions states.States, clusterState states.State, recorder record.EventRecorder, dryRun bool) (map[string]NamespaceInfo, *v1alpha1.ClusterCapacity, bool, error) {
 	log := ctrl.Log
 	var err error
 	var scalingobjects []g.ScalingInfo
@@ -42,29 +51,29 @@ func PrepareForNamespaceReconcile(ctx context.Context, _client client.Client, na
 	nsInfoMap := make(map[string]NamespaceInfo)
 
 	if namespace == "" {
-		scalingobjects, err = resources.ScalingItemNamespaceLister(ctx, _client, "", constants.OptInLabel)
+		scalingobjects, err = resources.ScalingItemNamespaceLister(ctx, _client, "", config.OptInSelector())
 		if err != nil {
 			log.Error(err, "error listing ScalingObjects")
-			return nil, true, err
+			return nil, nil, true, err
 		}
 	} else {
-		scalingobjects, err = resources.ScalingItemNamespaceLister(ctx, _client, namespace, constants.OptInLabel)
+		scalingobjects, err = resources.ScalingItemNamespaceLister(ctx, _client, namespace, config.OptInSelector())
 		if err != nil {
 			log.Error(err, fmt.Sprintf("error listing ScalingObjects in namespace %s", namespace))
-			return nil, true, err
+			return nil, nil, true, err
 		}
 	}
 
 	if len(scalingobjects) == 0 {
 		log.Info("nothing to reconcile. No opted in objects found.")
-		return nil, false, nil
+		return nil, nil, false, nil
 	}
 
 	scalingObjectGrouped := resources.GroupScalingItemByNamespace(scalingobjects)
 
 	overallNsInformation, err := resources.MakeNamespacesScaleDecisions(ctx, _client, scalingObjectGrouped, stateDefinitions, clusterState, dryRun)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, false, err
 	}
 
 	for namespaceKey, value := range overallNsInformation.NSScaleInfo {
@@ -77,6 +86,7 @@ func PrepareForNamespaceReconcile(ctx context.Context, _client client.Client, na
 			NSEvents:       value.NamespaceEvents,
 			AppliedState:   value.FinalNamespaceState.Name,
 			ScaleNamespace: value.ScaleNameSpace,
+			DryRunReport:   value.DryRunReport,
 		}
 
 		// Accumulate the information to return to the controller
@@ -84,7 +94,7 @@ func PrepareForNamespaceReconcile(ctx context.Context, _client client.Client, na
 	if overallNsInformation.NumberofNsToScale > 0 && !dryRun {
 		reTrigger = true
 	}
-	return nsInfoMap, reTrigger, nil
+	return nsInfoMap, overallNsInformation.Capacity, reTrigger, nil
 
 }
 
@@ -95,7 +105,15 @@ func ReconcileNamespace(ctx context.Context, _client client.Client, namespace st
 	log := ctrl.Log.
 		WithValues("namespace", namespace)
 
-	for _, scalingItem := range scalingItems {
+	ctx, span := tracing.Start(ctx, "ReconcileNamespace",
+		tracing.NamespaceKey.String(namespace),
+		tracing.StateKey.String(finalState.Name),
+		attribute.Int("prescale.items", len(scalingItems)),
+	)
+	defer span.End()
+
+	itemsToScale := []g.ScalingInfo{}
+	for _, scalingItem := range resources.ScalingOrder(scalingItems) {
 		// Don't scale if we don't need to
 		if scalingItem.SpecReplica == scalingItem.DesiredReplicas || scalingItem.DesiredReplicas == -1 {
 			continue
@@ -116,10 +134,24 @@ func ReconcileNamespace(ctx context.Context, _client client.Client, namespace st
 			}
 			continue
 		}
-		if !g.GetDenyList().IsDeploymentInFailureState(scalingItem) {
-			go resources.ScaleOrStepScale(ctx, _client, scalingItem, "NSSCALER", recorder)
+		if g.GetDenyList().IsDeploymentInFailureState(scalingItem) {
+			continue
+		}
+		// Replica changes of others are only reverted if the enforcement mode of the item says so
+		if handleDrift(ctx, _client, scalingItem, recorder) {
+			continue
 		}
+		itemsToScale = append(itemsToScale, scalingItem)
+	}
+	span.SetAttributes(attribute.Int("prescale.items_to_scale", len(itemsToScale)))
 
+	// Only the enqueued items are tracked, as the executor finishes each of them.
+	// The executor picks up the items in the order they are enqueued.
+	if transition, started := metrics.TransitionStarted(namespace, itemsToScale); started {
+		notify.TransitionStarted(namespace, transition.State, itemsToScale)
+	}
+	for _, scalingItem := range itemsToScale {
+		GetScalingExecutor().EnqueueScale(ctx, scalingItem, "NSSCALER")
 	}
 }
 
@@ -163,15 +195,48 @@ func ReconcileScalingItem(ctx context.Context, _client client.Client, scalingIte
 
 	// Don't scale if we don't need to
 	if scalingItem.ReadyReplicas == scalingItem.DesiredReplicas && scalingItem.SpecReplica == scalingItem.DesiredReplicas {
+		forgetDrift(ctx, _client, scalingItem)
+		return nil
+	}
+
+	// Replica changes of others are only reverted if the enforcement mode of the item says so
+	if !forceReconcile && scalingItem.SpecReplica != scalingItem.DesiredReplicas && handleDrift(ctx, _client, scalingItem, recorder) {
 		return nil
 	}
 
-	_, _, allowed, err := quotas.ResourceQuotaCheck(ctx, scalingItem.Namespace, resources.LimitsNeeded(scalingItem, scalingItem.DesiredReplicas))
+	defaultedItems, err := quotas.ApplyLimitRangeDefaults(ctx, scalingItem.Namespace, []g.ScalingInfo{scalingItem})
+	if err != nil {
+		log.Error(err, "Cannot read the LimitRanges. Checking the quotas without their defaults")
+	}
+	_, _, allowed, err := quotas.ResourceQuotaCheck(ctx, scalingItem.Namespace, defaultedItems)
 	if err != nil {
 		log.Error(err, "Cannot calculate the resource quotas")
 		return err
 	}
 
+	// With a best-effort policy the item gets as many replicas as the quota allows
+	quotaPolicy := config.Get().QuotaPolicy
+	if !allowed && quotaPolicy != quotas.QuotaPolicyAllOrNothing {
+		available, err := quotas.ResourceQuotaHeadroom(ctx, scalingItem.Namespace, defaultedItems, false)
+		if err != nil {
+			log.Error(err, "Cannot calculate the resource quota headroom")
+			return err
+		}
+		scalingItem = quotas.Allocate(quotaPolicy, available, defaultedItems)[0]
+		if scalingItem.DesiredReplicas == scalingItem.SpecReplica {
+			// Nothing granted. That's a shortfall the item waits out, not a failure.
+			log.Info(fmt.Sprintf("Quota doesn't allow any more replicas. %d replicas short of the target. Waiting for the quota to change", scalingItem.QuotaShortfall))
+			if obj, objErr := resources.ScalingItemObject(ctx, _client, scalingItem); objErr == nil {
+				recorder.Event(obj, "Warning", "QuotaShortfall", fmt.Sprintf("ResourceQuota doesn't allow any more replicas. %d replicas short of the %s state", scalingItem.QuotaShortfall, scalingItem.State))
+			}
+			return errQuotaWait
+		}
+		allowed = true
+		if scalingItem.QuotaShortfall > 0 {
+			log.Info(fmt.Sprintf("Quota only allows %d of the desired replicas. %d replicas short of the target", scalingItem.DesiredReplicas, scalingItem.QuotaShortfall))
+		}
+	}
+
 	if allowed {
 		scalingItemNew, notFoundErr := g.GetDenyList().GetDeploymentInfoFromList(scalingItem)
 		if notFoundErr == nil && !scalingItemNew.Failure {
@@ -198,6 +263,8 @@ func ReconcileScalingItem(ctx context.Context, _client client.Client, scalingIte
 	} else {
 		log = ctrl.Log
 		log.Info(fmt.Sprintf("Quota check didn't pass in namespace %s for object %s", scalingItem.Namespace, scalingItem.Name))
+		metrics.IncQuotaRejections(scalingItem.Namespace)
+		notify.QuotaRejected(scalingItem.Namespace, []g.ScalingInfo{scalingItem})
 		return ReconcilerError{
 			msg: "Can't scale due to ResourceQuota violation!",
 		}
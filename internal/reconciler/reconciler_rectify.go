@@ -0,0 +1,65 @@
+package reconciler
+
+import (
+	"context"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RectifyScalingItem tries to bring a ScalingItem in failure state back to its desired replica count.
+// It is called by the ScalingExecutor, which retries it with backoff until it succeeds.
+func RectifyScalingItem(ctx context.Context, _client client.Client, deploymentItem g.ScalingInfo, recorder record.EventRecorder) error {
+
+	log := ctrl.Log
+	log.WithValues("Name", deploymentItem.Name).
+		WithValues("Namespace", deploymentItem.Namespace).
+		WithValues("IsDeploymentconfig", deploymentItem.ScalingItemType).
+		WithValues("Failure", deploymentItem.Failure).
+		WithValues("Failure Message", deploymentItem.FailureMessage).
+		Info("Trying to rectify ScaleItem in failure state")
+
+	//We are certain that we have an object to reconcile, we need to get the state definitions
+	stateDefinitions, err := states.GetClusterScalingStates(ctx, _client)
+	if err != nil {
+		log.Error(err, "Failed to get ClusterStateDefinitions")
+		return err
+	}
+	namespaceState, nsStateErr := states.FetchNameSpaceState(ctx, _client, stateDefinitions, deploymentItem.Namespace)
+	if nsStateErr != nil {
+		return nsStateErr
+	}
+
+	// get all css
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	cssErr := _client.List(ctx, &clusterScalingStates)
+	if cssErr != nil {
+		return cssErr
+	}
+
+	deploymentItem = states.GetAppliedStateAndClassOnItem(deploymentItem, namespaceState, clusterScalingStates, stateDefinitions)
+
+	reconcileErr := ReconcileScalingItem(ctx, _client, deploymentItem, true, recorder, "RECTIFIER")
+	if reconcileErr != nil {
+		log.WithValues("Deployment", deploymentItem.Name).
+			WithValues("Namespace", deploymentItem.Namespace).
+			WithValues("IsDeploymentConfig", deploymentItem.ScalingItemType).
+			WithValues("Failure", deploymentItem.Failure).
+			WithValues("Failuremessage", deploymentItem.FailureMessage).
+			Error(reconcileErr, "Failed to rectify the Failure state for the ScalingItem!")
+		return reconcileErr
+	}
+
+	// Succesfully rectified. Remove from failure state
+	g.GetDenyList().RemoveFromList(deploymentItem)
+	log.WithValues("Deployment", deploymentItem.Name).
+		WithValues("Namespace", deploymentItem.Namespace).
+		WithValues("IsDeploymentConfig", deploymentItem.ScalingItemType).
+		WithValues("DesiredReplicas", deploymentItem.DesiredReplicas).
+		Info("Successfully rectified the failing ScalingItem!")
+	return nil
+}
@@ -3,14 +3,15 @@ package reconciler
 import (
 	"context"
 	"fmt"
-	"os"
 	"testing"
 
 	"github.com/containersol/prescale-operator/api/v1alpha1"
 	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
-	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/metrics"
 	"github.com/containersol/prescale-operator/internal/states"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -467,8 +468,10 @@ func TestPrepareNamespaces(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			os.Setenv(constants.EnvMaxConcurrentNamespaceReconciles, "2")
-			nsInfoMap, _, err := PrepareForNamespaceReconcile(tt.args.ctx, tt.args._client, tt.args.namespace, tt.args.stateDefinitions, tt.args.clusterState, record.NewFakeRecorder(10), tt.args.dryRun)
+			defaults := config.Defaults()
+			defaults.MaxConcurrentNamespaceReconciles = 2
+			config.SetDefaults(defaults)
+			nsInfoMap, _, _, err := PrepareForNamespaceReconcile(tt.args.ctx, tt.args._client, tt.args.namespace, tt.args.stateDefinitions, tt.args.clusterState, record.NewFakeRecorder(10), tt.args.dryRun)
 			if err != nil {
 				t.Errorf(fmt.Sprintf("Error during preparation/reconcile. %s", err))
 			}
@@ -503,3 +506,39 @@ func TestPrepareNamespaces(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileNamespaceTracksEnqueuedItems(t *testing.T) {
+	_ = scalingv1alpha1.AddToScheme(scheme.Scheme)
+	_client := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	e := NewScalingExecutor(_client, record.NewFakeRecorder(10), 1, DefaultMaxScalingAttempts)
+
+	item := func(name string) g.ScalingInfo {
+		return g.ScalingInfo{
+			Name:            name,
+			Namespace:       "tracked",
+			State:           "peak",
+			ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+			SpecReplica:     1,
+			DesiredReplicas: 2,
+		}
+	}
+	web := item("web")
+	// An item that's already being scaled isn't enqueued again, so the transition mustn't wait for it
+	busy := item("busy")
+	g.GetDenyList().SetScalingItemOnList(busy, false, "", 2)
+	defer g.GetDenyList().RemoveFromList(busy)
+
+	before := testutil.CollectAndCount(metrics.TransitionStartTime)
+	ReconcileNamespace(context.TODO(), _client, "tracked", []g.ScalingInfo{web, busy}, states.State{Name: "peak"}, record.NewFakeRecorder(10), false)
+	if e.Len() != 1 {
+		t.Fatalf("ReconcileNamespace() enqueued %d items, want only web", e.Len())
+	}
+	if got := testutil.CollectAndCount(metrics.TransitionStartTime); got != before+1 {
+		t.Fatalf("ReconcileNamespace() didn't start a transition")
+	}
+
+	metrics.ItemFinished(web)
+	if got := testutil.CollectAndCount(metrics.TransitionStartTime); got != before {
+		t.Errorf("The transition didn't finish with the last enqueued item")
+	}
+}
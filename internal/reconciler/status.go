@@ -0,0 +1,152 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetFailedItemOnStatus puts the failure of the item on the status of the ClusterScalingState of its scaling class.
+// Passing a nil failure removes the item from the status.
+func SetFailedItemOnStatus(ctx context.Context, _client client.Client, item g.ScalingInfo, failure *v1alpha1.ScalingItemFailure) error {
+	return updateItemStatus(ctx, _client, item, func(status *v1alpha1.ClusterScalingStateStatus) bool {
+		failedItems, changed := replaceFailedItem(status.FailedItems, item, failure)
+		status.FailedItems = failedItems
+		return changed
+	})
+}
+
+// replaceFailedItem returns the list without the old entry of the item and with the new failure, if there is one
+func replaceFailedItem(failedItems []v1alpha1.ScalingItemFailure, item g.ScalingInfo, failure *v1alpha1.ScalingItemFailure) ([]v1alpha1.ScalingItemFailure, bool) {
+	result := []v1alpha1.ScalingItemFailure{}
+	changed := false
+	for _, failedItem := range failedItems {
+		if isStatusEntryOf(item, failedItem.Name, failedItem.Namespace, failedItem.Kind) {
+			changed = true
+			continue
+		}
+		result = append(result, failedItem)
+	}
+	if failure != nil {
+		result = append(result, *failure)
+		changed = true
+	}
+	return result, changed
+}
+
+// SetDriftedItemOnStatus puts the replica drift of the item on the status of the ClusterScalingState of its scaling class.
+// Passing a nil drift removes the item from the status.
+func SetDriftedItemOnStatus(ctx context.Context, _client client.Client, item g.ScalingInfo, drift *v1alpha1.ReplicaDrift) error {
+	return updateItemStatus(ctx, _client, item, func(status *v1alpha1.ClusterScalingStateStatus) bool {
+		driftedItems := []v1alpha1.ReplicaDrift{}
+		changed := false
+		for _, driftedItem := range status.DriftedItems {
+			if isStatusEntryOf(item, driftedItem.Name, driftedItem.Namespace, driftedItem.Kind) {
+				changed = true
+				continue
+			}
+			driftedItems = append(driftedItems, driftedItem)
+		}
+		if drift != nil {
+			driftedItems = append(driftedItems, *drift)
+			changed = true
+		}
+		status.DriftedItems = driftedItems
+		return changed
+	})
+}
+
+// driftedItemOnStatus returns the replica drift of the item on the status of the ClusterScalingState of its scaling class
+func driftedItemOnStatus(ctx context.Context, _client client.Client, item g.ScalingInfo) (*v1alpha1.ReplicaDrift, error) {
+	css, err := clusterScalingStateOfItem(ctx, _client, item)
+	if err != nil || css == nil {
+		return nil, err
+	}
+	for _, driftedItem := range css.Status.DriftedItems {
+		if isStatusEntryOf(item, driftedItem.Name, driftedItem.Namespace, driftedItem.Kind) {
+			return driftedItem.DeepCopy(), nil
+		}
+	}
+	return nil, nil
+}
+
+// updateItemStatus changes the status of the ClusterScalingState of the scaling class of the item.
+// update reports if it changed the status, it's only written then.
+func updateItemStatus(ctx context.Context, _client client.Client, item g.ScalingInfo, update func(status *v1alpha1.ClusterScalingStateStatus) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		css, err := clusterScalingStateOfItem(ctx, _client, item)
+		if err != nil || css == nil {
+			return err
+		}
+		if !update(&css.Status) {
+			return nil
+		}
+		return _client.Status().Update(ctx, css)
+	})
+}
+
+// clusterScalingStateOfItem returns the ClusterScalingState of the scaling class of the item, or nil if there is none
+func clusterScalingStateOfItem(ctx context.Context, _client client.Client, item g.ScalingInfo) (*v1alpha1.ClusterScalingState, error) {
+	itemClass := states.GetAppliedScalingClassFromScalingItem(item)
+
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err := _client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return nil, err
+	}
+	for i, css := range clusterScalingStates.Items {
+		if states.GetAppliedScalingClassFromClusterScalingState(css) == itemClass {
+			return &clusterScalingStates.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// isStatusEntryOf tells if the status entry with the name, namespace and kind is the one of the item
+func isStatusEntryOf(item g.ScalingInfo, name string, namespace string, kind string) bool {
+	return name == item.Name && namespace == item.Namespace && kind == item.ScalingItemType.ItemTypeName
+}
+
+// SetCapacityOnStatus puts the result of the capacity check of a transition on the status of the ClusterScalingState
+func SetCapacityOnStatus(ctx context.Context, _client client.Client, name string, capacity *v1alpha1.ClusterCapacity) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		css := v1alpha1.ClusterScalingState{}
+		err := _client.Get(ctx, types.NamespacedName{Name: name}, &css)
+		if err != nil {
+			// The ClusterScalingState might've been deleted
+			return client.IgnoreNotFound(err)
+		}
+		if reflect.DeepEqual(css.Status.Capacity, capacity) {
+			return nil
+		}
+		css.Status.Capacity = capacity
+		return _client.Status().Update(ctx, &css)
+	})
+}
+
+// InsufficientCapacityMessage describes a failed capacity check for events
+func InsufficientCapacityMessage(capacity v1alpha1.ClusterCapacity) string {
+	return fmt.Sprintf("%d of %d new pods don't fit on the nodes. A cluster autoscaler would need to add: %s", capacity.UnschedulablePods, capacity.NewPods, formatResources(capacity.Shortfall))
+}
+
+func formatResources(resources map[string]string) string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+resources[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,65 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+)
+
+func Test_replaceFailedItem(t *testing.T) {
+	item := g.ScalingInfo{
+		Name:            "foo",
+		Namespace:       "bar",
+		ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+	}
+	other := v1alpha1.ScalingItemFailure{Name: "other", Namespace: "bar", Kind: "Deployment", Attempts: 10}
+
+	tests := []struct {
+		name        string
+		failedItems []v1alpha1.ScalingItemFailure
+		failure     *v1alpha1.ScalingItemFailure
+		wantLen     int
+		wantChanged bool
+	}{
+		{
+			name:        "TestAddFailure",
+			failedItems: []v1alpha1.ScalingItemFailure{other},
+			failure:     &v1alpha1.ScalingItemFailure{Name: "foo", Namespace: "bar", Kind: "Deployment", Attempts: 10},
+			wantLen:     2,
+			wantChanged: true,
+		},
+		{
+			name:        "TestReplaceFailure",
+			failedItems: []v1alpha1.ScalingItemFailure{other, {Name: "foo", Namespace: "bar", Kind: "Deployment", Attempts: 5}},
+			failure:     &v1alpha1.ScalingItemFailure{Name: "foo", Namespace: "bar", Kind: "Deployment", Attempts: 10},
+			wantLen:     2,
+			wantChanged: true,
+		},
+		{
+			name:        "TestRemoveFailure",
+			failedItems: []v1alpha1.ScalingItemFailure{other, {Name: "foo", Namespace: "bar", Kind: "Deployment", Attempts: 5}},
+			failure:     nil,
+			wantLen:     1,
+			wantChanged: true,
+		},
+		{
+			name:        "TestNothingToRemove",
+			failedItems: []v1alpha1.ScalingItemFailure{other},
+			failure:     nil,
+			wantLen:     1,
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := replaceFailedItem(tt.failedItems, item, tt.failure)
+			if len(got) != tt.wantLen {
+				t.Errorf("replaceFailedItem() returned %d items, want %d", len(got), tt.wantLen)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("replaceFailedItem() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
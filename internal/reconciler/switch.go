@@ -0,0 +1,220 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/states"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// How often the expiry of switched states is checked
+const stateExpiryPeriod = 15 * time.Second
+
+// SwitchClusterState sets the state on the ClusterScalingState of the class. It's created if there is none yet.
+// An empty class is the default class. With an expiry, the previous state comes back once it expires.
+func SwitchClusterState(ctx context.Context, _client client.Client, class string, state string, expires *time.Time) error {
+	className := class
+	if className == "" {
+		className = constants.DefaultScalingClass.Name
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+		err := _client.List(ctx, &clusterScalingStates)
+		if err != nil {
+			return err
+		}
+
+		for _, css := range clusterScalingStates.Items {
+			if states.GetAppliedScalingClassFromClusterScalingState(css).Name != className {
+				continue
+			}
+			if !setExpiry(&css.ObjectMeta, css.Spec.State, expires) && css.Spec.State == state {
+				return nil
+			}
+			css.Spec.State = state
+			return _client.Update(ctx, &css)
+		}
+
+		css := &v1alpha1.ClusterScalingState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: className,
+			},
+			Spec: v1alpha1.ClusterScalingStateSpec{
+				State:        state,
+				ScalingClass: class,
+			},
+		}
+		setExpiry(&css.ObjectMeta, "", expires)
+		return _client.Create(ctx, css)
+	})
+}
+
+// SwitchNamespaceState sets the state on the ScalingState of the namespace and returns its name. It's created if there
+// is none yet. With an expiry, the previous state comes back once it expires.
+func SwitchNamespaceState(ctx context.Context, _client client.Client, namespace string, state string, expires *time.Time) (string, error) {
+	name := ""
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scalingStates := v1alpha1.ScalingStateList{}
+		err := _client.List(ctx, &scalingStates, client.InNamespace(namespace))
+		if err != nil {
+			return err
+		}
+
+		switch len(scalingStates.Items) {
+		case 0:
+			name = "scalingstate-" + namespace
+			ss := &v1alpha1.ScalingState{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       v1alpha1.ScalingStateSpec{State: state},
+			}
+			setExpiry(&ss.ObjectMeta, "", expires)
+			return _client.Create(ctx, ss)
+		case 1:
+			scalingState := scalingStates.Items[0]
+			name = scalingState.Name
+			if !setExpiry(&scalingState.ObjectMeta, scalingState.Spec.State, expires) && scalingState.Spec.State == state {
+				return nil
+			}
+			scalingState.Spec.State = state
+			return _client.Update(ctx, &scalingState)
+		}
+		return fmt.Errorf("found %d ScalingStates in namespace %s. The operator ignores them until only one is left", len(scalingStates.Items), namespace)
+	})
+	return name, err
+}
+
+// setExpiry sets or removes the expiry annotations and returns if they changed. A state switched again before it
+// expires keeps going back to the state from before the first switch.
+func setExpiry(meta *metav1.ObjectMeta, previousState string, expires *time.Time) bool {
+	_, expiring := meta.Annotations[constants.StateExpiresAnnotation]
+	if expires == nil {
+		if !expiring {
+			return false
+		}
+		delete(meta.Annotations, constants.StateExpiresAnnotation)
+		delete(meta.Annotations, constants.StateRevertAnnotation)
+		return true
+	}
+
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	if !expiring && previousState != "" {
+		meta.Annotations[constants.StateRevertAnnotation] = previousState
+	}
+	meta.Annotations[constants.StateExpiresAnnotation] = expires.UTC().Format(time.RFC3339)
+	return true
+}
+
+// StateExpiry returns when the state of a ClusterScalingState or ScalingState expires and the state to go back to.
+// Found is false if the state doesn't expire.
+func StateExpiry(obj client.Object) (expires time.Time, revertTo string, found bool) {
+	value, found := obj.GetAnnotations()[constants.StateExpiresAnnotation]
+	if !found {
+		return time.Time{}, "", false
+	}
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return expires, obj.GetAnnotations()[constants.StateRevertAnnotation], true
+}
+
+// RevertExpiredState puts the previous state back on a ClusterScalingState or ScalingState whose state expired.
+// Without a previous state, the custom resource is deleted. It returns if the state was reverted.
+func RevertExpiredState(ctx context.Context, _client client.Client, obj client.Object) (bool, error) {
+	expires, revertTo, found := StateExpiry(obj)
+	if !found || time.Now().Before(expires) {
+		return false, nil
+	}
+
+	if revertTo == "" {
+		return true, client.IgnoreNotFound(_client.Delete(ctx, obj))
+	}
+	annotations := obj.GetAnnotations()
+	delete(annotations, constants.StateExpiresAnnotation)
+	delete(annotations, constants.StateRevertAnnotation)
+	obj.SetAnnotations(annotations)
+	switch o := obj.(type) {
+	case *v1alpha1.ClusterScalingState:
+		o.Spec.State = revertTo
+	case *v1alpha1.ScalingState:
+		o.Spec.State = revertTo
+	default:
+		return false, fmt.Errorf("%T has no state to revert", obj)
+	}
+	return true, _client.Update(ctx, obj)
+}
+
+// StateExpirer reverts expired states. The controllers reconcile the reverted custom resources like any other change.
+// It runs on its own instead of in the controllers, so expiries survive restarts of the operator.
+type StateExpirer struct {
+	client   client.Client
+	recorder record.EventRecorder
+}
+
+// NewStateExpirer creates a StateExpirer
+func NewStateExpirer(_client client.Client, recorder record.EventRecorder) *StateExpirer {
+	return &StateExpirer{client: _client, recorder: recorder}
+}
+
+// Start checks the expiries until the context is cancelled. It implements manager.Runnable.
+func (e *StateExpirer) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, e.revertExpired, stateExpiryPeriod)
+	return nil
+}
+
+// NeedLeaderElection makes sure only the leader reverts states. It implements manager.LeaderElectionRunnable.
+func (e *StateExpirer) NeedLeaderElection() bool {
+	return true
+}
+
+func (e *StateExpirer) revertExpired(ctx context.Context) {
+	log := ctrl.Log.WithName("StateExpirer")
+	objects := []client.Object{}
+
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err := e.client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		log.Error(err, "Failed to list the ClusterScalingStates")
+	}
+	for i := range clusterScalingStates.Items {
+		objects = append(objects, &clusterScalingStates.Items[i])
+	}
+	scalingStates := v1alpha1.ScalingStateList{}
+	err = e.client.List(ctx, &scalingStates)
+	if err != nil {
+		log.Error(err, "Failed to list the ScalingStates")
+	}
+	for i := range scalingStates.Items {
+		objects = append(objects, &scalingStates.Items[i])
+	}
+
+	for _, obj := range objects {
+		_, revertTo, _ := StateExpiry(obj)
+		reverted, err := RevertExpiredState(ctx, e.client, obj)
+		if err != nil {
+			log.Error(err, "Failed to revert the expired state", "namespace", obj.GetNamespace(), "name", obj.GetName())
+			continue
+		}
+		if !reverted {
+			continue
+		}
+		if revertTo == "" {
+			log.Info("State expired. Deleted the custom resource", "namespace", obj.GetNamespace(), "name", obj.GetName())
+			continue
+		}
+		e.recorder.Event(obj, "Normal", "StateExpired", fmt.Sprintf("The state expired. Went back to %s", revertTo))
+		log.Info("State expired", "namespace", obj.GetNamespace(), "name", obj.GetName(), "state", revertTo)
+	}
+}
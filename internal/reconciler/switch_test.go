@@ -0,0 +1,62 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSwitchClusterStateExpiry(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	ctx := context.TODO()
+	_client := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(&v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "bau"}}).
+		Build()
+	get := func(name string) *v1alpha1.ClusterScalingState {
+		css := &v1alpha1.ClusterScalingState{}
+		err := _client.Get(ctx, client.ObjectKey{Name: name}, css)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return css
+	}
+
+	// Switching again before the expiry keeps going back to the state from before
+	expires := time.Now().Add(time.Hour)
+	_ = SwitchClusterState(ctx, _client, "", "peak", &expires)
+	_ = SwitchClusterState(ctx, _client, "", "sale", &expires)
+	css := get("default")
+	if css.Spec.State != "sale" || css.Annotations[constants.StateRevertAnnotation] != "bau" {
+		t.Errorf("SwitchClusterState() = %v reverting to %v, want sale reverting to bau", css.Spec.State, css.Annotations[constants.StateRevertAnnotation])
+	}
+	if reverted, _ := RevertExpiredState(ctx, _client, css); reverted {
+		t.Errorf("RevertExpiredState() reverted a state that didn't expire")
+	}
+
+	// A switch without expiry is permanent
+	_ = SwitchClusterState(ctx, _client, "", "peak", nil)
+	if _, _, found := StateExpiry(get("default")); found {
+		t.Errorf("SwitchClusterState() without expiry kept the expiry")
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	_ = SwitchClusterState(ctx, _client, "", "sale", &expired)
+	_ = SwitchClusterState(ctx, _client, "batch", "peak", &expired)
+	NewStateExpirer(_client, record.NewFakeRecorder(10)).revertExpired(ctx)
+	if css := get("default"); css.Spec.State != "peak" || len(css.Annotations) != 0 {
+		t.Errorf("revertExpired() = %v with annotations %v, want peak without expiry", css.Spec.State, css.Annotations)
+	}
+	if get("batch") != nil {
+		t.Errorf("revertExpired() kept the ClusterScalingState created for the expired state")
+	}
+}
@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/analysis"
+	"github.com/containersol/prescale-operator/internal/states"
+	"github.com/containersol/prescale-operator/internal/tracing"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// analysisClient queries the Prometheus compatible APIs of the analysis gates
+var analysisClient = &http.Client{}
+
+// WaitForAnalysis evaluates the analysis gate of the item before the next step. With the Pause policy it waits until the
+// gate passes again. It returns an error if the gate aborts the scaling or stayed failed for longer than the pause timeout.
+func WaitForAnalysis(ctx context.Context, _client client.Client, item g.ScalingInfo, recorder record.EventRecorder, log logr.Logger) (err error) {
+	definition := v1alpha1.ClusterScalingStateDefinition{}
+	if definitions, err := states.GetClusterScalingStateDefinitionsList(ctx, _client); err == nil {
+		definition = definitions.Items[0]
+	}
+	gate, found := analysis.For(item, definition)
+	if !found {
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "AnalysisGate", tracing.ItemAttributes(item)...)
+	defer func() { tracing.End(span, err) }()
+
+	paused := time.Time{}
+	for {
+		evalErr := analysis.Evaluate(ctx, analysisClient, gate, item)
+		if evalErr == nil {
+			if !paused.IsZero() {
+				analysisEvent(ctx, _client, recorder, item, "Normal", "AnalysisResumed", fmt.Sprintf("The analysis gate passed again after %s. Resuming the step scaling", time.Since(paused).Round(time.Second)))
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		switch analysis.OnFailure(gate) {
+		case v1alpha1.AnalysisContinue:
+			analysisEvent(ctx, _client, recorder, item, "Warning", "AnalysisFailed", fmt.Sprintf("%s. Taking the next step anyway", evalErr.Error()))
+			return nil
+		case v1alpha1.AnalysisAbort:
+			return ScaleError{msg: fmt.Sprintf("Analysis gate failed: %s", evalErr.Error())}
+		}
+
+		if paused.IsZero() {
+			paused = time.Now()
+			log.Info("Analysis gate failed. Pausing the step scaling", "item", item.Name, "namespace", item.Namespace, "reason", evalErr.Error())
+			analysisEvent(ctx, _client, recorder, item, "Warning", "AnalysisPaused", fmt.Sprintf("%s. Pausing the step scaling at %d replicas", evalErr.Error(), item.SpecReplica))
+		}
+		if time.Since(paused) >= analysis.PauseTimeout(gate) {
+			return ScaleError{msg: fmt.Sprintf("Analysis gate didn't pass within %s: %s", analysis.PauseTimeout(gate), evalErr.Error())}
+		}
+		select {
+		case <-ctx.Done():
+			// The operator is shutting down or lost leadership
+			return ctx.Err()
+		case <-time.After(analysis.Interval(gate)):
+		}
+	}
+}
+
+func analysisEvent(ctx context.Context, _client client.Client, recorder record.EventRecorder, item g.ScalingInfo, eventType string, reason string, message string) {
+	obj, err := ScalingItemObject(ctx, _client, item)
+	if err != nil {
+		return
+	}
+	recorder.Event(obj, eventType, reason, message)
+}
@@ -0,0 +1,80 @@
+package resources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestWaitForAnalysis(t *testing.T) {
+	// The error rate regresses on the first query and recovers on the second
+	var queries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := "0.01"
+		if atomic.AddInt32(&queries, 1) == 1 {
+			value = "0.2"
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"` + value + `"]}]}}`))
+	}))
+	defer server.Close()
+
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	run := func(onFailure string) ([]string, error) {
+		atomic.StoreInt32(&queries, 0)
+		_client := fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(
+				&v1alpha1.ClusterScalingStateDefinition{
+					ObjectMeta: metav1.ObjectMeta{Name: "states"},
+					Spec: []v1alpha1.States{{Name: "peak", Priority: 1, Analysis: &v1alpha1.AnalysisGate{
+						Address:         server.URL,
+						Query:           `rate(errors{namespace="${namespace}"}[1m])`,
+						Threshold:       "< 0.05",
+						OnFailure:       onFailure,
+						IntervalSeconds: 1,
+					}}},
+				},
+				&v1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}},
+			).
+			Build()
+		recorder := record.NewFakeRecorder(10)
+		item := g.ScalingInfo{Name: "web", Namespace: "shop", State: "peak", SpecReplica: 3, ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}}
+		err := WaitForAnalysis(context.TODO(), _client, item, recorder, ctrllog.NullLogger{})
+		close(recorder.Events)
+		events := []string{}
+		for event := range recorder.Events {
+			events = append(events, event)
+		}
+		return events, err
+	}
+
+	events, err := run(v1alpha1.AnalysisPause)
+	if err != nil || atomic.LoadInt32(&queries) != 2 {
+		t.Errorf("WaitForAnalysis() with Pause = %v after %d queries, want it to pass on the second", err, queries)
+	}
+	if len(events) != 2 || !strings.Contains(events[0], "AnalysisPaused") || !strings.Contains(events[1], "AnalysisResumed") {
+		t.Errorf("WaitForAnalysis() with Pause recorded %v, want AnalysisPaused and AnalysisResumed", events)
+	}
+
+	events, err = run(v1alpha1.AnalysisContinue)
+	if err != nil || len(events) != 1 || !strings.Contains(events[0], "AnalysisFailed") {
+		t.Errorf("WaitForAnalysis() with Continue = %v, %v, want no error and an AnalysisFailed event", err, events)
+	}
+
+	_, err = run(v1alpha1.AnalysisAbort)
+	if _, ok := err.(ScaleError); !ok || !strings.Contains(err.Error(), "0.2, which is not < 0.05") {
+		t.Errorf("WaitForAnalysis() with Abort = %v, want a ScaleError with the failed sample", err)
+	}
+}
@@ -4,26 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/containersol/prescale-operator/api/v1alpha1"
 	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	nd "github.com/containersol/prescale-operator/internal/namespace_defaults"
+	"github.com/containersol/prescale-operator/internal/notify"
 	"github.com/containersol/prescale-operator/internal/quotas"
 	sr "github.com/containersol/prescale-operator/internal/state_replicas"
 	"github.com/containersol/prescale-operator/internal/states"
+	"github.com/containersol/prescale-operator/internal/tracing"
 	"github.com/containersol/prescale-operator/internal/validations"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
 	"github.com/containersol/prescale-operator/pkg/utils/math"
 	redisalpha "github.com/containersolutions/redis-operator/api/v1alpha1"
-	"github.com/olekukonko/tablewriter"
 	ocv1 "github.com/openshift/api/apps/v1"
 	"github.com/prometheus/common/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -39,16 +44,20 @@ type NamespaceScaleInfo struct {
 	ReplicaListError        error
 	ResourceQuotaCheckError error
 	NamespaceEvents         NamespaceEvents
+	DryRunReport            *NamespaceReport
 }
 
 type OverallNsInfo struct {
 	NSScaleInfo           map[string]NamespaceScaleInfo
 	NumberofNsBeingScaled int
 	NumberofNsToScale     int
+	// Capacity is only set if the capacity check is enabled
+	Capacity *v1alpha1.ClusterCapacity
 }
 
 type NamespaceEvents struct {
 	QuotaExceeded    string
+	QuotaShortfall   string
 	ReconcileSuccess []string
 	ReconcileFailure []string
 	DryRunInfo       string
@@ -83,10 +92,12 @@ func DoScaling(ctx context.Context, _client client.Client, scalingItem g.Scaling
 
 		// Skip if we couldn't get the deploymentItem
 		if err == nil {
+			// A failing workload can still be parked at zero replicas, the pods that fail are removed on the way
+			parking := scalingItem.DesiredReplicas == 0 && replicas < deploymentItem.SpecReplica
 			deploymentItem.SpecReplica = replicas
 
 			var updateErr error = nil
-			if !deploymentItem.Failure {
+			if !deploymentItem.Failure || parking {
 				exists, labelErr := OptinLabel(deploymentItem)
 				if !exists || labelErr != nil {
 					return DeploymentScaleError{
@@ -110,6 +121,10 @@ func DoScaling(ctx context.Context, _client client.Client, scalingItem g.Scaling
 //DeploymentOptinLabel returns true if the optin-label is found and is true for the deploymentItem
 func OptinLabel(deploymentItem g.ScalingInfo) (bool, error) {
 
+	// Opted in by its namespace
+	if deploymentItem.NamespaceOptIn {
+		return true, nil
+	}
 	return validations.OptinLabelExists(deploymentItem.Labels)
 }
 
@@ -179,6 +194,7 @@ func DetermineDesiredReplicas(items []g.ScalingInfo) ([]g.ScalingInfo, error) {
 			items[i].DesiredReplicas = stateReplica.Replicas
 			returnList = append(returnList, items[i])
 		}
+		metrics.SetItemReplicas(items[i])
 
 	}
 
@@ -200,6 +216,7 @@ func ScaleOrStepScale(ctx context.Context, _client client.Client, deploymentItem
 	}
 
 	rapidScalingEnabled := states.GetRapidScalingSetting(deploymentItem)
+	metrics.SetItemReplicas(deploymentItem)
 	log.Info("Putting deploymentItem on denylist")
 	deploymentItem.IsBeingScaled = true
 	g.GetDenyList().SetScalingItemOnList(deploymentItem, deploymentItem.Failure, deploymentItem.FailureMessage, desiredReplicaCount)
@@ -213,10 +230,31 @@ func ScaleOrStepScale(ctx context.Context, _client client.Client, deploymentItem
 		err = StepScale(ctx, _client, deploymentItem, recorder, log)
 	}
 
+	if paused, isPaused := err.(PausedError); isPaused {
+		// The item leaves the transition. Unpausing reconciles it, which puts it back.
+		log.Info(fmt.Sprintf("Stopped scaling: %s", paused.Error()))
+		g.GetDenyList().RemoveUnlessFailed(deploymentItem)
+		return nil
+	}
+
+	finalItem, _ := g.GetDenyList().GetDeploymentInfoFromList(deploymentItem)
+	metrics.SetItemReplicas(finalItem)
+
 	if err != nil {
 		log.Error(err, "Error scaling deployment")
 		RegisterEvents(ctx, _client, recorder, nil, deploymentItem)
-		g.GetDenyList().RemoveFromList(deploymentItem)
+		if finalItem.DesiredReplicas == -1 {
+			// The item opted out while it was scaled
+			g.GetDenyList().RemoveFromList(deploymentItem)
+			return err
+		}
+		// The item stays on the deny list in failure state, so its retry attempts are kept until it's scaled or given up on
+		failureMessage := finalItem.FailureMessage
+		if failureMessage == "" {
+			failureMessage = err.Error()
+		}
+		finalItem.IsBeingScaled = false
+		g.GetDenyList().SetScalingItemOnList(finalItem, true, failureMessage, finalItem.DesiredReplicas)
 		return err
 	}
 
@@ -238,11 +276,7 @@ func LimitsNeeded(deploymentItem g.ScalingInfo, replicas int32) corev1.ResourceL
 
 func LimitsNeededList(deployments []g.ScalingInfo) corev1.ResourceList {
 
-	var limitsneeded corev1.ResourceList
-	for _, deploymentItem := range deployments {
-		limitsneeded = math.Add(limitsneeded, math.Mul(math.ReplicaCalc(deploymentItem.DesiredReplicas, deploymentItem.SpecReplica), deploymentItem.ResourceList))
-	}
-	return limitsneeded
+	return quotas.ResourcesNeeded(deployments)
 }
 
 func GetRefreshedScalingItemSetError(ctx context.Context, _client client.Client, deploymentInfo g.ScalingInfo, failure bool) (g.ScalingInfo, error) {
@@ -285,63 +319,78 @@ func GetRefreshedScalingItem(ctx context.Context, _client client.Client, deploym
 	} else {
 		return g.ScalingInfo{}, errors.New("type of the item could not be determined!")
 	}
-	// Refresh the item on the list as well
+	itemToReturn = ApplyNamespaceDefaults(ctx, _client, itemToReturn)
+	// Refresh the item on the list as well. The retry attempts and the GaveUp state are kept.
 	itemToReturn.IsBeingScaled = deploymentInfo.IsBeingScaled
+	itemToReturn.RetryAttempts = deploymentInfo.RetryAttempts
+	itemToReturn.GaveUp = deploymentInfo.GaveUp
 	g.GetDenyList().SetScalingItemOnList(itemToReturn, itemToReturn.Failure, itemToReturn.FailureMessage, deploymentInfo.DesiredReplicas)
 	item, _ := g.GetDenyList().GetDeploymentInfoFromList(itemToReturn)
 	return item, nil
 }
 
-//DeploymentLister lists all deployments in a namespace
-func ScalingItemNamespaceLister(ctx context.Context, _client client.Client, namespace string, OptInLabel map[string]string) ([]g.ScalingInfo, error) {
+//DeploymentLister lists all opted-in items in a namespace, or in all namespaces the operator watches if the namespace is empty.
+//The items get the defaults of their namespace.
+func ScalingItemNamespaceLister(ctx context.Context, _client client.Client, namespace string, optIn labels.Selector) ([]g.ScalingInfo, error) {
 
-	returnList := []g.ScalingInfo{}
-	deployments := v1.DeploymentList{}
-	deploymentconfigs := ocv1.DeploymentConfigList{}
-	redisclusters := redisalpha.RedisClusterList{}
+	// Namespaces outside of the watched ones may not even be in the cache
+	if namespace != "" && !NamespaceWatched(ctx, _client, namespace) {
+		return []g.ScalingInfo{}, nil
+	}
 
-	if namespace != "" {
-		err := _client.List(ctx, &deployments, client.MatchingLabels(OptInLabel), client.InNamespace(namespace))
-		if err != nil {
-			return []g.ScalingInfo{}, err
+	// An empty namespace lists clusterwide
+	returnList, err := listScalingItems(ctx, _client, client.MatchingLabelsSelector{Selector: optIn}, client.InNamespace(namespace))
+	if err != nil {
+		return []g.ScalingInfo{}, err
+	}
+
+	// Namespaces with the opt-in annotation opt in all of their workloads, except the ones that opt out themselves
+	for _, optedInNamespace := range optedInNamespaces(ctx, _client, namespace) {
+		if namespace == "" && !NamespaceWatched(ctx, _client, optedInNamespace) {
+			continue
 		}
-	} else {
-		// List all deployments, clusterwide.
-		err := _client.List(ctx, &deployments, client.MatchingLabels(OptInLabel))
+		namespaceItems, err := listScalingItems(ctx, _client, client.InNamespace(optedInNamespace))
 		if err != nil {
 			return []g.ScalingInfo{}, err
 		}
+		for _, item := range namespaceItems {
+			if !nd.OptedOut(item.Labels) && !containsItem(returnList, item) {
+				returnList = append(returnList, item)
+			}
+		}
 	}
 
-	if constants.OpenshiftCluster {
+	if namespace == "" {
+		returnList = filterWatchedNamespaces(ctx, _client, returnList)
+	}
 
-		if namespace != "" {
-			err := _client.List(ctx, &deploymentconfigs, client.MatchingLabels(OptInLabel), client.InNamespace(namespace))
-			if err != nil {
-				return []g.ScalingInfo{}, err
-			}
-		} else {
-			// List all deploymentconfigs, clusterwide.
-			err := _client.List(ctx, &deploymentconfigs, client.MatchingLabels(OptInLabel))
-			if err != nil {
-				return []g.ScalingInfo{}, err
-			}
+	return SetPriorities(ctx, _client, applyNamespaceDefaults(ctx, _client, returnList)), nil
+
+}
+
+// listScalingItems lists the deployments, deploymentconfigs and redisclusters
+func listScalingItems(ctx context.Context, _client client.Client, opts ...client.ListOption) ([]g.ScalingInfo, error) {
+	returnList := []g.ScalingInfo{}
+	deployments := v1.DeploymentList{}
+	deploymentconfigs := ocv1.DeploymentConfigList{}
+	redisclusters := redisalpha.RedisClusterList{}
+
+	err := _client.List(ctx, &deployments, opts...)
+	if err != nil {
+		return []g.ScalingInfo{}, err
+	}
+
+	if constants.OpenshiftCluster {
+		err := _client.List(ctx, &deploymentconfigs, opts...)
+		if err != nil {
+			return []g.ScalingInfo{}, err
 		}
 	}
 
 	if constants.RedisCluster {
-
-		if namespace != "" {
-			err := _client.List(ctx, &redisclusters, client.MatchingLabels(OptInLabel), client.InNamespace(namespace))
-			if err != nil {
-				return []g.ScalingInfo{}, err
-			}
-		} else {
-			// List all redisclusters, clusterwide.
-			err := _client.List(ctx, &redisclusters, client.MatchingLabels(OptInLabel))
-			if err != nil {
-				return []g.ScalingInfo{}, err
-			}
+		err := _client.List(ctx, &redisclusters, opts...)
+		if err != nil {
+			return []g.ScalingInfo{}, err
 		}
 	}
 
@@ -358,9 +407,98 @@ func ScalingItemNamespaceLister(ctx context.Context, _client client.Client, name
 	}
 
 	return returnList, nil
+}
+
+// optedInNamespaces returns the namespaces with the opt-in annotation, or only the namespace if it's not empty.
+// Namespaces that can't be read are left out.
+func optedInNamespaces(ctx context.Context, _client client.Client, namespace string) []string {
+	result := []string{}
+	if namespace != "" {
+		namespaceAnnotations, err := nd.Annotations(ctx, _client, namespace)
+		if err != nil {
+			ctrl.Log.Error(err, fmt.Sprintf("Error getting namespace %s. Ignoring its annotations", namespace))
+		}
+		if nd.OptedIn(namespaceAnnotations) {
+			result = append(result, namespace)
+		}
+		return result
+	}
+
+	namespaces := corev1.NamespaceList{}
+	err := _client.List(ctx, &namespaces)
+	if err != nil {
+		ctrl.Log.Error(err, "Error listing namespaces. Ignoring their annotations")
+		return result
+	}
+	for _, ns := range namespaces.Items {
+		if nd.OptedIn(ns.Annotations) {
+			result = append(result, ns.Name)
+		}
+	}
+	return result
+}
+
+// applyNamespaceDefaults gives the items the defaults of their namespaces
+func applyNamespaceDefaults(ctx context.Context, _client client.Client, items []g.ScalingInfo) []g.ScalingInfo {
+	annotationsByNamespace := map[string]map[string]string{}
+	for i, item := range items {
+		namespaceAnnotations, found := annotationsByNamespace[item.Namespace]
+		if !found {
+			var err error
+			namespaceAnnotations, err = nd.Annotations(ctx, _client, item.Namespace)
+			if err != nil {
+				ctrl.Log.Error(err, fmt.Sprintf("Error getting namespace %s. Ignoring its annotations", item.Namespace))
+			}
+			annotationsByNamespace[item.Namespace] = namespaceAnnotations
+		}
+		items[i] = nd.Apply(item, namespaceAnnotations)
+	}
+	return items
+}
+
+// ApplyNamespaceDefaults gives the item the defaults of its namespace
+func ApplyNamespaceDefaults(ctx context.Context, _client client.Client, item g.ScalingInfo) g.ScalingInfo {
+	return applyNamespaceDefaults(ctx, _client, []g.ScalingInfo{item})[0]
+}
+
+func containsItem(items []g.ScalingInfo, item g.ScalingInfo) bool {
+	for _, inList := range items {
+		if inList.Name == item.Name && inList.Namespace == item.Namespace && inList.ItemTypeName == item.ItemTypeName {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceWatched tells if the operator scales the items of the namespace, by the namespace settings of the PreScalingOperatorConfig
+func NamespaceWatched(ctx context.Context, _client client.Client, namespace string) bool {
+	if !config.NamespaceLabelsNeeded() {
+		return config.WatchesNamespace(namespace, nil)
+	}
+	ns := corev1.Namespace{}
+	err := _client.Get(ctx, client.ObjectKey{Name: namespace}, &ns)
+	if err != nil {
+		ctrl.Log.Error(err, fmt.Sprintf("Error getting namespace %s. Not scaling it", namespace))
+		return false
+	}
+	return config.WatchesNamespace(namespace, ns.Labels)
+}
 
+func filterWatchedNamespaces(ctx context.Context, _client client.Client, items []g.ScalingInfo) []g.ScalingInfo {
+	watched := map[string]bool{}
+	result := []g.ScalingInfo{}
+	for _, item := range items {
+		if _, found := watched[item.Namespace]; !found {
+			watched[item.Namespace] = NamespaceWatched(ctx, _client, item.Namespace)
+		}
+		if watched[item.Namespace] {
+			result = append(result, item)
+		}
+	}
+	return result
 }
 
+// UpdateScalingItem writes the replicas of the item as the OperatorFieldManager
 func UpdateScalingItem(ctx context.Context, _client client.Client, deploymentItem g.ScalingInfo) error {
 	var req reconcile.Request
 	req.NamespacedName.Namespace = deploymentItem.Namespace
@@ -378,21 +516,21 @@ func UpdateScalingItem(ctx context.Context, _client client.Client, deploymentIte
 			return getErr
 		}
 		deploymentConfig.Spec.Replicas = deploymentItem.SpecReplica
-		updateErr = _client.Update(ctx, &deploymentConfig, &client.UpdateOptions{})
+		updateErr = _client.Update(ctx, &deploymentConfig, client.FieldOwner(OperatorFieldManager))
 	} else if deploymentItem.ScalingItemType.ItemTypeName == "Deployment" {
 		deployment, getErr = DeploymentGetter(ctx, _client, req)
 		if getErr != nil {
 			return getErr
 		}
 		deployment.Spec.Replicas = &deploymentItem.SpecReplica
-		updateErr = _client.Update(ctx, &deployment, &client.UpdateOptions{})
+		updateErr = _client.Update(ctx, &deployment, client.FieldOwner(OperatorFieldManager))
 	} else if deploymentItem.ScalingItemType.ItemTypeName == "RedisCluster" {
 		redisCluster, getErr = RedisClusterGetter(ctx, _client, req)
 		if getErr != nil {
 			return getErr
 		}
 		redisCluster.Spec.Replicas = deploymentItem.SpecReplica
-		updateErr = _client.Update(ctx, &redisCluster, &client.UpdateOptions{})
+		updateErr = _client.Update(ctx, &redisCluster, client.FieldOwner(OperatorFieldManager))
 	} else {
 		return errors.New("type of the item could not be determined! No update")
 	}
@@ -428,19 +566,50 @@ func RegisterEvents(ctx context.Context, _client client.Client, recorder record.
 
 }
 
-// Determines if the given namespaces need to be scaled or not. Determining factors are: final state, Resource quota checks, MaxConcurrentReconciles, and if they're already being scaled
-func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, groupedNamespaces map[string][]g.ScalingInfo, stateDefinitions states.States, clusterState states.State, dryRun bool) (OverallNsInfo, error) {
-	log := ctrl.Log
-	nsInfoMap := make(map[string]NamespaceScaleInfo)
-	numberNsbeingScaled := 0
-	numberNsToScale := 0
-	var limitsneeded corev1.ResourceList
+// RegisterGaveUpEvent tells on the workload that the operator stopped retrying to scale it
+func RegisterGaveUpEvent(ctx context.Context, _client client.Client, recorder record.EventRecorder, scalingItem g.ScalingInfo, scalerErr error) {
+	obj, getErr := ScalingItemObject(ctx, _client, scalingItem)
+	if getErr != nil {
+		return
+	}
+	recorder.Event(obj, "Warning", "GaveUp", fmt.Sprintf("Gave up scaling the %s to %d replicas after %d attempts: %s | Set or change the %s annotation to retry", scalingItem.ScalingItemType.ItemTypeName, scalingItem.DesiredReplicas, scalingItem.RetryAttempts, scalerErr.Error(), constants.RetryAnnotation))
+}
 
-	maxConcurrentNsReconcile, _ := strconv.Atoi(os.Getenv(constants.EnvMaxConcurrentNamespaceReconciles))
+// ScalingItemObject returns the cluster object behind a ScalingItem
+func ScalingItemObject(ctx context.Context, _client client.Client, scalingItem g.ScalingInfo) (client.Object, error) {
+	var obj client.Object
+	switch scalingItem.ScalingItemType.ItemTypeName {
+	case "DeploymentConfig":
+		obj = &ocv1.DeploymentConfig{}
+	case "Deployment":
+		obj = &v1.Deployment{}
+	case "RedisCluster":
+		obj = &redisalpha.RedisCluster{}
+	default:
+		return nil, errors.New("type of the item could not be determined!")
+	}
+	err := _client.Get(ctx, client.ObjectKey{Namespace: scalingItem.Namespace, Name: scalingItem.Name}, obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
 
-	if maxConcurrentNsReconcile == 0 {
-		maxConcurrentNsReconcile = 1
+// ScalingItemFromObject converts a cluster object to its ScalingItem
+func ScalingItemFromObject(obj client.Object) (g.ScalingInfo, error) {
+	switch o := obj.(type) {
+	case *ocv1.DeploymentConfig:
+		return g.ConvertDeploymentConfigToItem(*o), nil
+	case *v1.Deployment:
+		return g.ConvertDeploymentToItem(*o), nil
+	case *redisalpha.RedisCluster:
+		return g.ConvertRedisClusterToItem(*o), nil
 	}
+	return g.ScalingInfo{}, errors.New("type of the item could not be determined!")
+}
+
+// Determines if the given namespaces need to be scaled or not. Determining factors are: final state, Resource quota checks, MaxConcurrentReconciles, and if they're already being scaled
+func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, groupedNamespaces map[string][]g.ScalingInfo, stateDefinitions states.States, clusterState states.State, dryRun bool) (OverallNsInfo, error) {
 	// get all css
 	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
 	err := _client.List(context.Background(), &clusterScalingStates, &client.ListOptions{})
@@ -448,6 +617,26 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 		return OverallNsInfo{}, err
 	}
 
+	return MakeNamespacesScaleDecisionsWithClusterStates(ctx, _client, groupedNamespaces, stateDefinitions, clusterScalingStates, dryRun)
+}
+
+// MakeNamespacesScaleDecisionsWithClusterStates makes the scale decisions based on the given ClusterScalingStates instead of the ones on the cluster.
+// Used to compute what would happen if a ClusterScalingState changed.
+func MakeNamespacesScaleDecisionsWithClusterStates(ctx context.Context, _client client.Client, groupedNamespaces map[string][]g.ScalingInfo, stateDefinitions states.States, clusterScalingStates v1alpha1.ClusterScalingStateList, dryRun bool) (OverallNsInfo, error) {
+	log := ctrl.Log
+	nsInfoMap := make(map[string]NamespaceScaleInfo)
+	numberNsbeingScaled := 0
+	numberNsToScale := 0
+
+	operatorConfig := config.Get()
+	maxConcurrentNsReconcile := int(operatorConfig.MaxConcurrentNamespaceReconciles)
+
+	ctx, span := tracing.Start(ctx, "MakeNamespacesScaleDecisions",
+		tracing.DryRunKey.Bool(dryRun),
+		attribute.Int("prescale.namespaces", len(groupedNamespaces)),
+	)
+	defer span.End()
+
 	for namespaceKey, scalingInfoList := range groupedNamespaces {
 		namespaceState, nsStateErr := states.FetchNameSpaceState(ctx, _client, stateDefinitions, namespaceKey)
 		if nsStateErr != nil {
@@ -468,8 +657,8 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 				log.Info(fmt.Sprintf("Error on scalingitem %s in namespace %s | Error: %s", item.Name, item.Namespace, item.FailureMessage))
 			}
 		}
+		metrics.SetNamespaceState(namespaceKey, namespaceState.Name)
 
-		var finalLimitsCPU, finalLimitsMemory string
 		var nsEvents NamespaceEvents
 
 		scalingInfoList, replicalisterr := DetermineDesiredReplicas(scalingInfoList)
@@ -479,11 +668,26 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 			continue
 		}
 		// Resource Quota Check //
-		//Here we calculate the resource limits we need from all deployments combined
-		limitsneeded = LimitsNeededList(scalingInfoList)
-
-		// After we have calculated the resources needed from all workloads in a given namespace, we can determine if the scaling should be allowed to go through
-		finalLimitsCPU, finalLimitsMemory, allowed, rqCheckErr := quotas.ResourceQuotaCheck(ctx, namespaceKey, limitsneeded)
+		// Containers without resources get the defaults of the LimitRanges on admission, which count against the quota too
+		scalingInfoList, lrErr := quotas.ApplyLimitRangeDefaults(ctx, namespaceKey, scalingInfoList)
+		if lrErr != nil {
+			log.Error(lrErr, "Cannot read the LimitRanges. Checking the quotas without their defaults")
+		}
+		// The resources needed are calculated per ResourceQuota, as scoped quotas only track the pods of some workloads.
+		// Then we can determine if the scaling should be allowed to go through
+		_, _, allowed, rqCheckErr := quotas.ResourceQuotaCheck(ctx, namespaceKey, scalingInfoList)
+
+		// With a best-effort policy the namespace gets as much of the scale-up as the quota allows
+		if !allowed && rqCheckErr == nil && operatorConfig.QuotaPolicy != quotas.QuotaPolicyAllOrNothing {
+			available, headroomErr := quotas.ResourceQuotaHeadroom(ctx, namespaceKey, scalingInfoList, false)
+			if headroomErr != nil {
+				log.Error(headroomErr, "Cannot calculate the resource quota headroom. Not scaling the namespace")
+			} else {
+				scalingInfoList = quotas.Allocate(operatorConfig.QuotaPolicy, available, scalingInfoList)
+				allowed = true
+				nsEvents.QuotaShortfall = QuotaShortfallInfo(namespaceKey, scalingInfoList)
+			}
+		}
 		if rqCheckErr != nil {
 			log.Error(rqCheckErr, "Cannot calculate the resource quotas")
 			putOnMap := NamespaceScaleInfo{
@@ -500,34 +704,27 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 
 		if !allowed {
 			nsEvents.QuotaExceeded = namespaceKey
+			// Dry runs and ScalingPlans only compute the decision, nobody tried to scale
+			if !dryRun {
+				metrics.IncQuotaRejections(namespaceKey)
+				notify.QuotaRejected(namespaceKey, scalingInfoList)
+			}
 		}
+		span.AddEvent("NamespaceScaleDecision", trace.WithAttributes(
+			tracing.NamespaceKey.String(namespaceKey),
+			tracing.StateKey.String(namespaceState.Name),
+			attribute.Bool("prescale.quota_allowed", allowed),
+			attribute.Int("prescale.items", len(scalingInfoList)),
+		))
 
 		// Accumulate the dryrun information
 		if dryRun {
-			tableString := &strings.Builder{}
-			table := tablewriter.NewWriter(tableString)
-			table.SetHeader([]string{"Namespace", "Quotas enough", "Cpu left after scaling", "Memory left after scaling"})
-			table.Append([]string{namespaceKey, strconv.FormatBool(allowed), finalLimitsCPU, finalLimitsMemory})
-			table.Render()
-
-			nsEvents.DryRunInfo = tableString.String()
-
-			var applicationData [][]string
-			tableString = &strings.Builder{}
-			table = tablewriter.NewWriter(tableString)
-			table.SetHeader([]string{"Application", "Current replicas", "New state", "New replicas", "Rapid Scaling"})
-
-			for _, deployment := range scalingInfoList {
-
-				applicationData = append(applicationData, []string{deployment.Name, fmt.Sprint(deployment.ReadyReplicas), deployment.State, fmt.Sprint(deployment.DesiredReplicas), strconv.FormatBool(states.GetRapidScalingSetting(deployment))})
-
-			}
-
-			for _, v := range applicationData {
-				table.Append(v)
+			metrics.SetDryRunDeltas(namespaceKey, scalingInfoList)
+			headroom, headroomErr := quotas.ResourceQuotaHeadroom(ctx, namespaceKey, scalingInfoList, true)
+			if headroomErr != nil {
+				log.Error(headroomErr, "Cannot calculate the resource quota headroom for the dry run report")
 			}
-
-			table.Render()
+			report := NewNamespaceReport(namespaceKey, namespaceState.Name, scalingInfoList, allowed, rqCheckErr, headroom)
 
 			// Check if we need to care about this namespace being dryrun.
 			scaleNameSpace := false
@@ -537,7 +734,8 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 				}
 			}
 
-			nsEvents.DryRunInfo = nsEvents.DryRunInfo + tableString.String()
+			// The event only gets a summary. The full report is written by the controller.
+			nsEvents.DryRunInfo = report.Summary()
 			putOnMap := NamespaceScaleInfo{
 				ScalingItems:            scalingInfoList,
 				FinalNamespaceState:     namespaceState,
@@ -546,6 +744,7 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 				ReplicaListError:        replicalisterr,
 				ResourceQuotaCheckError: rqCheckErr,
 				NamespaceEvents:         nsEvents,
+				DryRunReport:            &report,
 			}
 			nsInfoMap[namespaceKey] = putOnMap
 
@@ -589,7 +788,7 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 			nsInfoMap[namespaceKey] = putOnMap
 		}
 
-		// Figure out if we need to limit the number of namespaces to scale concurrently based on env var "MaxConcurrentNamespaceReconciles"
+		// Figure out if we need to limit the number of namespaces to scale concurrently based on the configured MaxConcurrentNamespaceReconciles
 		nsScaleBudget := maxConcurrentNsReconcile - numberNsbeingScaled
 		for namespaceKey, item := range nsInfoMap {
 			if item.ScaleNameSpace {
@@ -603,13 +802,60 @@ func MakeNamespacesScaleDecisions(ctx context.Context, _client client.Client, gr
 		}
 	}
 
+	var capacity *v1alpha1.ClusterCapacity
+	if operatorConfig.CapacityCheck {
+		capacity = checkClusterCapacity(ctx, nsInfoMap)
+	}
+
 	return OverallNsInfo{
 		NSScaleInfo:           nsInfoMap,
 		NumberofNsBeingScaled: numberNsbeingScaled,
 		NumberofNsToScale:     numberNsToScale,
+		Capacity:              capacity,
 	}, nil
 }
 
+// QuotaShortfallInfo tells which items reached their target and how many replicas the others are short because of the quota.
+// Empty if all items reached their target.
+func QuotaShortfallInfo(namespace string, items []g.ScalingInfo) string {
+	reached := 0
+	short := []string{}
+	for _, item := range items {
+		if item.QuotaShortfall > 0 {
+			short = append(short, fmt.Sprintf("%s %s by %d", item.ScalingItemType.ItemTypeName, item.Name, item.QuotaShortfall))
+		} else if item.DesiredReplicas != -1 {
+			reached++
+		}
+	}
+	if len(short) == 0 {
+		return ""
+	}
+	sort.Strings(short)
+	return fmt.Sprintf("namespace %s: %d items reached their target, short of target in replicas: %s", namespace, reached, strings.Join(short, ", "))
+}
+
+// checkClusterCapacity checks if the nodes can fit the items of all namespaces that would be scaled, including the ones that
+// have to wait for other namespaces to finish. Returns nil if the check failed.
+func checkClusterCapacity(ctx context.Context, nsInfoMap map[string]NamespaceScaleInfo) *v1alpha1.ClusterCapacity {
+	items := []g.ScalingInfo{}
+	for _, nsInfo := range nsInfoMap {
+		if nsInfo.NamespaceEvents.QuotaExceeded != "" || nsInfo.ResourceQuotaCheckError != nil {
+			continue
+		}
+		items = append(items, nsInfo.ScalingItems...)
+	}
+
+	capacity, err := quotas.ClusterCapacityCheck(ctx, items)
+	if err != nil {
+		ctrl.Log.Error(err, "Cannot check the capacity of the cluster")
+		return nil
+	}
+	if !capacity.Fits {
+		ctrl.Log.Info(fmt.Sprintf("WARNING: %d of %d new pods don't fit on the nodes. Missing: %v", capacity.UnschedulablePods, capacity.NewPods, capacity.Shortfall))
+	}
+	return &capacity
+}
+
 // Groups the given objects by their namespaces in a map. Returns the namespaces alphabetically
 func GroupScalingItemByNamespace(items []g.ScalingInfo) map[string][]g.ScalingInfo {
 	if len(items) == 0 {
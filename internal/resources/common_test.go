@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
 	sr "github.com/containersol/prescale-operator/internal/state_replicas"
 	"github.com/containersol/prescale-operator/internal/states"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
@@ -13,6 +15,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -23,7 +26,7 @@ func TestLister(t *testing.T) {
 		ctx        context.Context
 		_client    client.Client
 		namespace  string
-		OptInLabel map[string]string
+		OptInLabel labels.Selector
 	}
 	tests := []struct {
 		name    string
@@ -37,7 +40,7 @@ func TestLister(t *testing.T) {
 				ctx:        context.TODO(),
 				_client:    fake.NewClientBuilder().Build(),
 				namespace:  "default",
-				OptInLabel: map[string]string{},
+				OptInLabel: labels.Everything(),
 			},
 			want:    []g.ScalingInfo{},
 			wantErr: false,
@@ -57,6 +60,95 @@ func TestLister(t *testing.T) {
 	}
 }
 
+func TestListerHonoursOperatorConfig(t *testing.T) {
+	defer config.SetDefaults(config.Defaults())
+	config.SetDefaults(config.Defaults())
+	err := config.Apply(v1alpha1.PreScalingOperatorConfigSpec{
+		OptInSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+		ExcludeNamespaces: []string{"excluded"},
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"scaling": "enabled"}},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	deployment := func(name, namespace string, labels map[string]string) *v1.Deployment {
+		return &v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec:       v1.DeploymentSpec{Replicas: new(int32), ProgressDeadlineSeconds: new(int32)},
+		}
+	}
+	enabled := map[string]string{"scaling": "enabled"}
+	payments := map[string]string{"team": "payments"}
+	_client := fake.NewClientBuilder().
+		WithObjects(
+			namespace("selected", enabled),
+			namespace("excluded", enabled),
+			namespace("unlabeled", nil),
+			deployment("scaled", "selected", payments),
+			deployment("other-team", "selected", map[string]string{"team": "search", "scaler/opt-in": "true"}),
+			deployment("excluded", "excluded", payments),
+			deployment("unlabeled", "unlabeled", payments),
+		).
+		Build()
+
+	got, err := ScalingItemNamespaceLister(context.TODO(), _client, "", config.OptInSelector())
+	if err != nil {
+		t.Fatalf("ScalingItemNamespaceLister() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "scaled" {
+		t.Errorf("ScalingItemNamespaceLister() = %v, want only the deployment scaled", got)
+	}
+
+	got, err = ScalingItemNamespaceLister(context.TODO(), _client, "excluded", config.OptInSelector())
+	if err != nil {
+		t.Fatalf("ScalingItemNamespaceLister() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ScalingItemNamespaceLister() in an excluded namespace = %v, want none", got)
+	}
+}
+
+func TestListerHonoursNamespaceOptIn(t *testing.T) {
+	_client := fake.NewClientBuilder().
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "opted-in",
+				Annotations: map[string]string{"scaler/opt-in": "true", "scaler/state-peak-replicas": "3"},
+			}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			&v1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "opted-in"},
+				Spec:       v1.DeploymentSpec{Replicas: new(int32), ProgressDeadlineSeconds: new(int32)},
+			},
+			&v1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "opted-out", Namespace: "opted-in", Labels: map[string]string{"scaler/opt-in": "false"}},
+				Spec:       v1.DeploymentSpec{Replicas: new(int32), ProgressDeadlineSeconds: new(int32)},
+			},
+			&v1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "other"},
+				Spec:       v1.DeploymentSpec{Replicas: new(int32), ProgressDeadlineSeconds: new(int32)},
+			},
+		).
+		Build()
+
+	for _, namespace := range []string{"", "opted-in"} {
+		got, err := ScalingItemNamespaceLister(context.TODO(), _client, namespace, config.OptInSelector())
+		if err != nil {
+			t.Fatalf("ScalingItemNamespaceLister() error = %v", err)
+		}
+		if len(got) != 1 || got[0].Name != "unlabeled" || got[0].Namespace != "opted-in" {
+			t.Fatalf("ScalingItemNamespaceLister(%q) = %v, want only the unlabeled deployment in opted-in", namespace, got)
+		}
+		if !got[0].NamespaceOptIn || got[0].Annotations["scaler/state-peak-replicas"] != "3" {
+			t.Errorf("ScalingItemNamespaceLister(%q) = %v, want the opt-in and defaults of the namespace", namespace, got[0])
+		}
+	}
+}
+
 func TestGetter(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -566,7 +658,10 @@ func TestLimitsNeeded(t *testing.T) {
 				},
 				replicas: 5,
 			},
-			want: map[corev1.ResourceName]resource.Quantity{},
+			// Without resources on the containers only the pod count is needed
+			want: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourcePods: *resource.NewQuantity(5, resource.DecimalSI),
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -729,3 +824,18 @@ func TestNamespaceGrouping(t *testing.T) {
 		})
 	}
 }
+
+func TestQuotaShortfallInfo(t *testing.T) {
+	items := []g.ScalingInfo{
+		{Name: "checkout", ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}, DesiredReplicas: 5},
+		{Name: "recommendations", ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}, DesiredReplicas: 2, QuotaShortfall: 3},
+	}
+
+	want := "namespace bar: 1 items reached their target, short of target in replicas: Deployment recommendations by 3"
+	if got := QuotaShortfallInfo("bar", items); got != want {
+		t.Errorf("QuotaShortfallInfo() = %q, want %q", got, want)
+	}
+	if got := QuotaShortfallInfo("bar", items[:1]); got != "" {
+		t.Errorf("QuotaShortfallInfo() without shortfall = %q, want empty", got)
+	}
+}
@@ -0,0 +1,66 @@
+package resources
+
+import (
+	"encoding/json"
+	"time"
+
+	constants "github.com/containersol/prescale-operator/internal"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Enforcement modes of the scaler/enforcement annotation
+const (
+	// EnforcementEnforce reverts replica changes of others to the desired replicas. It's the default.
+	EnforcementEnforce = "enforce"
+	// EnforcementWarn reports replica changes of others with an event, a metric and an entry on the status of the
+	// ClusterScalingState, but doesn't revert them
+	EnforcementWarn = "warn"
+	// EnforcementIgnore leaves replica changes of others alone
+	EnforcementIgnore = "ignore"
+)
+
+// OperatorFieldManager is the field manager of the replica updates of the operator. Replicas it owns are no drift.
+const OperatorFieldManager = "prescale-operator"
+
+// Enforcement returns the enforcement mode of the item. A missing or unknown mode enforces the desired replicas.
+func Enforcement(item g.ScalingInfo) string {
+	switch mode := item.Annotations[constants.EnforcementAnnotation]; mode {
+	case EnforcementWarn, EnforcementIgnore:
+		return mode
+	}
+	return EnforcementEnforce
+}
+
+// ReplicaActor returns the field manager that owns the replicas of the object. If several managers own them,
+// the one that changed the object last wins. It returns an empty string if the managed fields don't tell.
+func ReplicaActor(obj metav1.Object) string {
+	actor := ""
+	latest := time.Time{}
+	for _, entry := range obj.GetManagedFields() {
+		if entry.FieldsV1 == nil || !ownsReplicas(entry.FieldsV1.Raw) {
+			continue
+		}
+		if actor == "" || (entry.Time != nil && entry.Time.After(latest)) {
+			actor = entry.Manager
+			if entry.Time != nil {
+				latest = entry.Time.Time
+			}
+		}
+	}
+	return actor
+}
+
+// ownsReplicas tells if the fields of a managed fields entry contain spec.replicas
+func ownsReplicas(raw []byte) bool {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false
+	}
+	spec := map[string]json.RawMessage{}
+	if err := json.Unmarshal(fields["f:spec"], &spec); err != nil {
+		return false
+	}
+	_, found := spec["f:replicas"]
+	return found
+}
@@ -0,0 +1,57 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnforcement(t *testing.T) {
+	tests := map[string]string{
+		"":        EnforcementEnforce,
+		"enforce": EnforcementEnforce,
+		"warn":    EnforcementWarn,
+		"ignore":  EnforcementIgnore,
+		"Warn":    EnforcementEnforce,
+	}
+	for mode, want := range tests {
+		item := g.ScalingInfo{Annotations: map[string]string{"scaler/enforcement": mode}}
+		if got := Enforcement(item); got != want {
+			t.Errorf("Enforcement() of %q = %q, want %q", mode, got, want)
+		}
+	}
+}
+
+func TestReplicaActor(t *testing.T) {
+	entry := func(manager string, fields string, at time.Time) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{Manager: manager, Time: &metav1.Time{Time: at}, FieldsV1: &metav1.FieldsV1{Raw: []byte(fields)}}
+	}
+	now := time.Now()
+	tests := []struct {
+		name    string
+		entries []metav1.ManagedFieldsEntry
+		want    string
+	}{
+		{"NoManagedFields", nil, ""},
+		{"OtherFields", []metav1.ManagedFieldsEntry{entry("kubectl", `{"f:metadata":{"f:labels":{}},"f:spec":{"f:template":{}}}`, now)}, ""},
+		{"ReplicaOwner", []metav1.ManagedFieldsEntry{
+			entry("kubectl-client-side-apply", `{"f:spec":{"f:template":{}}}`, now),
+			entry("argocd-controller", `{"f:spec":{"f:replicas":{}}}`, now.Add(-time.Hour)),
+		}, "argocd-controller"},
+		{"LatestSharedOwner", []metav1.ManagedFieldsEntry{
+			entry("helm", `{"f:spec":{"f:replicas":{}}}`, now.Add(-time.Hour)),
+			entry("kubectl", `{"f:spec":{"f:replicas":{}}}`, now),
+		}, "kubectl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := v1.Deployment{ObjectMeta: metav1.ObjectMeta{ManagedFields: tt.entries}}
+			if got := ReplicaActor(&deployment); got != tt.want {
+				t.Errorf("ReplicaActor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
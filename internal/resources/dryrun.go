@@ -0,0 +1,157 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons why an item would not be scaled in a dry run
+const (
+	BlockerQuotaExceeded   = "QuotaExceeded"
+	BlockerQuotaCheckError = "QuotaCheckError"
+	BlockerFailureState    = "FailureState"
+	BlockerGaveUp          = "GaveUp"
+	BlockerBeingScaled     = "BeingScaled"
+)
+
+// DryRunReport is the machine-readable result of a dry run. It has no timestamps, so two reports of the same plan are equal.
+type DryRunReport struct {
+	// Source is the custom resource that triggered the dry run, like ClusterScalingState/peak
+	Source     string            `json:"source"`
+	Namespaces []NamespaceReport `json:"namespaces"`
+	// Capacity tells if the nodes can fit the new pods. Only set if the capacity check is enabled.
+	Capacity *v1alpha1.ClusterCapacity `json:"capacity,omitempty"`
+}
+
+// NamespaceReport is the dry run result of one namespace
+type NamespaceReport struct {
+	Namespace    string `json:"namespace"`
+	State        string `json:"state,omitempty"`
+	WouldScale   bool   `json:"wouldScale"`
+	QuotaAllowed bool   `json:"quotaAllowed"`
+	// QuotaHeadroom holds the resources left in the ResourceQuotas after scaling, negative if exceeded
+	QuotaHeadroom map[string]string `json:"quotaHeadroom,omitempty"`
+	Items         []ItemReport      `json:"items"`
+}
+
+// ItemReport is the dry run result of one scaling item
+type ItemReport struct {
+	Name            string   `json:"name"`
+	Kind            string   `json:"kind"`
+	State           string   `json:"state"`
+	CurrentReplicas int32    `json:"currentReplicas"`
+	ReadyReplicas   int32    `json:"readyReplicas"`
+	DesiredReplicas int32    `json:"desiredReplicas"`
+	Mode            string   `json:"mode"`
+	Blockers        []string `json:"blockers,omitempty"`
+	// QuotaShortfall is the number of replicas the item stays short of its target because of the quota policy
+	QuotaShortfall int32 `json:"quotaShortfall,omitempty"`
+}
+
+// NewNamespaceReport builds the dry run result of a namespace from its scaling items and the quota check
+func NewNamespaceReport(namespace string, state string, items []g.ScalingInfo, quotaAllowed bool, quotaErr error, headroom corev1.ResourceList) NamespaceReport {
+	report := NamespaceReport{
+		Namespace:    namespace,
+		State:        state,
+		QuotaAllowed: quotaAllowed,
+		Items:        []ItemReport{},
+	}
+
+	if len(headroom) > 0 {
+		report.QuotaHeadroom = make(map[string]string)
+		for name, quantity := range headroom {
+			report.QuotaHeadroom[string(name)] = quantity.String()
+		}
+	}
+
+	for _, item := range items {
+		itemReport := ItemReport{
+			Name:            item.Name,
+			Kind:            item.ScalingItemType.ItemTypeName,
+			State:           item.State,
+			CurrentReplicas: item.SpecReplica,
+			ReadyReplicas:   item.ReadyReplicas,
+			DesiredReplicas: item.DesiredReplicas,
+			Mode:            "step",
+			Blockers:        itemBlockers(item, quotaAllowed, quotaErr),
+			QuotaShortfall:  item.QuotaShortfall,
+		}
+		if states.GetRapidScalingSetting(item) {
+			itemReport.Mode = "rapid"
+		}
+		if item.SpecReplica != item.DesiredReplicas && len(itemReport.Blockers) == 0 {
+			report.WouldScale = true
+		}
+		report.Items = append(report.Items, itemReport)
+	}
+
+	sort.Slice(report.Items, func(i, j int) bool {
+		if report.Items[i].Kind != report.Items[j].Kind {
+			return report.Items[i].Kind < report.Items[j].Kind
+		}
+		return report.Items[i].Name < report.Items[j].Name
+	})
+
+	return report
+}
+
+// Summary is a short human readable version of the report, meant for events
+func (r NamespaceReport) Summary() string {
+	toScale, blocked, short := 0, 0, 0
+	for _, item := range r.Items {
+		if len(item.Blockers) > 0 {
+			blocked++
+		} else if item.CurrentReplicas != item.DesiredReplicas {
+			toScale++
+		}
+		if item.QuotaShortfall > 0 {
+			short++
+		}
+	}
+	summary := fmt.Sprintf("namespace %s: %d of %d items would be scaled", r.Namespace, toScale, len(r.Items))
+	if r.State != "" {
+		summary = summary + fmt.Sprintf(" to state %s", r.State)
+	}
+	if blocked > 0 {
+		summary = summary + fmt.Sprintf(", %d blocked", blocked)
+	}
+	if short > 0 {
+		summary = summary + fmt.Sprintf(", %d short of target because of the quota", short)
+	}
+	if !r.QuotaAllowed {
+		summary = summary + ", ResourceQuota exceeded"
+	}
+	return summary + ". "
+}
+
+func itemBlockers(item g.ScalingInfo, quotaAllowed bool, quotaErr error) []string {
+	blockers := []string{}
+	if quotaErr != nil {
+		blockers = append(blockers, BlockerQuotaCheckError)
+	} else if !quotaAllowed {
+		blockers = append(blockers, BlockerQuotaExceeded)
+	}
+	if g.GetDenyList().HasGivenUp(item) {
+		blockers = append(blockers, BlockerGaveUp)
+	} else if g.GetDenyList().IsDeploymentInFailureState(item) {
+		blockers = append(blockers, BlockerFailureState)
+	} else if g.GetDenyList().IsBeingScaled(item) {
+		blockers = append(blockers, BlockerBeingScaled)
+	}
+	if len(blockers) == 0 {
+		return nil
+	}
+	return blockers
+}
+
+// SortNamespaceReports orders the namespaces of a report by name, so reports can be diffed
+func (r *DryRunReport) SortNamespaceReports() {
+	sort.Slice(r.Namespaces, func(i, j int) bool {
+		return r.Namespaces[i].Namespace < r.Namespaces[j].Namespace
+	})
+}
@@ -0,0 +1,60 @@
+package resources
+
+import (
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestNewNamespaceReport(t *testing.T) {
+	items := []g.ScalingInfo{
+		{
+			Name:            "b-app",
+			Namespace:       "report",
+			ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+			State:           "peak",
+			SpecReplica:     1,
+			DesiredReplicas: 3,
+			Annotations:     map[string]string{"scaler/rapid-scaling": "true"},
+		},
+		{
+			Name:            "a-app",
+			Namespace:       "report",
+			ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"},
+			State:           "peak",
+			SpecReplica:     2,
+			DesiredReplicas: 4,
+		},
+	}
+	headroom := corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("-500m")}
+
+	report := NewNamespaceReport("report", "peak", items, false, nil, headroom)
+
+	if report.WouldScale {
+		t.Errorf("Namespace would scale although the quota is exceeded")
+	}
+	if report.QuotaHeadroom["limits.cpu"] != "-500m" {
+		t.Errorf("Quota headroom not in report. Expected %s, got %s", "-500m", report.QuotaHeadroom["limits.cpu"])
+	}
+	if len(report.Items) != 2 || report.Items[0].Name != "a-app" {
+		t.Fatalf("Items are not sorted by name. Got %+v", report.Items)
+	}
+	if report.Items[0].Mode != "step" || report.Items[1].Mode != "rapid" {
+		t.Errorf("Scaling mode not reported correctly. Got %s and %s", report.Items[0].Mode, report.Items[1].Mode)
+	}
+	for _, item := range report.Items {
+		if len(item.Blockers) != 1 || item.Blockers[0] != BlockerQuotaExceeded {
+			t.Errorf("Expected item %s to be blocked by the quota. Got %v", item.Name, item.Blockers)
+		}
+	}
+
+	report = NewNamespaceReport("report", "peak", items, true, nil, nil)
+	if !report.WouldScale {
+		t.Errorf("Namespace would not scale although nothing blocks it")
+	}
+	if report.Summary() != "namespace report: 2 of 2 items would be scaled to state peak. " {
+		t.Errorf("Unexpected summary: %s", report.Summary())
+	}
+}
@@ -0,0 +1,53 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PausedError stops the scalers if scaling the item was paused. Unlike other errors, it doesn't put the item in failure state.
+type PausedError struct {
+	reason string
+}
+
+func (err PausedError) Error() string {
+	return "scaling is paused by " + err.reason
+}
+
+// Paused tells if scaling the item is paused, and by what: the scaler/paused annotation of the workload,
+// a paused ScalingState in its namespace or the paused ClusterScalingState of its scaling class.
+// The workload is read again, as the annotations of the item may be older than the pause.
+func Paused(ctx context.Context, _client client.Client, item g.ScalingInfo) (string, bool) {
+	if obj, err := ScalingItemObject(ctx, _client, item); err == nil {
+		if obj.GetAnnotations()[constants.PausedAnnotation] == "true" {
+			return fmt.Sprintf("the %s annotation of the %s", constants.PausedAnnotation, item.ScalingItemType.ItemTypeName), true
+		}
+		item.Labels = obj.GetLabels()
+	}
+
+	scalingStates := v1alpha1.ScalingStateList{}
+	if err := _client.List(ctx, &scalingStates, client.InNamespace(item.Namespace)); err == nil {
+		for _, ss := range scalingStates.Items {
+			if ss.Spec.Paused {
+				return fmt.Sprintf("ScalingState %s/%s", ss.Namespace, ss.Name), true
+			}
+		}
+	}
+
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	if err := _client.List(ctx, &clusterScalingStates); err == nil {
+		class := states.GetAppliedScalingClassFromScalingItem(item).Name
+		for _, css := range clusterScalingStates.Items {
+			if css.Spec.Paused && states.GetAppliedScalingClassFromClusterScalingState(css).Name == class {
+				return fmt.Sprintf("ClusterScalingState %s", css.Name), true
+			}
+		}
+	}
+	return "", false
+}
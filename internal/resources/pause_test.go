@@ -0,0 +1,61 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPaused(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	deployment := func(name string, namespace string, labels map[string]string, annotations map[string]string) *v1.Deployment {
+		return &v1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, Annotations: annotations}}
+	}
+	_client := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			deployment("web", "shop", nil, map[string]string{"scaler/paused": "true"}),
+			deployment("api", "shop", nil, map[string]string{"scaler/paused": "false"}),
+			deployment("batch", "shop", map[string]string{"scaler/scaling-class": "batch"}, nil),
+			deployment("web", "payments", nil, nil),
+			&v1alpha1.ScalingState{ObjectMeta: metav1.ObjectMeta{Name: "incident", Namespace: "payments"}, Spec: v1alpha1.ScalingStateSpec{State: "bau", Paused: true}},
+			&v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "batch"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "bau", ScalingClass: "batch", Paused: true}},
+			&v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "bau"}},
+		).
+		Build()
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      string
+	}{
+		{"web", "shop", "the scaler/paused annotation of the Deployment"},
+		{"api", "shop", ""},
+		{"batch", "shop", "ClusterScalingState batch"},
+		{"web", "payments", "ScalingState payments/incident"},
+	}
+	for _, test := range tests {
+		// The labels and annotations of the item are read from the workload
+		item := g.ScalingInfo{Name: test.name, Namespace: test.namespace, ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}}
+		reason, paused := Paused(context.TODO(), _client, item)
+		if reason != test.want || paused != (test.want != "") {
+			t.Errorf("Paused() of %s/%s = %q, %v, want %q", test.namespace, test.name, reason, paused, test.want)
+		}
+	}
+
+	// Unpausing takes effect right away
+	css := v1alpha1.ClusterScalingState{}
+	_ = _client.Get(context.TODO(), client.ObjectKey{Name: "batch"}, &css)
+	css.Spec.Paused = false
+	_ = _client.Update(context.TODO(), &css)
+	if reason, paused := Paused(context.TODO(), _client, g.ScalingInfo{Name: "batch", Namespace: "shop", ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}}); paused {
+		t.Errorf("Paused() after unpausing = %q, want not paused", reason)
+	}
+}
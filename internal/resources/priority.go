@@ -0,0 +1,102 @@
+package resources
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	constants "github.com/containersol/prescale-operator/internal"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=get;list;watch
+
+// SetPriorities sets the priority of the items. The "scaler/priority" annotation wins. Without it, the value of the
+// PriorityClass of the pod template is used, like the scheduler does it. Items without both get 0.
+func SetPriorities(ctx context.Context, _client client.Client, items []g.ScalingInfo) []g.ScalingInfo {
+	var priorityClasses *schedulingv1.PriorityClassList
+	for i, item := range items {
+		if priority, found := annotatedPriority(item); found {
+			items[i].Priority = priority
+			continue
+		}
+
+		if priorityClasses == nil {
+			priorityClasses = &schedulingv1.PriorityClassList{}
+			err := _client.List(ctx, priorityClasses)
+			if err != nil {
+				ctrl.Log.Error(err, "Cannot list the PriorityClasses. Items without the scaler/priority annotation get priority 0")
+			}
+		}
+		items[i].Priority = priorityClassValue(item.PodTemplate.PriorityClassName, priorityClasses.Items)
+	}
+	return items
+}
+
+func annotatedPriority(item g.ScalingInfo) (int32, bool) {
+	value, found := item.Annotations[constants.PriorityAnnotation]
+	if !found {
+		return 0, false
+	}
+	priority, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		ctrl.Log.Info("WARNING: Ignoring invalid priority annotation", "name", item.Name, "namespace", item.Namespace, "value", value)
+		return 0, false
+	}
+	return int32(priority), true
+}
+
+// PrioritySource tells where the priority of the item comes from, following the same rules as SetPriorities
+func PrioritySource(item g.ScalingInfo) string {
+	if _, found := annotatedPriority(item); found {
+		return constants.PriorityAnnotation + " annotation"
+	}
+	if item.PodTemplate.PriorityClassName != "" {
+		return "PriorityClass " + item.PodTemplate.PriorityClassName
+	}
+	return "global default PriorityClass"
+}
+
+// priorityClassValue returns the value of the PriorityClass. Without a class, the global default class counts.
+func priorityClassValue(name string, priorityClasses []schedulingv1.PriorityClass) int32 {
+	for _, priorityClass := range priorityClasses {
+		if (name != "" && priorityClass.Name == name) || (name == "" && priorityClass.GlobalDefault) {
+			return priorityClass.Value
+		}
+	}
+	return 0
+}
+
+// SortByPriority orders the items from the highest to the lowest priority. Items of the same priority are ordered by name.
+func SortByPriority(items []g.ScalingInfo) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Priority != items[j].Priority {
+			return items[i].Priority > items[j].Priority
+		}
+		return items[i].Name < items[j].Name
+	})
+}
+
+// ScalingOrder returns the order the items of a namespace are scaled in. Scale-downs go first, starting with the lowest
+// priority, so their resources are freed for the scale-ups. Scale-ups follow, starting with the highest priority.
+func ScalingOrder(items []g.ScalingInfo) []g.ScalingInfo {
+	scaleDowns, scaleUps := []g.ScalingInfo{}, []g.ScalingInfo{}
+	for _, item := range items {
+		if item.DesiredReplicas != -1 && item.DesiredReplicas < item.SpecReplica {
+			scaleDowns = append(scaleDowns, item)
+		} else {
+			scaleUps = append(scaleUps, item)
+		}
+	}
+	SortByPriority(scaleUps)
+	sort.SliceStable(scaleDowns, func(i, j int) bool {
+		if scaleDowns[i].Priority != scaleDowns[j].Priority {
+			return scaleDowns[i].Priority < scaleDowns[j].Priority
+		}
+		return scaleDowns[i].Name < scaleDowns[j].Name
+	})
+	return append(scaleDowns, scaleUps...)
+}
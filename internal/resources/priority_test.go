@@ -0,0 +1,77 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSetPriorities(t *testing.T) {
+	_client := fake.NewClientBuilder().
+		WithObjects(
+			&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "critical"}, Value: 1000},
+			&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Value: 10, GlobalDefault: true},
+		).
+		Build()
+
+	items := []g.ScalingInfo{
+		{Name: "annotated", Annotations: map[string]string{"scaler/priority": "5"}, PodTemplate: corev1.PodSpec{PriorityClassName: "critical"}},
+		{Name: "class", PodTemplate: corev1.PodSpec{PriorityClassName: "critical"}},
+		{Name: "globaldefault"},
+		{Name: "unknownclass", PodTemplate: corev1.PodSpec{PriorityClassName: "missing"}},
+		{Name: "invalid", Annotations: map[string]string{"scaler/priority": "high"}},
+	}
+	want := []int32{5, 1000, 10, 0, 10}
+
+	got := SetPriorities(context.TODO(), _client, items)
+	for i := range got {
+		if got[i].Priority != want[i] {
+			t.Errorf("SetPriorities() %s priority = %d, want %d", got[i].Name, got[i].Priority, want[i])
+		}
+	}
+}
+
+func TestScalingOrder(t *testing.T) {
+	item := func(name string, current, desired, priority int32) g.ScalingInfo {
+		return g.ScalingInfo{Name: name, SpecReplica: current, DesiredReplicas: desired, Priority: priority}
+	}
+	items := []g.ScalingInfo{
+		item("up-low", 1, 3, 1),
+		item("down-high", 3, 1, 10),
+		item("up-high", 1, 3, 10),
+		item("down-low", 3, 1, 1),
+		item("up-high-b", 1, 3, 10),
+	}
+	want := []string{"down-low", "down-high", "up-high", "up-high-b", "up-low"}
+
+	got := ScalingOrder(items)
+	for i := range want {
+		if got[i].Name != want[i] {
+			t.Errorf("ScalingOrder()[%d] = %s, want %s", i, got[i].Name, want[i])
+		}
+	}
+}
+
+func TestPrioritySource(t *testing.T) {
+	tests := []struct {
+		name string
+		item g.ScalingInfo
+		want string
+	}{
+		{name: "TestAnnotation", item: g.ScalingInfo{Annotations: map[string]string{"scaler/priority": "5"}, PodTemplate: corev1.PodSpec{PriorityClassName: "critical"}}, want: "scaler/priority annotation"},
+		{name: "TestPriorityClass", item: g.ScalingInfo{Annotations: map[string]string{"scaler/priority": "high"}, PodTemplate: corev1.PodSpec{PriorityClassName: "critical"}}, want: "PriorityClass critical"},
+		{name: "TestGlobalDefault", item: g.ScalingInfo{}, want: "global default PriorityClass"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrioritySource(tt.item); got != tt.want {
+				t.Errorf("PrioritySource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/states"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Progress of an item on its way to the desired replicas
+const (
+	ProgressFailed   = "Failed"
+	ProgressNoTarget = "NoTarget"
+	ProgressPending  = "Pending"
+	ProgressScaling  = "Scaling"
+	ProgressDone     = "Done"
+)
+
+// WithTargets resolves the applied state and the desired replicas of the items like the operator does. It returns the
+// items and the states of their namespaces.
+func WithTargets(ctx context.Context, _client client.Client, items []g.ScalingInfo, stateDefinitions states.States, clusterScalingStates v1alpha1.ClusterScalingStateList) ([]g.ScalingInfo, map[string]states.State, error) {
+	namespaceStates := map[string]states.State{}
+	for i, item := range items {
+		namespaceState, found := namespaceStates[item.Namespace]
+		if !found {
+			var err error
+			namespaceState, err = states.FetchNameSpaceState(ctx, _client, stateDefinitions, item.Namespace)
+			if err != nil {
+				return nil, nil, err
+			}
+			namespaceStates[item.Namespace] = namespaceState
+		}
+		items[i] = states.GetAppliedStateAndClassOnItem(item, namespaceState, clusterScalingStates, stateDefinitions)
+	}
+
+	// The desired replicas are set on the items themselves. Items without replicas for their state keep -1.
+	_, err := DetermineDesiredReplicas(items)
+	return items, namespaceStates, err
+}
+
+// Progress tells how far an item with resolved targets is on its way to the desired replicas
+func Progress(item g.ScalingInfo) string {
+	switch {
+	case item.Failure:
+		return ProgressFailed
+	case item.DesiredReplicas == -1:
+		return ProgressNoTarget
+	case item.SpecReplica != item.DesiredReplicas:
+		return ProgressPending
+	case item.ReadyReplicas < item.SpecReplica:
+		return ProgressScaling
+	}
+	return ProgressDone
+}
@@ -3,14 +3,22 @@ package resources
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	"github.com/containersol/prescale-operator/internal/notify"
+	"github.com/containersol/prescale-operator/internal/tracing"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
 	"github.com/go-logr/logr"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// DefaultWakeUpTimeout is the wake-up timeout of the workloads without the scaler/wake-up-timeout-seconds annotation
+const DefaultWakeUpTimeout = 10 * time.Minute
+
 func StepScale(ctx context.Context, _client client.Client, deploymentItem g.ScalingInfo, recorder record.EventRecorder, log logr.Logger) error {
 	var retryErr error = nil
 
@@ -18,16 +26,20 @@ func StepScale(ctx context.Context, _client client.Client, deploymentItem g.Scal
 	oldReplicaCount := deploymentItem.SpecReplica
 	desiredReplicaCount := deploymentItem.DesiredReplicas
 	initialDesiredReplicaCount := deploymentItem.DesiredReplicas
+	// Only a workload that starts at zero replicas wakes up. Others without ready pods are failing.
+	fromZero := oldReplicaCount == 0
 
 	// Loop step by step until deploymentItem has reached desiredreplica count.
 	var stepCondition bool = true
+	// The analysis gate is only evaluated between steps, once a step was taken
+	var stepped bool = false
 	for stepCondition {
 		// Refresh the deploymentItem
 		deploymentItem, _ = g.GetDenyList().GetDeploymentInfoFromList(deploymentItem)
 		// Get the desired replica count
 		desiredReplicaCount = deploymentItem.DesiredReplicas
 		// Check (and wait until) deployment is ready to scale
-		deploymentItem, err := WaitForReady(ctx, _client, deploymentItem, recorder, log)
+		deploymentItem, err := WaitForReady(ctx, _client, deploymentItem, fromZero, recorder, log)
 		if err != nil {
 			log.Error(err, "Error waiting for deployment to become ready")
 			return err
@@ -44,11 +56,32 @@ func StepScale(ctx context.Context, _client client.Client, deploymentItem g.Scal
 			stepReplicaCount = oldReplicaCount - 1
 		}
 		// check if desired is reached from a fresh item
-		if deploymentItem.ReadyReplicas == deploymentItem.DesiredReplicas {
+		if reachedDesiredReplicas(deploymentItem) {
 			stepCondition = false
 		} else {
-			// Attempt to scale by 1 step
-			retryErr = DoScaling(ctx, _client, deploymentItem, stepReplicaCount)
+			// Check the health of the workload after the previous step
+			if stepped {
+				retryErr = WaitForAnalysis(ctx, _client, deploymentItem, recorder, log)
+				if retryErr != nil && ctx.Err() != nil {
+					// The operator is shutting down or lost leadership. Don't put the item in failure state.
+					return retryErr
+				}
+			}
+			if retryErr == nil {
+				// Stop before the next step if scaling was paused in the meantime
+				if reason, paused := Paused(ctx, _client, deploymentItem); paused {
+					return PausedError{reason: reason}
+				}
+				// Attempt to scale by 1 step
+				stepCtx, span := tracing.Start(ctx, "ScaleStep", tracing.ItemAttributes(deploymentItem)...)
+				span.SetAttributes(tracing.StepReplicasKey.Int64(int64(stepReplicaCount)))
+				retryErr = DoScaling(stepCtx, _client, deploymentItem, stepReplicaCount)
+				tracing.End(span, retryErr)
+				if retryErr == nil {
+					metrics.IncScalingSteps(deploymentItem, "step")
+					stepped = true
+				}
+			}
 		}
 
 		if retryErr != nil {
@@ -56,6 +89,7 @@ func StepScale(ctx context.Context, _client client.Client, deploymentItem g.Scal
 			// Set failure state to true
 			g.GetDenyList().SetScalingItemOnList(deploymentItem, true, retryErr.Error(), desiredReplicaCount)
 			RegisterEvents(ctx, _client, recorder, retryErr, deploymentItem)
+			notify.ItemFailed(deploymentItem, retryErr.Error())
 			return retryErr
 		}
 	}
@@ -74,20 +108,30 @@ func RapidScale(ctx context.Context, _client client.Client, deploymentItem g.Sca
 	deploymentItem.IsBeingScaled = true
 	g.GetDenyList().SetScalingItemOnList(deploymentItem, deploymentItem.Failure, deploymentItem.FailureMessage, desiredReplicaCount)
 
-	deploymentItem, err := WaitForReady(ctx, _client, deploymentItem, recorder, log)
+	deploymentItem, err := WaitForReady(ctx, _client, deploymentItem, oldReplicaCount == 0, recorder, log)
 
 	if err != nil {
 		log.Error(err, "Error waiting for deployment to become ready")
 		return err
 	}
 
-	retryErr = DoScaling(ctx, _client, deploymentItem, desiredReplicaCount)
+	if reason, paused := Paused(ctx, _client, deploymentItem); paused {
+		return PausedError{reason: reason}
+	}
+
+	scaleCtx, span := tracing.Start(ctx, "RapidScale", tracing.ItemAttributes(deploymentItem)...)
+	retryErr = DoScaling(scaleCtx, _client, deploymentItem, desiredReplicaCount)
+	tracing.End(span, retryErr)
+	if retryErr == nil {
+		metrics.IncScalingSteps(deploymentItem, "rapid")
+	}
 
 	if retryErr != nil {
 		deploymentItem.IsBeingScaled = false
 		// Set failure state to true
 		g.GetDenyList().SetScalingItemOnList(deploymentItem, true, retryErr.Error(), desiredReplicaCount)
 		RegisterEvents(ctx, _client, recorder, retryErr, deploymentItem)
+		notify.ItemFailed(deploymentItem, retryErr.Error())
 		return retryErr
 	}
 
@@ -95,31 +139,51 @@ func RapidScale(ctx context.Context, _client client.Client, deploymentItem g.Sca
 
 }
 
-func WaitForReady(ctx context.Context, _client client.Client, deploymentItem g.ScalingInfo, recorder record.EventRecorder, log logr.Logger) (g.ScalingInfo, error) {
-	var err error
+// WaitForReady waits until the item settled after the last step. If the scaling started at zero replicas, the item is
+// waking up. Then its first pods get the wake-up timeout to become ready, instead of the progress deadline.
+func WaitForReady(ctx context.Context, _client client.Client, deploymentItem g.ScalingInfo, fromZero bool, recorder record.EventRecorder, log logr.Logger) (_ g.ScalingInfo, err error) {
+	ctx, span := tracing.Start(ctx, "WaitForReady", tracing.ItemAttributes(deploymentItem)...)
+	defer func() {
+		span.SetAttributes(tracing.ReadyReplicasKey.Int64(int64(deploymentItem.ReadyReplicas)))
+		tracing.End(span, err)
+	}()
 
 	stepReplicaCount := deploymentItem.SpecReplica
 	desiredReplicaCount := deploymentItem.DesiredReplicas
 
 	// Wait until deploymentItem is ready for the next step and check if it's failing for some reason
 	waitTime := time.Duration(time.Duration(deploymentItem.ProgressDeadline))*time.Second + time.Second
+	started := time.Now()
+	wakingUp := false
 
 	// Wait 2s at a time until timeout is reached
 	for stay, timeout := true, time.After(waitTime); stay; {
 		select {
+		case <-ctx.Done():
+			// The operator is shutting down or lost leadership. Don't put the item in failure state.
+			deploymentItem.IsBeingScaled = false
+			return deploymentItem, ctx.Err()
 		case <-timeout:
 			// If timeout
 			timeoutErr := ScaleError{
 				msg: fmt.Sprintf("Message on the cluster: %s | The operator decided that it can't scale that deployment or deploymentconfig!", deploymentItem.ConditionReason),
 			}
+			if wakingUp {
+				timeoutErr.msg = fmt.Sprintf("No pod became ready within the wake-up timeout of %s | %s", wakeUpTimeout(deploymentItem, waitTime), timeoutErr.msg)
+			}
 			deploymentItem.IsBeingScaled = false
 			RegisterEvents(ctx, _client, recorder, timeoutErr, deploymentItem)
 			// Set failure state to true
 			g.GetDenyList().SetScalingItemOnList(deploymentItem, true, timeoutErr.msg, deploymentItem.DesiredReplicas)
+			notify.ItemFailed(deploymentItem, timeoutErr.msg)
 			return deploymentItem, timeoutErr
 		default:
 			// While not timeout
-			time.Sleep(time.Second * 2)
+			select {
+			case <-ctx.Done():
+				continue
+			case <-time.After(time.Second * 2):
+			}
 			// Refresh the deploymentItem
 			deploymentItem, err = GetRefreshedScalingItem(ctx, _client, deploymentItem)
 			if err != nil {
@@ -129,21 +193,68 @@ func WaitForReady(ctx context.Context, _client client.Client, deploymentItem g.S
 				g.GetDenyList().RemoveFromList(deploymentItem)
 				return deploymentItem, err
 			}
+			metrics.SetItemReplicas(deploymentItem)
 
-			if deploymentItem.ReadyReplicas == stepReplicaCount || deploymentItem.SpecReplica == deploymentItem.ReadyReplicas {
+			if readyForNextStep(deploymentItem, stepReplicaCount) {
 				stay = false
+			} else if fromZero && !wakingUp && deploymentItem.SpecReplica > 0 && deploymentItem.ReadyReplicas == 0 {
+				// The first pods of a workload waking up from zero replicas often need new nodes and image pulls,
+				// so they get their own timeout.
+				wakingUp = true
+				timeout = time.After(wakeUpTimeout(deploymentItem, waitTime) - time.Since(started))
 			}
-			// k8s can't handle the deployment for some reason. We can't scale
-			if deploymentItem.ConditionReason == "ProgressDeadlineExceeded" {
+			// k8s can't handle the deployment for some reason. We can't scale. On the way down there's nothing to wait for, though,
+			// and waking up may take longer than the progress deadline of the workload. The wake-up timeout decides then.
+			if stay && deploymentItem.ConditionReason == "ProgressDeadlineExceeded" {
+				if wakingUp {
+					deploymentItem = g.GetDenyList().SetScalingItemOnList(deploymentItem, false, "", desiredReplicaCount)
+					continue
+				}
 				scaleErr := ScaleError{
 					msg: "The deployment is in a failing state on the cluster! ProgressDeadlineExceeded!",
 				}
 				deploymentItem.IsBeingScaled = false
 				g.GetDenyList().SetScalingItemOnList(deploymentItem, true, "ProgressDeadlineExceeded", desiredReplicaCount)
 				RegisterEvents(ctx, _client, recorder, scaleErr, deploymentItem)
+				notify.ItemFailed(deploymentItem, scaleErr.msg)
 				return deploymentItem, scaleErr
 			}
 		}
 	}
 	return deploymentItem, nil
 }
+
+// readyForNextStep tells if the item settled after the last step. On the way down and at zero replicas there is nothing
+// to wait for, as no pods need to become ready. So parking a workload at zero replicas doesn't wait for pods that may never
+// become ready. On the way up a workload with replicas isn't ready without ready pods, which matters when it wakes up from zero.
+func readyForNextStep(item g.ScalingInfo, stepReplicaCount int32) bool {
+	if item.SpecReplica == 0 || (item.DesiredReplicas >= 0 && item.DesiredReplicas < item.SpecReplica) {
+		return true
+	}
+	if item.SpecReplica > 0 && item.ReadyReplicas == 0 {
+		return false
+	}
+	return item.ReadyReplicas == stepReplicaCount || item.SpecReplica == item.ReadyReplicas
+}
+
+// reachedDesiredReplicas tells if the step scaler is done. A workload parked at zero replicas is done once its replicas are
+// set to zero, even if none of its pods were ready before.
+func reachedDesiredReplicas(item g.ScalingInfo) bool {
+	if item.DesiredReplicas == 0 {
+		return item.SpecReplica == 0
+	}
+	return item.ReadyReplicas == item.DesiredReplicas
+}
+
+// wakeUpTimeout returns how long the first pods of the item get to become ready when it wakes up, like from zero replicas:
+// the scaler/wake-up-timeout-seconds annotation, or the default, but not less than the usual wait.
+func wakeUpTimeout(item g.ScalingInfo, waitTime time.Duration) time.Duration {
+	timeout := DefaultWakeUpTimeout
+	if seconds, err := strconv.Atoi(item.Annotations[constants.WakeUpTimeoutAnnotation]); err == nil && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if waitTime > timeout {
+		return waitTime
+	}
+	return timeout
+}
@@ -0,0 +1,115 @@
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReadyForNextStep(t *testing.T) {
+	item := func(spec int32, ready int32, desired int32) g.ScalingInfo {
+		return g.ScalingInfo{SpecReplica: spec, ReadyReplicas: ready, DesiredReplicas: desired}
+	}
+	tests := []struct {
+		name string
+		item g.ScalingInfo
+		step int32
+		want bool
+	}{
+		{"TestScalingUp", item(3, 2, 5), 3, false},
+		{"TestScaledUp", item(3, 3, 5), 3, true},
+		{"TestScalingDownWithoutReadyPods", item(2, 0, 0), 3, true},
+		{"TestParkedAtZero", item(0, 0, 0), 1, true},
+		{"TestWakingUp", item(1, 0, 3), 0, false},
+		{"TestWokenUp", item(1, 1, 3), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readyForNextStep(tt.item, tt.step); got != tt.want {
+				t.Errorf("readyForNextStep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReachedDesiredReplicas(t *testing.T) {
+	if reachedDesiredReplicas(g.ScalingInfo{SpecReplica: 3, ReadyReplicas: 0, DesiredReplicas: 0}) {
+		t.Errorf("reachedDesiredReplicas() of a workload without ready pods = true, want it scaled to zero first")
+	}
+	if !reachedDesiredReplicas(g.ScalingInfo{SpecReplica: 0, ReadyReplicas: 0, DesiredReplicas: 0}) {
+		t.Errorf("reachedDesiredReplicas() of a workload parked at zero = false")
+	}
+	if !reachedDesiredReplicas(g.ScalingInfo{SpecReplica: 3, ReadyReplicas: 3, DesiredReplicas: 3}) {
+		t.Errorf("reachedDesiredReplicas() of a ready workload = false")
+	}
+}
+
+func TestWakeUpTimeout(t *testing.T) {
+	annotated := g.ScalingInfo{Annotations: map[string]string{"scaler/wake-up-timeout-seconds": "1800"}}
+	if got := wakeUpTimeout(annotated, time.Minute); got != 30*time.Minute {
+		t.Errorf("wakeUpTimeout() with the annotation = %s, want 30m", got)
+	}
+	if got := wakeUpTimeout(g.ScalingInfo{}, time.Minute); got != DefaultWakeUpTimeout {
+		t.Errorf("wakeUpTimeout() = %s, want the default", got)
+	}
+	if got := wakeUpTimeout(g.ScalingInfo{}, time.Hour); got != time.Hour {
+		t.Errorf("wakeUpTimeout() = %s, want at least the usual wait", got)
+	}
+	if got := wakeUpTimeout(annotated, time.Hour); got != time.Hour {
+		t.Errorf("wakeUpTimeout() with a shorter annotation = %s, want at least the usual wait", got)
+	}
+}
+
+func TestWaitForReadyParksWithoutReadyPods(t *testing.T) {
+	// A crashlooping workload never gets ready pods. That mustn't stop parking it at zero replicas.
+	replicas := int32(2)
+	deadline := int32(0)
+	deployment := &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       v1.DeploymentSpec{Replicas: &replicas, ProgressDeadlineSeconds: &deadline},
+		Status: v1.DeploymentStatus{Conditions: []v1.DeploymentCondition{
+			{Type: v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"},
+		}},
+	}
+	_client := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+	item := g.ScalingInfo{Name: "web", Namespace: "shop", SpecReplica: 3, DesiredReplicas: 0, ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}}
+	g.GetDenyList().SetScalingItemOnList(item, false, "", 0)
+	defer g.GetDenyList().RemoveFromList(item)
+
+	got, err := WaitForReady(context.TODO(), _client, item, false, record.NewFakeRecorder(10), ctrllog.NullLogger{})
+	if err != nil {
+		t.Fatalf("WaitForReady() on the way down error = %v", err)
+	}
+	if got.SpecReplica != 2 {
+		t.Errorf("WaitForReady() = %d replicas, want the refreshed item", got.SpecReplica)
+	}
+}
+
+func TestWaitForReadyFailsCrashloopingWorkload(t *testing.T) {
+	// Without ready pods, a workload that didn't start at zero replicas isn't waking up. The progress deadline applies.
+	replicas := int32(3)
+	deadline := int32(0)
+	deployment := &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec:       v1.DeploymentSpec{Replicas: &replicas, ProgressDeadlineSeconds: &deadline},
+		Status: v1.DeploymentStatus{Conditions: []v1.DeploymentCondition{
+			{Type: v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"},
+		}},
+	}
+	_client := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+	item := g.ScalingInfo{Name: "api", Namespace: "shop", SpecReplica: 3, DesiredReplicas: 5, ScalingItemType: g.ScalingItemType{ItemTypeName: "Deployment"}}
+	g.GetDenyList().SetScalingItemOnList(item, false, "", 5)
+	defer g.GetDenyList().RemoveFromList(item)
+
+	if _, err := WaitForReady(context.TODO(), _client, item, false, record.NewFakeRecorder(10), ctrllog.NullLogger{}); err == nil {
+		t.Errorf("WaitForReady() of a crashlooping workload error = nil, want ProgressDeadlineExceeded")
+	}
+}
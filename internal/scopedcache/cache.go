@@ -0,0 +1,142 @@
+package scopedcache
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// scopedCache watches namespaced objects only in a list of namespaces, so the operator doesn't need cluster-wide
+// permissions on them. Cluster-scoped objects, like the custom resources of the operator, are watched cluster-wide.
+// The multi namespace cache of controller-runtime alone can't serve cluster-scoped objects.
+type scopedCache struct {
+	cluster    cache.Cache
+	namespaces cache.Cache
+	scheme     *runtime.Scheme
+	mapper     meta.RESTMapper
+}
+
+// New returns a cache.NewCacheFunc that restricts the cache to the namespaces. Duplicates are ignored.
+func New(namespaces []string) cache.NewCacheFunc {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, namespace := range namespaces {
+		if !seen[namespace] {
+			seen[namespace] = true
+			unique = append(unique, namespace)
+		}
+	}
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if opts.Mapper == nil {
+			mapper, err := apiutil.NewDynamicRESTMapper(config)
+			if err != nil {
+				return nil, err
+			}
+			opts.Mapper = mapper
+		}
+
+		clusterOpts := opts
+		clusterOpts.Namespace = ""
+		cluster, err := cache.New(config, clusterOpts)
+		if err != nil {
+			return nil, err
+		}
+		namespaced, err := cache.MultiNamespacedCacheBuilder(unique)(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &scopedCache{cluster: cluster, namespaces: namespaced, scheme: opts.Scheme, mapper: opts.Mapper}, nil
+	}
+}
+
+// cacheFor returns the cache responsible for the kind
+func (c *scopedCache) cacheFor(gvk schema.GroupVersionKind) (cache.Cache, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return c.cluster, nil
+	}
+	return c.namespaces, nil
+}
+
+func (c *scopedCache) cacheForObject(obj runtime.Object) (cache.Cache, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.cacheFor(gvk)
+}
+
+func (c *scopedCache) cacheForList(list client.ObjectList) (cache.Cache, error) {
+	gvk, err := apiutil.GVKForObject(list, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	return c.cacheFor(gvk)
+}
+
+func (c *scopedCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	delegate, err := c.cacheForObject(obj)
+	if err != nil {
+		return err
+	}
+	return delegate.Get(ctx, key, obj)
+}
+
+func (c *scopedCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	delegate, err := c.cacheForList(list)
+	if err != nil {
+		return err
+	}
+	return delegate.List(ctx, list, opts...)
+}
+
+func (c *scopedCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	delegate, err := c.cacheForObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	return delegate.GetInformer(ctx, obj)
+}
+
+func (c *scopedCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	delegate, err := c.cacheFor(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return delegate.GetInformerForKind(ctx, gvk)
+}
+
+func (c *scopedCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	delegate, err := c.cacheForObject(obj)
+	if err != nil {
+		return err
+	}
+	return delegate.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start runs both caches until the context is done
+func (c *scopedCache) Start(ctx context.Context) error {
+	clusterErr := make(chan error, 1)
+	go func() {
+		clusterErr <- c.cluster.Start(ctx)
+	}()
+	if err := c.namespaces.Start(ctx); err != nil {
+		return err
+	}
+	return <-clusterErr
+}
+
+func (c *scopedCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.cluster.WaitForCacheSync(ctx) && c.namespaces.WaitForCacheSync(ctx)
+}
@@ -0,0 +1,179 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/resources"
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Capture captures the scaling custom resources and the replicas of all opted-in items
+func Capture(ctx context.Context, _client client.Client) (v1alpha1.SnapshotData, error) {
+	data := v1alpha1.SnapshotData{CapturedTime: metav1.Now()}
+
+	definitions := v1alpha1.ClusterScalingStateDefinitionList{}
+	err := _client.List(ctx, &definitions)
+	if err != nil {
+		return data, err
+	}
+	for _, definition := range definitions.Items {
+		data.ClusterScalingStateDefinitions = append(data.ClusterScalingStateDefinitions, v1alpha1.SnapshotStateDefinition{
+			Name:   definition.Name,
+			States: definition.Spec,
+			Hooks:  definition.Hooks,
+			DryRun: definition.Config.DryRun,
+		})
+	}
+
+	clusterScalingStates := v1alpha1.ClusterScalingStateList{}
+	err = _client.List(ctx, &clusterScalingStates)
+	if err != nil {
+		return data, err
+	}
+	for _, css := range clusterScalingStates.Items {
+		data.ClusterScalingStates = append(data.ClusterScalingStates, v1alpha1.SnapshotClusterScalingState{
+			Name:         css.Name,
+			State:        css.Spec.State,
+			ScalingClass: css.Spec.ScalingClass,
+		})
+	}
+
+	scalingStates := v1alpha1.ScalingStateList{}
+	err = _client.List(ctx, &scalingStates)
+	if err != nil {
+		return data, err
+	}
+	for _, ss := range scalingStates.Items {
+		data.ScalingStates = append(data.ScalingStates, v1alpha1.SnapshotScalingState{
+			Namespace: ss.Namespace,
+			Name:      ss.Name,
+			State:     ss.Spec.State,
+			DryRun:    ss.Config.DryRun,
+		})
+	}
+
+	items, err := resources.ScalingItemNamespaceLister(ctx, _client, "", config.OptInSelector())
+	if err != nil {
+		return data, err
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, v1alpha1.SnapshotItem{
+			Namespace: item.Namespace,
+			Kind:      item.ItemTypeName,
+			Name:      item.Name,
+			Replicas:  item.SpecReplica,
+		})
+	}
+	sort.Slice(data.Items, func(i, j int) bool {
+		if data.Items[i].Namespace != data.Items[j].Namespace {
+			return data.Items[i].Namespace < data.Items[j].Namespace
+		}
+		return data.Items[i].Name < data.Items[j].Name
+	})
+
+	return data, nil
+}
+
+// Diff returns the items whose replicas differ from the snapshot. Items that don't exist anymore have -1 current replicas.
+func Diff(ctx context.Context, _client client.Client, data v1alpha1.SnapshotData) ([]v1alpha1.SnapshotItemDrift, error) {
+	drift := []v1alpha1.SnapshotItemDrift{}
+	for _, snapshotItem := range data.Items {
+		current := int32(-1)
+		item, err := currentItem(ctx, _client, snapshotItem)
+		if err == nil {
+			current = item.SpecReplica
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if current != snapshotItem.Replicas {
+			drift = append(drift, v1alpha1.SnapshotItemDrift{
+				Namespace:        snapshotItem.Namespace,
+				Kind:             snapshotItem.Kind,
+				Name:             snapshotItem.Name,
+				SnapshotReplicas: snapshotItem.Replicas,
+				CurrentReplicas:  current,
+			})
+		}
+	}
+	return drift, nil
+}
+
+// Restore restores the scaling custom resources of the snapshot and returns the items whose replicas differ from the
+// snapshot. The items are not scaled here, so the restore doesn't race the operator: they have to be reconciled by the
+// operator, which scales them to the replicas of their restored states with the quota checks and the deny list.
+// Captured replicas that differ from the state of the item are only kept if its enforcement mode leaves them alone.
+// Custom resources created after the snapshot are left alone. The restore carries on past failures and returns all of them.
+func Restore(ctx context.Context, _client client.Client, data v1alpha1.SnapshotData) ([]g.ScalingInfo, error) {
+	errs := []error{}
+
+	// The states have to be defined before they are set
+	for _, definition := range data.ClusterScalingStateDefinitions {
+		obj := &v1alpha1.ClusterScalingStateDefinition{ObjectMeta: metav1.ObjectMeta{Name: definition.Name}}
+		_, err := controllerutil.CreateOrUpdate(ctx, _client, obj, func() error {
+			obj.Spec = definition.States
+			obj.Hooks = definition.Hooks
+			obj.Config.DryRun = definition.DryRun
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ClusterScalingStateDefinition %s: %w", definition.Name, err))
+		}
+	}
+	for _, css := range data.ClusterScalingStates {
+		obj := &v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: css.Name}}
+		_, err := controllerutil.CreateOrUpdate(ctx, _client, obj, func() error {
+			obj.Spec.State = css.State
+			obj.Spec.ScalingClass = css.ScalingClass
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ClusterScalingState %s: %w", css.Name, err))
+		}
+	}
+	for _, ss := range data.ScalingStates {
+		obj := &v1alpha1.ScalingState{ObjectMeta: metav1.ObjectMeta{Name: ss.Name, Namespace: ss.Namespace}}
+		_, err := controllerutil.CreateOrUpdate(ctx, _client, obj, func() error {
+			obj.Spec.State = ss.State
+			obj.Config.DryRun = ss.DryRun
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ScalingState %s/%s: %w", ss.Namespace, ss.Name, err))
+		}
+	}
+
+	items := []g.ScalingInfo{}
+	for _, snapshotItem := range data.Items {
+		item, err := currentItem(ctx, _client, snapshotItem)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s/%s: %w", snapshotItem.Kind, snapshotItem.Namespace, snapshotItem.Name, err))
+			continue
+		}
+		if item.SpecReplica != snapshotItem.Replicas {
+			items = append(items, item)
+		}
+	}
+
+	return items, utilerrors.NewAggregate(errs)
+}
+
+func currentItem(ctx context.Context, _client client.Client, snapshotItem v1alpha1.SnapshotItem) (g.ScalingInfo, error) {
+	obj, err := resources.ScalingItemObject(ctx, _client, g.ScalingInfo{
+		Namespace:       snapshotItem.Namespace,
+		Name:            snapshotItem.Name,
+		ScalingItemType: g.ScalingItemType{ItemTypeName: snapshotItem.Kind},
+	})
+	if err != nil {
+		return g.ScalingInfo{}, err
+	}
+	return resources.ScalingItemFromObject(obj)
+}
@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/containersol/prescale-operator/api/v1alpha1"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func deployment(name string, replicas int32, labels map[string]string) *v1.Deployment {
+	return &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: labels},
+		Spec:       v1.DeploymentSpec{Replicas: &replicas, ProgressDeadlineSeconds: new(int32)},
+	}
+}
+
+func TestCaptureDiffRestore(t *testing.T) {
+	_ = v1alpha1.AddToScheme(scheme.Scheme)
+	optedIn := map[string]string{"scaler/opt-in": "true"}
+	_client := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+			&v1alpha1.ClusterScalingStateDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "states"},
+				Spec:       []v1alpha1.States{{Name: "peak", Priority: 1}, {Name: "bau", Priority: 5}},
+			},
+			&v1alpha1.ClusterScalingState{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1alpha1.ClusterScalingStateSpec{State: "peak"}},
+			&v1alpha1.ScalingState{ObjectMeta: metav1.ObjectMeta{Name: "scalingstate-shop", Namespace: "shop"}, Spec: v1alpha1.ScalingStateSpec{State: "bau"}},
+			deployment("web", 4, optedIn),
+			deployment("worker", 2, optedIn),
+			deployment("unrelated", 1, nil),
+		).
+		Build()
+	ctx := context.TODO()
+
+	data, err := Capture(ctx, _client)
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	wantItems := []v1alpha1.SnapshotItem{
+		{Namespace: "shop", Kind: "Deployment", Name: "web", Replicas: 4},
+		{Namespace: "shop", Kind: "Deployment", Name: "worker", Replicas: 2},
+	}
+	if !reflect.DeepEqual(data.Items, wantItems) {
+		t.Errorf("Capture() items = %v, want %v", data.Items, wantItems)
+	}
+	if len(data.ClusterScalingStateDefinitions) != 1 || len(data.ClusterScalingStates) != 1 || len(data.ScalingStates) != 1 {
+		t.Errorf("Capture() = %v, want the definition, the ClusterScalingState and the ScalingState", data)
+	}
+
+	// The event is over: the cluster state changed, web scaled down and worker is gone
+	css := &v1alpha1.ClusterScalingState{}
+	_ = _client.Get(ctx, client.ObjectKey{Name: "default"}, css)
+	css.Spec.State = "bau"
+	_ = _client.Update(ctx, css)
+	web := &v1.Deployment{}
+	_ = _client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "web"}, web)
+	*web.Spec.Replicas = 1
+	_ = _client.Update(ctx, web)
+	_ = _client.Delete(ctx, deployment("worker", 2, optedIn))
+
+	drift, err := Diff(ctx, _client, data)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	wantDrift := []v1alpha1.SnapshotItemDrift{
+		{Namespace: "shop", Kind: "Deployment", Name: "web", SnapshotReplicas: 4, CurrentReplicas: 1},
+		{Namespace: "shop", Kind: "Deployment", Name: "worker", SnapshotReplicas: 2, CurrentReplicas: -1},
+	}
+	if !reflect.DeepEqual(drift, wantDrift) {
+		t.Errorf("Diff() = %v, want %v", drift, wantDrift)
+	}
+
+	// The missing worker is reported, the states are restored and web is left to the operator
+	items, err := Restore(ctx, _client, data)
+	if err == nil {
+		t.Errorf("Restore() error = nil, want an error for the missing worker")
+	}
+	_ = _client.Get(ctx, client.ObjectKey{Name: "default"}, css)
+	if css.Spec.State != "peak" {
+		t.Errorf("Restore() ClusterScalingState state = %v, want peak", css.Spec.State)
+	}
+	if len(items) != 1 || items[0].Name != "web" {
+		t.Errorf("Restore() items = %v, want web", items)
+	}
+	_ = _client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "web"}, web)
+	if *web.Spec.Replicas != 1 {
+		t.Errorf("Restore() web replicas = %v, want 1 until the operator scales it", *web.Spec.Replicas)
+	}
+}
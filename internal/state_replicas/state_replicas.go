@@ -9,7 +9,12 @@ import (
 	annotations2 "github.com/containersol/prescale-operator/pkg/utils/annotations"
 )
 
-const StateReplicaAnnotationPrefix = "scaler/state-"
+const (
+	StateReplicaAnnotationPrefix = "scaler/state-"
+	StateReplicaAnnotationSuffix = "-replicas"
+	// StateMultiplierAnnotationSuffix marks the replica multipliers a namespace can set for a state
+	StateMultiplierAnnotationSuffix = "-multiplier"
+)
 
 type StateReplica struct {
 	Name     string
@@ -54,7 +59,11 @@ func NewStateReplicasFromAnnotations(annotations map[string]string) (StateReplic
 	stateReplicas := StateReplicas{}
 	states := annotations2.FilterByKeyPrefix(StateReplicaAnnotationPrefix, annotations)
 	for key, value := range states {
-		stateName := key[len(StateReplicaAnnotationPrefix) : len(key)-len("-replicas")]
+		// Other annotations with the prefix, like multipliers, are no replica counts
+		if !strings.HasSuffix(key, StateReplicaAnnotationSuffix) {
+			continue
+		}
+		stateName := key[len(StateReplicaAnnotationPrefix) : len(key)-len(StateReplicaAnnotationSuffix)]
 		replicas, err := strconv.Atoi(value)
 		if err != nil {
 			return stateReplicas, errors.New("replica count in annotation is not a valid integer")
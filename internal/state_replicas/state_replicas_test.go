@@ -80,3 +80,18 @@ func TestStateReplicas_GetStateReturnErrorIfNotStatesMatch(t *testing.T) {
 		t.Errorf("Expected GetState to fail, but it passed")
 	}
 }
+
+func TestNewStateReplicasFromAnnotationsIgnoresMultipliers(t *testing.T) {
+	annotations := map[string]string{
+		"scaler/state-peak-replicas":   "5",
+		"scaler/state-peak-multiplier": "1.5",
+	}
+	got, err := state_replicas.NewStateReplicasFromAnnotations(annotations)
+	if err != nil {
+		t.Errorf("Failed to process state replicas: %v", err)
+	}
+	expected := []state_replicas.StateReplica{{Name: "peak", Replicas: 5}}
+	if !reflect.DeepEqual(got.GetStates(), expected) {
+		t.Errorf("Could not calculate state replicas. Expected %s, Got %s", expected, got.GetStates())
+	}
+}
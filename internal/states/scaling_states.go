@@ -144,14 +144,32 @@ func GetClusterScalingState(ctx context.Context, _client client.Client) (string,
 		}
 	}
 
-	return clusterScalingStates.Items[0].Spec.State, nil
+	return EffectiveState(clusterScalingStates.Items[0]), nil
 }
 
 func GetClusterScalingStateNew(css v1alpha1.ClusterScalingState) string {
-	return css.Spec.State
+	return EffectiveState(css)
 
 }
 
+// EffectiveState is the state the items of the scaling class are scaled to. While the pre hooks of a transition run,
+// or after one of them aborted it, the class keeps the state from before the transition.
+func EffectiveState(css v1alpha1.ClusterScalingState) string {
+	transition := css.Status.Transition
+	if transition == nil || transition.State != css.Spec.State {
+		return css.Spec.State
+	}
+	return TransitionState(*transition)
+}
+
+// TransitionState is the state the items are scaled to in the current phase of the transition
+func TransitionState(transition v1alpha1.TransitionStatus) string {
+	if transition.FromState != "" && (transition.Phase == v1alpha1.TransitionPreHooks || transition.Phase == v1alpha1.TransitionAborted) {
+		return transition.FromState
+	}
+	return transition.State
+}
+
 func fetchClusterState(ctx context.Context, _client client.Client, stateDefinitions States) (State, error) {
 	clusterStateName, err := GetClusterScalingState(ctx, _client)
 	if err != nil {
@@ -303,7 +321,7 @@ func FindScalingClassOnClusterScalingState(itemClass ScalingClass, clusterScalin
 		cssClass := GetAppliedScalingClassFromClusterScalingState(css)
 
 		if reflect.DeepEqual(cssClass, itemClass) {
-			stateName := css.Spec.State
+			stateName := EffectiveState(css)
 			stateOnCss := State{}
 			err := stateDefinitions.FindState(stateName, &stateOnCss)
 			if err != nil {
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	g "github.com/containersol/prescale-operator/pkg/utils/global"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone disables tracing. Spans are still created, but dropped right away.
+	ExporterNone = "none"
+	// ExporterOTLP sends the spans to an OTLP collector via gRPC
+	ExporterOTLP = "otlp"
+
+	tracerName  = "github.com/containersol/prescale-operator"
+	serviceName = "prescale-operator"
+)
+
+// Attribute keys used on the spans
+const (
+	NamespaceKey       = attribute.Key("prescale.namespace")
+	NameKey            = attribute.Key("prescale.name")
+	KindKey            = attribute.Key("prescale.kind")
+	StateKey           = attribute.Key("prescale.state")
+	ClassKey           = attribute.Key("prescale.class")
+	OriginKey          = attribute.Key("prescale.origin")
+	DryRunKey          = attribute.Key("prescale.dry_run")
+	SpecReplicasKey    = attribute.Key("prescale.replicas.spec")
+	ReadyReplicasKey   = attribute.Key("prescale.replicas.ready")
+	DesiredReplicasKey = attribute.Key("prescale.replicas.desired")
+	StepReplicasKey    = attribute.Key("prescale.replicas.step")
+)
+
+// Setup configures the global tracer provider with the given exporter.
+// The returned function flushes and stops the exporter and should be called on shutdown.
+func Setup(ctx context.Context, exporter string, endpoint string, insecure bool) (func(context.Context) error, error) {
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		// Without an endpoint the exporter falls back to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+		}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exp),
+			sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+		)
+		otel.SetTracerProvider(provider)
+		return provider.Shutdown, nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q. Supported are %q and %q", exporter, ExporterNone, ExporterOTLP)
+	}
+}
+
+// SetupInMemory configures the global tracer provider to keep all spans in memory. Meant for tests.
+func SetupInMemory() *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	return exp
+}
+
+// Start starts a span as child of the span in the context, if there is one
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ItemAttributes describes a scaling item on a span
+func ItemAttributes(item g.ScalingInfo) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		NamespaceKey.String(item.Namespace),
+		NameKey.String(item.Name),
+		KindKey.String(item.ScalingItemType.ItemTypeName),
+		StateKey.String(item.State),
+		ClassKey.String(item.ScalingClass),
+		SpecReplicasKey.Int64(int64(item.SpecReplica)),
+		ReadyReplicasKey.Int64(int64(item.ReadyReplicas)),
+		DesiredReplicasKey.Int64(int64(item.DesiredReplicas)),
+	}
+}
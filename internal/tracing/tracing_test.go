@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestSpansAreRecordedInMemory(t *testing.T) {
+	exp := SetupInMemory()
+
+	ctx, root := Start(context.Background(), "root", StateKey.String("peak"))
+	_, child := Start(ctx, "child")
+	End(child, errors.New("scaling failed"))
+	End(root, nil)
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected %d spans, got %d", 2, len(spans))
+	}
+	childSpan, rootSpan := spans[0], spans[1]
+	if childSpan.Parent.SpanID() != rootSpan.SpanContext.SpanID() {
+		t.Errorf("Child span is not a child of the root span")
+	}
+	if childSpan.Status.Code != codes.Error {
+		t.Errorf("Error was not recorded on the span. Got status %v", childSpan.Status.Code)
+	}
+	if rootSpan.Status.Code == codes.Error {
+		t.Errorf("Root span without error has an error status")
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), "carrier-pigeon", "", false)
+	if err == nil {
+		t.Errorf("Expected an error for an unknown exporter")
+	}
+
+	shutdown, err := Setup(context.Background(), ExporterNone, "", false)
+	if err != nil {
+		t.Errorf("Disabled tracing returned an error: %s", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown of disabled tracing returned an error: %s", err)
+	}
+}
@@ -5,18 +5,23 @@ import (
 	"strconv"
 
 	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
 )
 
-// OptinLabelExists checks if the opt-in label exists in the target object and returns its value
+// OptinLabelExists checks if the opt-in label exists in the target object and returns its value.
+// If an opt-in selector is configured, it returns whether the selector matches the labels instead.
 func OptinLabelExists(labels map[string]string) (bool, error) {
 
 	var optinlabel bool
 
-	for k := range constants.OptInLabel {
-		if v, found := labels[k]; found {
-			optinlabel, err := strconv.ParseBool(v)
-			return optinlabel, err
-		}
+	operatorConfig := config.Get()
+	if operatorConfig.OptInSelector != nil {
+		return config.OptedIn(labels), nil
+	}
+
+	if v, found := labels[operatorConfig.OptInLabel]; found {
+		optinlabel, err := strconv.ParseBool(v)
+		return optinlabel, err
 	}
 	return optinlabel, errors.New(constants.LabelNotFound)
 }
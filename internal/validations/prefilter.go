@@ -1,34 +1,46 @@
 package validations
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"time"
 
 	constants "github.com/containersol/prescale-operator/internal"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/metrics"
+	nd "github.com/containersol/prescale-operator/internal/namespace_defaults"
 	"github.com/containersol/prescale-operator/pkg/utils/annotations"
 	g "github.com/containersol/prescale-operator/pkg/utils/global"
 	"github.com/containersol/prescale-operator/pkg/utils/labels"
 	redisalpha "github.com/containersolutions/redis-operator/api/v1alpha1"
 	ocv1 "github.com/openshift/api/apps/v1"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 // PreFilter for incoming changes of deployments or deploymentconfigs. We only care about changes on the opt-in label, replica count and annotations.
-func PreFilter(r record.EventRecorder) predicate.Predicate {
+// Namespaces only pass when their annotations change, as they opt in their workloads and hold their defaults.
+// The reader is used to look up the annotations of the namespace of a workload.
+func PreFilter(r record.EventRecorder, reader client.Reader) predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
+			if _, isNamespace := e.ObjectNew.(*corev1.Namespace); isNamespace {
+				return AssessAnnotationChange(e)
+			}
+
 			// Ignore updates to CR status in which case metadata.Generation does not change
 			deploymentName := e.ObjectNew.GetName()
 			nameSpace := e.ObjectNew.GetNamespace()
 			var oldoptin, newoptin, replicaChange, annotationchange bool
 
-			oldoptin = labels.GetLabelValueBool(e.ObjectOld.GetLabels(), "scaler/opt-in")
-			newoptin = labels.GetLabelValueBool(e.ObjectNew.GetLabels(), "scaler/opt-in")
+			oldoptin = workloadOptedIn(reader, nameSpace, e.ObjectOld.GetLabels())
+			newoptin = workloadOptedIn(reader, nameSpace, e.ObjectNew.GetLabels())
 
 			// check if scalingclass has changed
 			oldclass := labels.GetLabelValueString(e.ObjectOld.GetLabels(), "scaler/scaling-class")
@@ -46,6 +58,7 @@ func PreFilter(r record.EventRecorder) predicate.Predicate {
 
 			// Deployment opted out. Don't do anything
 			if !newoptin {
+				metrics.ForgetItem(item)
 				if g.GetDenyList().IsBeingScaled(item) {
 					// The deployment is being scaled at the moment! Notify scaler to abort.
 					log := ctrl.Log.
@@ -61,10 +74,23 @@ func PreFilter(r record.EventRecorder) predicate.Predicate {
 			replicaChange = AssesReplicaChange(e)
 			annotationchange = AssessAnnotationChange(e)
 
+			// A manual retry brings back items the operator gave up on
+			if AssessRetryAnnotation(e) {
+				if g.GetDenyList().IsDeploymentInFailureState(item) {
+					log := ctrl.Log.
+						WithValues("Name", item.Name).
+						WithValues("Namespace", item.Namespace)
+					log.Info("Manual retry requested. Removing the item from failure state")
+					g.GetDenyList().RemoveFromList(item)
+				}
+				generateRetryEvent(e, r)
+				return true
+			}
+
 			// don't reconcile if the stepscale annotation is present.
 			//stepScaleActive := AssessStepScaleAnnotation(e)
 
-			// Let reconciler_cron handle those deployments
+			// Let the ScalingExecutor retry those deployments
 			if g.GetDenyList().IsDeploymentInFailureState(item) {
 				return false
 			}
@@ -97,9 +123,14 @@ func PreFilter(r record.EventRecorder) predicate.Predicate {
 				return false
 			}
 
+			// New namespaces have no workloads yet
+			if _, isNamespace := e.Object.(*corev1.Namespace); isNamespace {
+				return false
+			}
+
 			newlabels := e.Object.GetLabels()
 
-			newoptin := labels.GetLabelValueBool(newlabels, "scaler/opt-in")
+			newoptin := workloadOptedIn(reader, e.Object.GetNamespace(), newlabels)
 			deploymentName := e.Object.GetName()
 
 			if newoptin {
@@ -111,6 +142,10 @@ func PreFilter(r record.EventRecorder) predicate.Predicate {
 			return newoptin
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
+			if _, isNamespace := e.Object.(*corev1.Namespace); isNamespace {
+				return false
+			}
+
 			// The deployment got deleted. Regardless of the failure state, we need to delete the item from the list.
 			item := g.ScalingInfo{
 				Name:      e.Object.GetName(),
@@ -120,6 +155,7 @@ func PreFilter(r record.EventRecorder) predicate.Predicate {
 			if g.GetDenyList().IsInConcurrentList(item) {
 				g.GetDenyList().RemoveFromList(item)
 			}
+			metrics.ForgetItem(item)
 
 			return false
 		},
@@ -139,6 +175,14 @@ func AssessAnnotationChange(e event.UpdateEvent) bool {
 	return false
 }
 
+// AssessRetryAnnotation returns true if the retry annotation was set or changed
+func AssessRetryAnnotation(e event.UpdateEvent) bool {
+	retryNew, foundNew := e.ObjectNew.GetAnnotations()[constants.RetryAnnotation]
+	retryOld := e.ObjectOld.GetAnnotations()[constants.RetryAnnotation]
+
+	return foundNew && retryNew != retryOld
+}
+
 func AssesReplicaChange(e event.UpdateEvent) bool {
 	var replicasOld, replicasNew *int32
 	if reflect.TypeOf(e.ObjectNew) == reflect.TypeOf(&ocv1.DeploymentConfig{}) {
@@ -184,6 +228,10 @@ func generateOptInLabelUpdateEvent(e event.UpdateEvent, r record.EventRecorder,
 
 }
 
+func generateRetryEvent(e event.UpdateEvent, r record.EventRecorder) {
+	r.Event(e.ObjectNew, "Normal", "PreScalingOperator", fmt.Sprintf("Manual retry requested for the %s object", e.ObjectNew.GetName()))
+}
+
 func generateOptInLabelCreateEvent(e event.CreateEvent, r record.EventRecorder, newoptin bool) {
 
 	if reflect.TypeOf(e.Object) == reflect.TypeOf(&ocv1.DeploymentConfig{}) {
@@ -195,3 +243,24 @@ func generateOptInLabelCreateEvent(e event.CreateEvent, r record.EventRecorder,
 		r.Event(e.Object.(*redisalpha.RedisCluster), "Normal", "PreScalingOperator", fmt.Sprintf("The %s object has just opted-in", e.Object.(*redisalpha.RedisCluster).Name))
 	}
 }
+
+// workloadOptedIn tells if the workload with these labels is opted in. The namespace is only looked up if the labels
+// of the workload don't decide on their own.
+func workloadOptedIn(reader client.Reader, namespace string, workloadLabels map[string]string) bool {
+	if config.OptedIn(workloadLabels) {
+		return true
+	}
+	if nd.OptedOut(workloadLabels) {
+		return false
+	}
+	return nd.WorkloadOptedIn(workloadLabels, getNamespaceAnnotations(reader, namespace))
+}
+
+// getNamespaceAnnotations returns the annotations of the namespace, or none if it can't be read
+func getNamespaceAnnotations(reader client.Reader, namespace string) map[string]string {
+	namespaceAnnotations, err := nd.Annotations(context.Background(), reader, namespace)
+	if err != nil {
+		ctrl.Log.Error(err, fmt.Sprintf("Error getting namespace %s. Ignoring its annotations", namespace))
+	}
+	return namespaceAnnotations
+}
@@ -0,0 +1,51 @@
+package validations
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// countingReader counts the reads of the namespace
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	r.gets++
+	return r.Reader.Get(ctx, key, obj)
+}
+
+func TestWorkloadOptedIn(t *testing.T) {
+	reader := &countingReader{Reader: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Annotations: map[string]string{"scaler/opt-in": "true"}}},
+	).Build()}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+		gets   int
+	}{
+		{"opted in by the label", map[string]string{"scaler/opt-in": "true"}, true, 0},
+		{"opted out by the label", map[string]string{"scaler/opt-in": "false"}, false, 0},
+		{"opted in by the namespace", map[string]string{}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader.gets = 0
+			if got := workloadOptedIn(reader, "shop", tt.labels); got != tt.want {
+				t.Errorf("workloadOptedIn() = %v, want %v", got, tt.want)
+			}
+			if reader.gets != tt.gets {
+				t.Errorf("workloadOptedIn() read the namespace %d times, want %d", reader.gets, tt.gets)
+			}
+		})
+	}
+}
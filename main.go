@@ -17,13 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/containersol/prescale-operator/internal/validations"
-	"github.com/joho/godotenv"
-	"github.com/robfig/cron"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -34,12 +35,21 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	scalingv1alpha1 "github.com/containersol/prescale-operator/api/v1alpha1"
 	"github.com/containersol/prescale-operator/controllers"
+	"github.com/containersol/prescale-operator/internal/analysis"
+	"github.com/containersol/prescale-operator/internal/api"
+	"github.com/containersol/prescale-operator/internal/config"
+	"github.com/containersol/prescale-operator/internal/hooks"
+	"github.com/containersol/prescale-operator/internal/notify"
+	"github.com/containersol/prescale-operator/internal/quotas"
 	r "github.com/containersol/prescale-operator/internal/reconciler"
+	"github.com/containersol/prescale-operator/internal/scopedcache"
+	"github.com/containersol/prescale-operator/internal/tracing"
 	redisalpha "github.com/containersolutions/redis-operator/api/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -61,11 +71,35 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var scalingWorkers int
+	var maxScalingAttempts int
+	var tracingExporter string
+	var otlpEndpoint string
+	var otlpInsecure bool
+	var capacityCheck bool
+	var quotaPolicy string
+	var apiAddr string
+	var apiCertFile string
+	var apiKeyFile string
+	var apiInsecure bool
+	var analysisPrometheusURL string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&scalingWorkers, "scaling-workers", r.DefaultScalingWorkers, "The number of scaling items the operator scales in parallel.")
+	flag.IntVar(&maxScalingAttempts, "max-scaling-attempts", r.DefaultMaxScalingAttempts, "The number of attempts to scale a failing item before the operator gives up on it.")
+	flag.StringVar(&tracingExporter, "tracing-exporter", tracing.ExporterNone, "Where to export traces to. One of 'none' or 'otlp'.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The host:port of the OTLP gRPC collector. Defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false, "Connect to the OTLP collector without TLS.")
+	flag.BoolVar(&capacityCheck, "capacity-check", false, "Check if the nodes have enough allocatable resources left for the pods a transition adds. Default of the PreScalingOperatorConfig.")
+	flag.StringVar(&quotaPolicy, "quota-policy", quotas.QuotaPolicyAllOrNothing, "What to do if a scale-up exceeds the ResourceQuotas of a namespace. One of 'all-or-nothing', 'proportional' or 'priority'. Default of the PreScalingOperatorConfig.")
+	flag.StringVar(&apiAddr, "api-bind-address", "0", "The address the management API binds to. Set this to '0' to disable it.")
+	flag.StringVar(&apiCertFile, "api-tls-cert-file", "", "The TLS certificate of the management API. It's required unless --api-insecure is set.")
+	flag.StringVar(&apiKeyFile, "api-tls-key-file", "", "The TLS key of the management API.")
+	flag.BoolVar(&apiInsecure, "api-insecure", false, "Serve the management API over plain HTTP without a TLS certificate. Only fit for a sidecar or a service mesh.")
+	flag.StringVar(&analysisPrometheusURL, "analysis-prometheus-url", "", "The Prometheus compatible API the analysis gates query if they don't set an address, like http://prometheus.monitoring:9090.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -73,45 +107,153 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	if !quotas.IsQuotaPolicy(quotaPolicy) {
+		setupLog.Error(fmt.Errorf("unknown quota policy %q", quotaPolicy), "invalid --quota-policy")
+		os.Exit(1)
+	}
+
+	// The flags and the deprecated environment variable are the defaults for the fields the PreScalingOperatorConfig leaves out
+	defaults := config.Defaults()
+	defaults.CapacityCheck = capacityCheck
+	defaults.QuotaPolicy = quotaPolicy
+	if value := os.Getenv(constants.EnvMaxConcurrentNamespaceReconciles); value != "" {
+		maxConcurrentNsReconcile, err := strconv.Atoi(value)
+		if err != nil || maxConcurrentNsReconcile < 1 {
+			setupLog.Error(fmt.Errorf("invalid value %q", value), "ignoring "+constants.EnvMaxConcurrentNamespaceReconciles)
+		} else {
+			setupLog.Info("WARNING: " + constants.EnvMaxConcurrentNamespaceReconciles + " is deprecated. Use maxConcurrentNamespaceReconciles of the PreScalingOperatorConfig instead")
+			defaults.MaxConcurrentNamespaceReconciles = int32(maxConcurrentNsReconcile)
+		}
+	}
+	config.SetDefaults(defaults)
+	analysis.SetDefaultAddress(analysisPrometheusURL)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingExporter, otlpEndpoint, otlpInsecure)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "problem flushing traces")
+		}
+	}()
+
+	restConfig := ctrl.GetConfigOrDie()
+
+	// The config decides the namespaces the cache watches and the MaxConcurrentReconciles of the controllers,
+	// so it is read before the manager and its cache are created
+	configReader, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err == nil {
+		err = controllers.LoadOperatorConfig(context.Background(), configReader)
+	}
+	if err != nil {
+		setupLog.Error(err, "unable to load the PreScalingOperatorConfig. Starting with the defaults")
+	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	managerOptions := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "14b492ce.prescale.com",
-	})
+	}
+	if namespaces := config.WatchedNamespaces(); namespaces != nil {
+		setupLog.Info("Watching only the included namespaces", "namespaces", namespaces)
+		// Dry run reports of cluster-wide resources are written to the namespace of the operator
+		managerOptions.NewCache = scopedcache.New(append(namespaces, r.OperatorNamespace()))
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, managerOptions)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.ClusterScalingStateDefinitionReconciler{
+	// All scaling work runs on the executor. It is started and stopped by the manager.
+	scalingExecutor := r.NewScalingExecutor(mgr.GetClient(), mgr.GetEventRecorderFor("scaling-executor"), scalingWorkers, maxScalingAttempts)
+	if err = mgr.Add(scalingExecutor); err != nil {
+		setupLog.Error(err, "unable to add scaling executor")
+		os.Exit(1)
+	}
+	if err = mgr.Add(r.NewStateExpirer(mgr.GetClient(), mgr.GetEventRecorderFor("state-expirer"))); err != nil {
+		setupLog.Error(err, "unable to add state expirer")
+		os.Exit(1)
+	}
+	// The Secrets with the URLs of the notifications are read directly, so the cache doesn't have to watch all Secrets
+	if err = mgr.Add(notify.NewDispatcher(mgr.GetClient(), mgr.GetAPIReader(), r.OperatorNamespace(), ctrl.Log.WithName("notify"))); err != nil {
+		setupLog.Error(err, "unable to add notification dispatcher")
+		os.Exit(1)
+	}
+	hookRunner := hooks.NewRunner(mgr.GetClient(), mgr.GetEventRecorderFor("clusterscalingstate-controller"))
+	if err = mgr.Add(hookRunner); err != nil {
+		setupLog.Error(err, "unable to add transition hook runner")
+		os.Exit(1)
+	}
+	if apiAddr != "0" {
+		if err = mgr.Add(api.NewServer(mgr.GetClient(), apiAddr, apiCertFile, apiKeyFile, apiInsecure, ctrl.Log.WithName("api"))); err != nil {
+			setupLog.Error(err, "unable to add the management API")
+			os.Exit(1)
+		}
+	}
+
+	if err = (&controllers.PreScalingOperatorConfigReconciler{
 		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ClusterScalingStateDefinition"),
+		Log:      ctrl.Log.WithName("controllers").WithName("PreScalingOperatorConfig"),
 		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("clusterscalingstatedefinition-controller"),
+		Recorder: mgr.GetEventRecorderFor("prescalingoperatorconfig-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PreScalingOperatorConfig")
+		os.Exit(1)
+	}
+	if err = (&controllers.ClusterScalingStateDefinitionReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ClusterScalingStateDefinition"),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("clusterscalingstatedefinition-controller"),
+		APIReader: mgr.GetAPIReader(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterScalingStateDefinition")
 		os.Exit(1)
 	}
 	if err = (&controllers.ClusterScalingStateReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ClusterScalingState"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("clusterscalingstate-controller"),
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ClusterScalingState"),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("clusterscalingstate-controller"),
+		APIReader: mgr.GetAPIReader(),
+		Hooks:     hookRunner,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterScalingState")
 		os.Exit(1)
 	}
 	if err = (&controllers.ScalingStateReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ScalingState"),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("scalingstate-controller"),
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScalingState")
+		os.Exit(1)
+	}
+	if err = (&controllers.ScalingPlanReconciler{
 		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ScalingState"),
+		Log:      ctrl.Log.WithName("controllers").WithName("ScalingPlan"),
 		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("scalingstate-controller"),
+		Recorder: mgr.GetEventRecorderFor("scalingplan-controller"),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ScalingState")
+		setupLog.Error(err, "unable to create controller", "controller", "ScalingPlan")
+		os.Exit(1)
+	}
+	if err = (&controllers.ScalingSnapshotReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ScalingSnapshot"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("scalingsnapshot-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScalingSnapshot")
 		os.Exit(1)
 	}
 	if err = (&controllers.DeploymentWatcher{
@@ -124,6 +266,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controllers.NamespaceWatcher{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("NamespaceWatcher"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("namespace-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NamespaceWatcher")
+		os.Exit(1)
+	}
+
 	//We need to identify if the operator is running on an Openshift cluster. If yes, we activate the deploymentconfig watcher
 	constants.OpenshiftCluster, err = validations.OpenshiftClusterCheck()
 	if err != nil {
@@ -167,19 +319,12 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
-	z := cron.New()
-	z.AddFunc("@every 0h1m", func() {
-		r.RectifyScaleItemsInFailureState(mgr.GetClient(), mgr.GetEventRecorderFor("clusterscalingstatedefinition-controller"))
-	})
-	z.Start()
-
-	godotenv.Load("./.env")
-
 	constants.StartTime = time.Now()
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
+		shutdownTracing(context.Background())
 		os.Exit(1)
 	}
 
@@ -3,6 +3,7 @@ package global
 import (
 	"sync"
 
+	"github.com/containersol/prescale-operator/pkg/utils/math"
 	redisalpha "github.com/containersolutions/redis-operator/api/v1alpha1"
 	ocv1 "github.com/openshift/api/apps/v1"
 	v1 "k8s.io/api/apps/v1"
@@ -36,7 +37,13 @@ type ScalingInfo struct {
 	ScalingClass      string
 	ProgressDeadline  int32
 	ResourceList      corev1.ResourceList
+	PodTemplate       corev1.PodSpec
 	ConditionReason   string
+	RetryAttempts     int32
+	GaveUp            bool
+	QuotaShortfall    int32
+	Priority          int32
+	NamespaceOptIn    bool
 }
 
 // Global DenyList to check if the deployment is currently reconciles/step scaled
@@ -132,6 +139,21 @@ func (cs *ConcurrentSlice) RemoveFromList(item ScalingInfo) {
 	}
 }
 
+// RemoveUnlessFailed drops an item that's being scaled from the list. An item in failure state stays on it, so its failure,
+// retry attempts and GaveUp state survive. It's only no longer being scaled.
+func (cs *ConcurrentSlice) RemoveUnlessFailed(item ScalingInfo) {
+	itemFromList, err := cs.GetDeploymentInfoFromList(item)
+	if err != nil {
+		return
+	}
+	if !itemFromList.Failure {
+		cs.RemoveFromList(item)
+		return
+	}
+	itemFromList.IsBeingScaled = false
+	cs.Update(itemFromList)
+}
+
 func (cs *ConcurrentSlice) Update(item ScalingInfo) {
 	i := 0
 	for inList := range cs.Iter() {
@@ -247,6 +269,26 @@ func (cs *ConcurrentSlice) IsDeploymentInFailureState(item ScalingInfo) bool {
 	return itemToReturn.Failure
 }
 
+// HasGivenUp returns true if the operator stopped retrying the item. Only a manual retry brings it back.
+func (cs *ConcurrentSlice) HasGivenUp(item ScalingInfo) bool {
+	itemToReturn, err := cs.GetDeploymentInfoFromList(item)
+	if err != nil {
+		return false
+	}
+	return itemToReturn.GaveUp
+}
+
+// SetGaveUp puts the item in the terminal failure state after the operator used up all retry attempts
+func (cs *ConcurrentSlice) SetGaveUp(item ScalingInfo, attempts int32, failureMessage string) ScalingInfo {
+	item.IsBeingScaled = false
+	item.Failure = true
+	item.FailureMessage = failureMessage
+	item.RetryAttempts = attempts
+	item.GaveUp = true
+	cs.UpdateOrAppend(item)
+	return item
+}
+
 func (cs *ConcurrentSlice) GetDesiredReplicasFromList(item ScalingInfo) int32 {
 	itemToReturn, _ := cs.GetDeploymentInfoFromList(item)
 	return itemToReturn.DesiredReplicas
@@ -261,11 +303,7 @@ func ConvertDeploymentToItem(deployment v1.Deployment) ScalingInfo {
 		conditionReason = deployment.Status.Conditions[len(deployment.Status.Conditions)-1].Reason
 	}
 
-	var resourceList corev1.ResourceList = corev1.ResourceList{}
-	if len(deployment.Spec.Template.Spec.Containers) != 0 {
-		// get the latest condition reason in case there is one.
-		resourceList = deployment.Spec.Template.Spec.Containers[0].Resources.Limits
-	}
+	resourceList := math.PodQuotaResources(deployment.Spec.Template.Spec)
 
 	failure := false
 	failureMessage := ""
@@ -286,6 +324,7 @@ func ConvertDeploymentToItem(deployment v1.Deployment) ScalingInfo {
 		ReadyReplicas:    deployment.Status.AvailableReplicas,
 		DesiredReplicas:  -1,
 		ResourceList:     resourceList,
+		PodTemplate:      deployment.Spec.Template.Spec,
 		ConditionReason:  conditionReason,
 		ProgressDeadline: *deployment.Spec.ProgressDeadlineSeconds,
 	}
@@ -301,9 +340,10 @@ func ConvertDeploymentConfigToItem(deploymentConfig ocv1.DeploymentConfig) Scali
 	}
 
 	var resourceList corev1.ResourceList = corev1.ResourceList{}
-	if len(deploymentConfig.Spec.Template.Spec.Containers) != 0 {
-		// get the latest condition reason in case there is one.
-		resourceList = deploymentConfig.Spec.Template.Spec.Containers[0].Resources.Limits
+	var podTemplate corev1.PodSpec
+	if deploymentConfig.Spec.Template != nil {
+		podTemplate = deploymentConfig.Spec.Template.Spec
+		resourceList = math.PodQuotaResources(podTemplate)
 	}
 	var progressDeadLine int32 = 600
 	if deploymentConfig.Spec.Strategy.Type == "Rolling" {
@@ -330,6 +370,7 @@ func ConvertDeploymentConfigToItem(deploymentConfig ocv1.DeploymentConfig) Scali
 		ReadyReplicas:    deploymentConfig.Status.AvailableReplicas,
 		DesiredReplicas:  -1,
 		ResourceList:     resourceList,
+		PodTemplate:      podTemplate,
 		ConditionReason:  conditionReason,
 		ProgressDeadline: progressDeadLine,
 	}
@@ -337,11 +378,16 @@ func ConvertDeploymentConfigToItem(deploymentConfig ocv1.DeploymentConfig) Scali
 
 func ConvertRedisClusterToItem(rediscluster redisalpha.RedisCluster) ScalingInfo {
 
-	var resourceList corev1.ResourceList = corev1.ResourceList{}
-	if len(rediscluster.Spec.Resources.Limits) != 0 {
-		// get the latest condition reason in case there is one.
-		resourceList = rediscluster.Spec.Resources.Limits
+	// A redis node runs a single container with the resources of the spec
+	podTemplate := corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{
+				Requests: rediscluster.Spec.Resources.Requests,
+				Limits:   rediscluster.Spec.Resources.Limits,
+			},
+		}},
 	}
+	resourceList := math.PodQuotaResources(podTemplate)
 
 	failure := false
 	failureMessage := ""
@@ -359,6 +405,7 @@ func ConvertRedisClusterToItem(rediscluster redisalpha.RedisCluster) ScalingInfo
 		ReadyReplicas:    int32(len(rediscluster.Status.Nodes)),
 		DesiredReplicas:  -1,
 		ResourceList:     resourceList,
+		PodTemplate:      podTemplate,
 		ConditionReason:  conditionReason,
 		ProgressDeadline: int32(500),
 	}
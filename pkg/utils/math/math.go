@@ -24,11 +24,15 @@ func Subtract(a corev1.ResourceList, b corev1.ResourceList) corev1.ResourceList
 	return result
 }
 
+// Mul returns the resources times the factor. A negative factor gives the negated resources, like what scaling down frees.
 func Mul(times int32, resources corev1.ResourceList) corev1.ResourceList {
 	result := corev1.ResourceList{}
-	for i := 0; int32(i) < times; i++ {
+	for i := int32(0); i < Abs(times); i++ {
 		result = Add(result, resources)
 	}
+	if times < 0 {
+		return Subtract(corev1.ResourceList{}, result)
+	}
 	return result
 }
 
@@ -51,15 +55,113 @@ func Add(a corev1.ResourceList, b corev1.ResourceList) corev1.ResourceList {
 	return result
 }
 
+// TranslateResourcesToQuotaResources maps resources to the names ResourceQuotas use. Plain cpu and memory count as limits,
+// resources that already carry a quota name are kept as they are.
 func TranslateResourcesToQuotaResources(resources corev1.ResourceList) corev1.ResourceList {
 	result := make(corev1.ResourceList)
-	cpu, ok := resources[corev1.ResourceCPU]
-	if ok {
-		result[corev1.ResourceLimitsCPU] = cpu
+	for name, quantity := range resources {
+		switch name {
+		case corev1.ResourceCPU:
+			result[corev1.ResourceLimitsCPU] = quantity
+		case corev1.ResourceMemory:
+			result[corev1.ResourceLimitsMemory] = quantity
+		default:
+			result[name] = quantity
+		}
+	}
+	return result
+}
+
+// PodQuotaResources returns what one pod of the spec is charged in a ResourceQuota. Like the scheduler does it, that is the sum
+// of the containers or the biggest init container, whichever is larger, plus the pod overhead.
+// Every resource of the containers is charged as requests.<name> and limits.<name>, including ephemeral storage and extended
+// resources like nvidia.com/gpu. The claims of generic ephemeral volumes are charged like PersistentVolumeClaims.
+// Containers without requests are charged their limits, as the API server defaults the requests to the limits.
+// A spec without containers is not a complete pod template and is charged nothing.
+func PodQuotaResources(spec corev1.PodSpec) corev1.ResourceList {
+	if len(spec.Containers) == 0 {
+		return corev1.ResourceList{}
+	}
+	requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, container := range spec.Containers {
+		containerRequests, containerLimits := containerResources(container.Resources)
+		requests = Add(requests, containerRequests)
+		limits = Add(limits, containerLimits)
+	}
+	for _, container := range spec.InitContainers {
+		containerRequests, containerLimits := containerResources(container.Resources)
+		requests = Max(requests, containerRequests)
+		limits = Max(limits, containerLimits)
+	}
+	if spec.Overhead != nil {
+		requests = Add(requests, spec.Overhead)
+		limits = Add(limits, spec.Overhead)
+	}
+
+	result := corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")}
+	for name, quantity := range requests {
+		result[corev1.ResourceName(corev1.DefaultResourceRequestsPrefix+string(name))] = quantity
+	}
+	for name, quantity := range limits {
+		result[corev1.ResourceName(limitsPrefix+string(name))] = quantity
+	}
+
+	claims := []corev1.PersistentVolumeClaimSpec{}
+	for _, volume := range spec.Volumes {
+		if volume.Ephemeral != nil && volume.Ephemeral.VolumeClaimTemplate != nil {
+			claims = append(claims, volume.Ephemeral.VolumeClaimTemplate.Spec)
+		}
+	}
+	return Add(result, ClaimQuotaResources(claims))
+}
+
+const (
+	limitsPrefix      = "limits."
+	storageClassInfix = ".storageclass.storage.k8s.io/"
+)
+
+// ClaimQuotaResources returns what the PersistentVolumeClaims are charged in a ResourceQuota, in total and per storage class
+func ClaimQuotaResources(claims []corev1.PersistentVolumeClaimSpec) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, claim := range claims {
+		charged := corev1.ResourceList{corev1.ResourcePersistentVolumeClaims: resource.MustParse("1")}
+		if storage, ok := claim.Resources.Requests[corev1.ResourceStorage]; ok {
+			charged[corev1.ResourceRequestsStorage] = storage
+		}
+		result = Add(result, charged)
+		if claim.StorageClassName != nil && *claim.StorageClassName != "" {
+			// e.g. gold.storageclass.storage.k8s.io/requests.storage
+			perClass := corev1.ResourceList{}
+			for name, quantity := range charged {
+				perClass[corev1.ResourceName(*claim.StorageClassName+storageClassInfix+string(name))] = quantity
+			}
+			result = Add(result, perClass)
+		}
+	}
+	return result
+}
+
+func containerResources(resources corev1.ResourceRequirements) (corev1.ResourceList, corev1.ResourceList) {
+	requests := corev1.ResourceList{}
+	for key, value := range resources.Limits {
+		requests[key] = value.DeepCopy()
+	}
+	for key, value := range resources.Requests {
+		requests[key] = value.DeepCopy()
+	}
+	return requests, resources.Limits
+}
+
+// Max returns the larger quantity of every resource in a or b
+func Max(a corev1.ResourceList, b corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for key, value := range a {
+		result[key] = value.DeepCopy()
 	}
-	mem, ok := resources[corev1.ResourceMemory]
-	if ok {
-		result[corev1.ResourceLimitsMemory] = mem
+	for key, value := range b {
+		if current, found := result[key]; !found || value.Cmp(current) > 0 {
+			result[key] = value.DeepCopy()
+		}
 	}
 	return result
 }
@@ -75,6 +177,18 @@ func IsNegative(a corev1.ResourceList) []corev1.ResourceName {
 	return results
 }
 
+// IsPositive returns the resources of a with a quantity above zero
+func IsPositive(a corev1.ResourceList) []corev1.ResourceName {
+	results := []corev1.ResourceName{}
+	zero := resource.MustParse("0")
+	for k, v := range a {
+		if v.Cmp(zero) > 0 {
+			results = append(results, k)
+		}
+	}
+	return results
+}
+
 func IsZero(a corev1.ResourceList) bool {
 	zero := resource.MustParse("0")
 	for _, v := range a {
@@ -85,15 +199,10 @@ func IsZero(a corev1.ResourceList) bool {
 	return true
 }
 
+// ReplicaCalc returns the replica change from b to a. A change that keeps the replicas is 0, so it needs no resources,
+// and scaling to zero replicas frees all of them.
 func ReplicaCalc(a, b int32) int32 {
-
-	c := a - b
-
-	if c == 0 {
-		return a
-	}
-
-	return c
+	return a - b
 }
 
 // Abs returns the absolute value of x.
@@ -26,6 +26,14 @@ func TestMul(t *testing.T) {
 			},
 			want: map[corev1.ResourceName]resource.Quantity{},
 		},
+		{
+			name: "TestingNegativeMultiplication",
+			args: args{
+				times:     -2,
+				resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+			},
+			want: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("-1000m")},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -105,6 +113,20 @@ func TestIsNegative(t *testing.T) {
 	}
 }
 
+func TestIsPositive(t *testing.T) {
+	freed := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewQuantity(-2, resource.DecimalSI),
+		corev1.ResourcePods:   *resource.NewQuantity(0, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(500, resource.BinarySI),
+	}
+	if got := IsPositive(freed); !reflect.DeepEqual(got, []corev1.ResourceName{corev1.ResourceMemory}) {
+		t.Errorf("IsPositive() = %v, want [memory]", got)
+	}
+	if got := IsPositive(corev1.ResourceList{}); len(got) != 0 {
+		t.Errorf("IsPositive() of no resources = %v, want none", got)
+	}
+}
+
 func TestSubtract(t *testing.T) {
 	type args struct {
 		a corev1.ResourceList
@@ -256,7 +278,15 @@ func TestReplicaCalc(t *testing.T) {
 				a: 3,
 				b: 3,
 			},
-			want: 3,
+			want: 0,
+		},
+		{
+			name: "TestScaleToZero",
+			args: args{
+				a: 0,
+				b: 3,
+			},
+			want: -3,
 		},
 	}
 	for _, tt := range tests {
@@ -267,3 +297,110 @@ func TestReplicaCalc(t *testing.T) {
 		})
 	}
 }
+
+func TestPodQuotaResources(t *testing.T) {
+	gold := "gold"
+	resources := func(requests, limits string) corev1.ResourceRequirements {
+		r := corev1.ResourceRequirements{}
+		if requests != "" {
+			r.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(requests), corev1.ResourceMemory: resource.MustParse(requests + "Mi")}
+		}
+		if limits != "" {
+			r.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(limits), corev1.ResourceMemory: resource.MustParse(limits + "Mi")}
+		}
+		return r
+	}
+
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want map[corev1.ResourceName]string
+	}{
+		{
+			name: "TestSumOfContainers",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Resources: resources("1", "2")}, {Resources: resources("1", "3")}},
+			},
+			want: map[corev1.ResourceName]string{
+				corev1.ResourceRequestsCPU: "2", corev1.ResourceRequestsMemory: "2Mi",
+				corev1.ResourceLimitsCPU: "5", corev1.ResourceLimitsMemory: "5Mi",
+				corev1.ResourcePods: "1",
+			},
+		},
+		{
+			name: "TestBiggerInitContainerWins",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Resources: resources("4", "4")}, {Resources: resources("1", "1")}},
+				Containers:     []corev1.Container{{Resources: resources("1", "5")}},
+			},
+			want: map[corev1.ResourceName]string{
+				corev1.ResourceRequestsCPU: "4", corev1.ResourceRequestsMemory: "4Mi",
+				corev1.ResourceLimitsCPU: "5", corev1.ResourceLimitsMemory: "5Mi",
+				corev1.ResourcePods: "1",
+			},
+		},
+		{
+			name: "TestRequestsDefaultToLimitsAndOverheadIsAdded",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Resources: resources("", "2")}},
+				Overhead:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+			want: map[corev1.ResourceName]string{
+				corev1.ResourceRequestsCPU: "3", corev1.ResourceRequestsMemory: "2Mi",
+				corev1.ResourceLimitsCPU: "3", corev1.ResourceLimitsMemory: "2Mi",
+				corev1.ResourcePods: "1",
+			},
+		},
+		{
+			name: "TestNoResources",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{}},
+			},
+			want: map[corev1.ResourceName]string{
+				corev1.ResourcePods: "1",
+			},
+		},
+		{
+			name: "TestExtendedResourcesAndEphemeralVolumes",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1"), corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+				}}},
+				Volumes: []corev1.Volume{{VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{Spec: corev1.PersistentVolumeClaimSpec{
+						StorageClassName: &gold,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+						},
+					}},
+				}}}},
+			},
+			want: map[corev1.ResourceName]string{
+				"requests.nvidia.com/gpu": "1", "limits.nvidia.com/gpu": "1",
+				corev1.ResourceRequestsEphemeralStorage: "1Gi", corev1.ResourceLimitsEphemeralStorage: "1Gi",
+				corev1.ResourcePersistentVolumeClaims: "1", corev1.ResourceRequestsStorage: "10Gi",
+				"gold.storageclass.storage.k8s.io/persistentvolumeclaims": "1", "gold.storageclass.storage.k8s.io/requests.storage": "10Gi",
+				corev1.ResourcePods: "1",
+			},
+		},
+		{
+			name: "TestNoContainers",
+			spec: corev1.PodSpec{},
+			want: map[corev1.ResourceName]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PodQuotaResources(tt.spec)
+			if len(got) != len(tt.want) {
+				t.Errorf("PodQuotaResources() = %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				quantity := got[name]
+				if quantity.Cmp(resource.MustParse(want)) != 0 {
+					t.Errorf("PodQuotaResources()[%s] = %s, want %s", name, quantity.String(), want)
+				}
+			}
+		})
+	}
+}